@@ -0,0 +1,367 @@
+// Package backup 生成和恢复包含数据库快照、数据目录文件和清单的备份压缩包
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+
+	"backend/database"
+)
+
+// FormatVersion 备份压缩包的格式版本
+const FormatVersion = 1
+
+// 压缩包中的固定路径
+const (
+	manifestName = "manifest.json"
+	databaseName = "database/" + database.FileName
+	filesPrefix  = "files/"
+)
+
+// ErrNotFound 备份文件不存在
+var ErrNotFound = errors.New("备份文件不存在")
+
+// ErrInvalidName 备份文件名不合法
+var ErrInvalidName = errors.New("备份文件名不合法")
+
+// 备份和恢复互斥执行
+var mu sync.Mutex
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*\.zip$`)
+
+// Dir 备份文件目录，默认为数据目录下的backups
+func Dir() string {
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(database.DataDir(), "backups")
+}
+
+// Manifest 备份清单
+type Manifest struct {
+	Format        int         `json:"format"`
+	SchemaVersion int         `json:"schema_version"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Files         []FileEntry `json:"files"` // 包括数据库快照
+}
+
+// FileEntry 压缩包中的一个文件及其校验值
+type FileEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Info 备份目录中的一个备份文件
+type Info struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Path 返回备份目录中指定备份的路径，名称只能是文件名
+func Path(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", ErrInvalidName
+	}
+	path := filepath.Join(Dir(), name)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// Create 在备份目录中生成一个备份，prefix为文件名前缀
+func Create(prefix string) (Info, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return create(prefix)
+}
+
+func create(prefix string) (Info, error) {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return Info{}, err
+	}
+	now := time.Now()
+	name := prefix + "-" + now.Format("20060102-150405") + ".zip"
+	path := filepath.Join(Dir(), name)
+	for i := 2; fileExists(path); i++ {
+		name = prefix + "-" + now.Format("20060102-150405") + "-" + strconv.Itoa(i) + ".zip"
+		path = filepath.Join(Dir(), name)
+	}
+
+	// 先写入临时文件，完成后再改名，未完成的备份不会出现在列表中
+	tmp := path + ".tmp"
+	if err := writeArchive(tmp, now); err != nil {
+		os.Remove(tmp)
+		return Info{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Info{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Name: name, Size: info.Size(), CreatedAt: now}, nil
+}
+
+// WriteTo 生成备份并写入指定文件，用于命令行
+func WriteTo(path string) (Manifest, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := writeArchive(path, time.Now()); err != nil {
+		os.Remove(path)
+		return Manifest{}, err
+	}
+	return ReadManifest(path)
+}
+
+// List 按时间倒序列出备份目录中的备份
+func List() ([]Info, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backups := []Info{}
+	for _, entry := range entries {
+		if entry.IsDir() || !namePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Delete 删除备份目录中的备份
+func Delete(name string) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// 生成备份压缩包：数据库快照、数据目录中的其他文件和清单
+func writeArchive(path string, now time.Time) error {
+	snapshot, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*.db")
+	if err != nil {
+		return err
+	}
+	snapshot.Close()
+	defer os.Remove(snapshot.Name())
+	if err := snapshotDatabase(snapshot.Name()); err != nil {
+		return err
+	}
+	version, err := schemaVersion(snapshot.Name())
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	manifest := Manifest{Format: FormatVersion, SchemaVersion: version, CreatedAt: now, Files: []FileEntry{}}
+
+	add := func(name, source string) error {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		hash := sha256.New()
+		size, err := io.Copy(io.MultiWriter(w, hash), f)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, FileEntry{Path: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))})
+		return nil
+	}
+
+	if err := add(databaseName, snapshot.Name()); err != nil {
+		return err
+	}
+	files, err := dataFiles()
+	if err != nil {
+		return err
+	}
+	for _, rel := range files {
+		if err := add(filesPrefix+rel, filepath.Join(database.DataDir(), filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+	}
+
+	// 清单放在最后，包含前面所有文件的校验值
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// 数据目录中不属于作品集数据的位置：备份目录、自动生成的静态站点(目录或.zip)和开发模式的邮件输出目录。
+// 这些位置既不备份，恢复时也不会删除其中的文件
+func excludedPaths() map[string]bool {
+	excluded := map[string]bool{}
+	for _, p := range []string{Dir(), os.Getenv("STATIC_EXPORT_DIR"), os.Getenv("MAIL_DEV_DIR")} {
+		if p == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			excluded[abs] = true
+		}
+	}
+	return excluded
+}
+
+// 数据目录中需要备份的文件(如上传的文件)，不含数据库文件和excludedPaths，返回以/分隔的相对路径。
+// 恢复时只删除这个范围内、备份中没有的文件
+func dataFiles() ([]string, error) {
+	root := database.DataDir()
+	excluded := excludedPaths()
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if abs, _ := filepath.Abs(path); excluded[abs] {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isDatabaseFile(rel) || strings.HasPrefix(entry.Name(), ".snapshot-") || strings.HasPrefix(entry.Name(), ".restore-") {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// 数据库文件及其日志文件
+func isDatabaseFile(rel string) bool {
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if rel == database.FileName+suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// 使用SQLite在线备份接口复制数据库，服务运行时也能得到一致的快照
+func snapshotDatabase(dest string) error {
+	destDB, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+	return copyDatabase(destDB, database.DB)
+}
+
+// 将src的main数据库整体复制到dest
+func copyDatabase(dest, src *sql.DB) error {
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw interface{}) error {
+		return srcConn.Raw(func(srcRaw interface{}) error {
+			destSQLite, ok1 := destRaw.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return errors.New("数据库驱动不支持在线备份")
+			}
+			b, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			// 一次复制全部页面，复制期间源数据库的写入会等待，不会得到一半新一半旧的内容
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+}
+
+// 读取数据库文件记录的结构版本
+func schemaVersion(path string) (int, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	var version int
+	err = db.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
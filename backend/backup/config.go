@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config 定时备份配置，全部从环境变量读取
+type Config struct {
+	Schedule  string    // cron表达式，留空则不定时备份
+	Retention Retention // 定时备份的保留策略，本地和异地存储相同
+	S3        S3Config
+}
+
+// S3Config 异地备份的S3兼容存储(AWS S3、MinIO等)
+type S3Config struct {
+	Endpoint  string // 服务地址，如http://localhost:9000，留空则使用AWS S3
+	Region    string
+	Bucket    string
+	Prefix    string // 对象键前缀，如resume/
+	AccessKey string
+	SecretKey string
+	PathStyle bool // 使用路径形式(endpoint/bucket/key)访问，MinIO需要开启
+}
+
+// LoadConfig 从环境变量加载定时备份配置
+func LoadConfig() Config {
+	region := os.Getenv("BACKUP_S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	prefix := strings.TrimPrefix(os.Getenv("BACKUP_S3_PREFIX"), "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	endpoint := strings.TrimSuffix(os.Getenv("BACKUP_S3_ENDPOINT"), "/")
+
+	return Config{
+		Schedule: strings.TrimSpace(os.Getenv("BACKUP_SCHEDULE")),
+		Retention: Retention{
+			Daily:   envInt("BACKUP_KEEP_DAILY", 7),
+			Weekly:  envInt("BACKUP_KEEP_WEEKLY", 4),
+			Monthly: envInt("BACKUP_KEEP_MONTHLY", 6),
+		},
+		S3: S3Config{
+			Endpoint:  endpoint,
+			Region:    region,
+			Bucket:    os.Getenv("BACKUP_S3_BUCKET"),
+			Prefix:    prefix,
+			AccessKey: os.Getenv("BACKUP_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("BACKUP_S3_SECRET_KEY"),
+			// 自定义服务地址时默认使用路径形式
+			PathStyle: envBool("BACKUP_S3_PATH_STYLE", endpoint != ""),
+		},
+	}
+}
+
+// Enabled 是否配置了异地存储
+func (c S3Config) Enabled() bool {
+	return c.Bucket != ""
+}
+
+func envInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return fallback
+	}
+	return v
+}
+
+func envBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,139 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name, expr, from string
+		want             []string // 依次调用Next得到的时间
+	}{
+		{"步长", "*/15 * * * *", "2026-10-16 10:07", []string{"2026-10-16 10:15", "2026-10-16 10:30", "2026-10-16 10:45", "2026-10-16 11:00"}},
+		{"起始值加步长", "5/20 9 * * *", "2026-10-16 09:00", []string{"2026-10-16 09:05", "2026-10-16 09:25", "2026-10-16 09:45", "2026-10-17 09:05"}},
+		{"范围加步长", "0 8-18/4 * * *", "2026-10-16 09:00", []string{"2026-10-16 12:00", "2026-10-16 16:00", "2026-10-17 08:00"}},
+		{"列表", "0 9,21 * * *", "2026-10-16 09:00", []string{"2026-10-16 21:00", "2026-10-17 09:00"}},
+		{"严格晚于起始时间", "0 3 * * *", "2026-10-16 03:00", []string{"2026-10-17 03:00"}},
+		{"忽略秒", "0 3 * * *", "2026-10-16 02:59", []string{"2026-10-16 03:00"}},
+		{"星期名称范围", "30 9 * * mon-fri", "2026-10-16 10:00", []string{"2026-10-19 09:30", "2026-10-20 09:30"}},
+		{"星期日写作7", "0 0 * * 7", "2026-10-16 00:00", []string{"2026-10-18 00:00", "2026-10-25 00:00"}},
+		{"月份名称", "0 0 1 jan,JUL *", "2026-10-16 00:00", []string{"2027-01-01 00:00", "2027-07-01 00:00"}},
+		{"跳过没有31日的月份", "0 12 31 * *", "2026-10-31 12:00", []string{"2026-12-31 12:00", "2027-01-31 12:00", "2027-03-31 12:00"}},
+		{"闰年2月29日", "0 0 29 2 *", "2026-10-16 00:00", []string{"2028-02-29 00:00"}},
+		// 日和星期都有限制时满足其一即可：13日或星期五
+		{"日和星期取并集", "0 0 13 * fri", "2026-11-08 00:00", []string{"2026-11-13 00:00", "2026-11-20 00:00", "2026-11-27 00:00", "2026-12-04 00:00", "2026-12-11 00:00", "2026-12-13 00:00"}},
+		// 只限制其中一项时另一项不参与匹配
+		{"只限制日", "0 0 13 * *", "2026-11-08 00:00", []string{"2026-11-13 00:00", "2026-12-13 00:00"}},
+		{"只限制星期", "0 0 * * fri", "2026-11-08 00:00", []string{"2026-11-13 00:00", "2026-11-20 00:00"}},
+		{"问号等同星号", "0 0 ? * fri", "2026-11-08 00:00", []string{"2026-11-13 00:00", "2026-11-20 00:00"}},
+		{"简写", "@weekly", "2026-10-16 10:00", []string{"2026-10-18 00:00", "2026-10-25 00:00"}},
+		{"简写不区分大小写", "@Monthly", "2026-10-16 10:00", []string{"2026-11-01 00:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			cur := at(tt.from)
+			for i, want := range tt.want {
+				cur = c.Next(cur)
+				if !cur.Equal(at(want)) {
+					t.Fatalf("第%d次Next = %s, 期望 %s", i+1, cur.Format("2006-01-02 15:04 Mon"), want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronNextNoMatch(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := c.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Errorf("2月30日不存在，Next = %s, 期望零值", next)
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04 MST", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name, expr string
+		from       time.Time
+		want       []time.Time
+	}{
+		// 2026-03-08 02:00 EST调快到03:00 EDT，02:30不存在，顺延到03:30执行
+		{"夏令时开始", "30 2 * * *", at("2026-03-07 03:00 EST"),
+			[]time.Time{at("2026-03-08 03:30 EDT"), at("2026-03-09 02:30 EDT")}},
+		{"夏令时开始当天的其他时刻", "0 4 * * *", at("2026-03-07 05:00 EST"),
+			[]time.Time{at("2026-03-08 04:00 EDT"), at("2026-03-09 04:00 EDT")}},
+		// 2026-11-01 02:00 EDT调回01:00 EST，01:30出现两次，只执行一次
+		{"夏令时结束", "30 1 * * *", at("2026-11-01 00:00 EDT"),
+			[]time.Time{at("2026-11-01 01:30 EDT"), at("2026-11-02 01:30 EST")}},
+		{"夏令时结束时重复的一小时内启动", "30 1 * * *", at("2026-11-01 01:00 EST"),
+			[]time.Time{at("2026-11-02 01:30 EST")}},
+		{"夏令时结束后按钟面时间每小时执行", "0 * * * *", at("2026-11-01 00:30 EDT"),
+			[]time.Time{at("2026-11-01 01:00 EDT"), at("2026-11-01 02:00 EST"), at("2026-11-01 03:00 EST")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cur := tt.from
+			for i, want := range tt.want {
+				cur = c.Next(cur)
+				if !cur.Equal(want) {
+					t.Fatalf("第%d次Next = %s, 期望 %s", i+1, cur.Format("2006-01-02 15:04 MST"), want.Format("2006-01-02 15:04 MST"))
+				}
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"* * * * mon-",
+		"@reboot",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) 应返回错误", expr)
+		}
+	}
+}
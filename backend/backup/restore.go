@@ -0,0 +1,412 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"backend/database"
+)
+
+// ErrInvalidArchive 备份压缩包无效
+var ErrInvalidArchive = errors.New("备份压缩包无效")
+
+// ArchiveError 备份压缩包校验失败的原因
+type ArchiveError struct {
+	Reason string
+}
+
+func (e *ArchiveError) Error() string {
+	return ErrInvalidArchive.Error() + ": " + e.Reason
+}
+
+func (e *ArchiveError) Unwrap() error {
+	return ErrInvalidArchive
+}
+
+func invalid(reason string) error {
+	return &ArchiveError{Reason: reason}
+}
+
+// 恢复后执行的迁移，由主程序设置为启动时的建表和初始化步骤
+var migrator = database.Migrate
+
+// SetMigrator 设置恢复后执行的迁移，应包含启动时所有创建表和索引的步骤
+func SetMigrator(fn func() error) {
+	migrator = fn
+}
+
+// RestoreResult 恢复结果
+type RestoreResult struct {
+	Manifest      Manifest `json:"manifest"`
+	FromVersion   int      `json:"from_version"`  // 备份中的结构版本
+	ToVersion     int      `json:"to_version"`    // 当前结构版本
+	Migrated      bool     `json:"migrated"`      // 备份较旧，恢复后执行了迁移
+	SafetyBackup  string   `json:"safety_backup"` // 恢复前自动生成的备份，用于撤销恢复
+	RestoredFiles int      `json:"restored_files"`
+	RemovedFiles  int      `json:"removed_files"`
+}
+
+// ReadManifest 读取备份压缩包中的清单，不做校验
+func ReadManifest(archive string) (Manifest, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return Manifest{}, invalid("无法读取压缩包")
+	}
+	defer zr.Close()
+	return readManifest(&zr.Reader)
+}
+
+func readManifest(zr *zip.Reader) (Manifest, error) {
+	var manifest Manifest
+	for _, f := range zr.File {
+		if f.Name != manifestName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return manifest, err
+		}
+		defer rc.Close()
+		if err := json.NewDecoder(io.LimitReader(rc, 16<<20)).Decode(&manifest); err != nil {
+			return manifest, invalid("清单格式错误")
+		}
+		return manifest, nil
+	}
+	return manifest, invalid("缺少" + manifestName)
+}
+
+// Validate 校验备份压缩包：清单格式、文件完整性、数据库可用且结构版本不高于当前版本
+func Validate(archive string) (Manifest, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return Manifest{}, invalid("无法读取压缩包")
+	}
+	defer zr.Close()
+
+	manifest, err := verifyArchive(&zr.Reader)
+	if err != nil {
+		return manifest, err
+	}
+
+	tmp, err := extractDatabase(&zr.Reader, filepath.Dir(archive))
+	if err != nil {
+		return manifest, err
+	}
+	defer os.Remove(tmp)
+	return manifest, checkDatabase(tmp, manifest)
+}
+
+// 校验清单和每个文件的大小与校验值
+func verifyArchive(zr *zip.Reader) (Manifest, error) {
+	manifest, err := readManifest(zr)
+	if err != nil {
+		return manifest, err
+	}
+	if manifest.Format != FormatVersion {
+		return manifest, invalid("不支持的备份格式版本" + strconv.Itoa(manifest.Format))
+	}
+	if manifest.SchemaVersion > database.SchemaVersion {
+		return manifest, invalid("备份的数据库结构版本" + strconv.Itoa(manifest.SchemaVersion) +
+			"高于当前程序支持的版本" + strconv.Itoa(database.SchemaVersion) + "，请先升级程序")
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	hasDatabase := false
+	for _, entry := range manifest.Files {
+		if entry.Path == databaseName {
+			hasDatabase = true
+		} else if !safeFilePath(entry.Path) {
+			return manifest, invalid("文件路径不合法: " + entry.Path)
+		}
+		f, ok := files[entry.Path]
+		if !ok {
+			return manifest, invalid("缺少文件" + entry.Path)
+		}
+		sum, size, err := checksum(f)
+		if err != nil {
+			return manifest, invalid("读取" + entry.Path + "失败")
+		}
+		if size != entry.Size || sum != entry.SHA256 {
+			return manifest, invalid(entry.Path + "校验值不匹配，文件可能已损坏")
+		}
+	}
+	if !hasDatabase {
+		return manifest, invalid("缺少数据库快照")
+	}
+	return manifest, nil
+}
+
+// 数据文件只能位于files/下，不能跳出数据目录
+func safeFilePath(name string) bool {
+	if !strings.HasPrefix(name, filesPrefix) || strings.Contains(name, "\\") {
+		return false
+	}
+	rel := strings.TrimPrefix(name, filesPrefix)
+	return rel != "" && path.Clean(rel) == rel && !strings.HasPrefix(rel, "../") && rel != ".." &&
+		!path.IsAbs(rel) && !isDatabaseFile(rel) && !inBackupDir(rel)
+}
+
+// 备份目录位于数据目录中时，不能通过恢复写入备份目录
+func inBackupDir(rel string) bool {
+	root, _ := filepath.Abs(database.DataDir())
+	dir, _ := filepath.Abs(Dir())
+	prefix, err := filepath.Rel(root, dir)
+	if err != nil || strings.HasPrefix(prefix, "..") {
+		return false
+	}
+	prefix = filepath.ToSlash(prefix)
+	return rel == prefix || strings.HasPrefix(rel, prefix+"/")
+}
+
+func checksum(f *zip.File) (string, int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", 0, err
+	}
+	defer rc.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, rc)
+	return hex.EncodeToString(hash.Sum(nil)), size, err
+}
+
+// 将数据库快照解压到临时文件
+func extractDatabase(zr *zip.Reader, dir string) (string, error) {
+	for _, f := range zr.File {
+		if f.Name != databaseName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		tmp, err := os.CreateTemp(dir, ".restore-*.db")
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(tmp, rc); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return "", err
+		}
+		return tmp.Name(), tmp.Close()
+	}
+	return "", invalid("缺少数据库快照")
+}
+
+// 数据库快照必须完整、可以打开，并且包含作品集数据
+func checkDatabase(file string, manifest Manifest) error {
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		return invalid("无法打开数据库快照")
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil || result != "ok" {
+		return invalid("数据库快照完整性检查失败")
+	}
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != manifest.SchemaVersion {
+		return invalid("数据库快照的结构版本与清单不一致")
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'profile'").Scan(&count); err != nil || count == 0 {
+		return invalid("数据库快照中没有作品集数据")
+	}
+	return nil
+}
+
+// Restore 校验并恢复备份：先自动备份当前数据，再用快照替换数据库、按清单还原数据目录中的文件，
+// 最后执行迁移使较旧的备份与当前程序的结构一致
+func Restore(archive string) (RestoreResult, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := RestoreResult{ToVersion: database.SchemaVersion}
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return result, invalid("无法读取压缩包")
+	}
+	defer zr.Close()
+
+	manifest, err := verifyArchive(&zr.Reader)
+	if err != nil {
+		return result, err
+	}
+	result.Manifest, result.FromVersion = manifest, manifest.SchemaVersion
+
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return result, err
+	}
+	tmp, err := extractDatabase(&zr.Reader, Dir())
+	if err != nil {
+		return result, err
+	}
+	defer os.Remove(tmp)
+	if err := checkDatabase(tmp, manifest); err != nil {
+		return result, err
+	}
+
+	safety, err := create("pre-restore")
+	if err != nil {
+		return result, errors.New("恢复前备份当前数据失败: " + err.Error())
+	}
+	result.SafetyBackup = safety.Name
+
+	// 备份任务记录是本机的备份历史，不随作品集数据恢复，否则连续失败次数等状态会被备份中的旧记录替换
+	runs, err := saveRuns()
+	if err != nil {
+		return result, errors.New("读取备份任务记录失败: " + err.Error())
+	}
+
+	source, err := sql.Open("sqlite3", tmp)
+	if err != nil {
+		return result, err
+	}
+	err = copyDatabase(database.DB, source)
+	source.Close()
+	if err != nil {
+		return result, errors.New("替换数据库失败: " + err.Error())
+	}
+
+	if result.RestoredFiles, result.RemovedFiles, err = restoreFiles(&zr.Reader, manifest); err != nil {
+		return result, errors.New("还原数据文件失败: " + err.Error())
+	}
+
+	if err := migrator(); err != nil {
+		return result, errors.New("迁移数据库结构失败: " + err.Error())
+	}
+	if err := runs.restore(); err != nil {
+		return result, errors.New("还原备份任务记录失败: " + err.Error())
+	}
+	result.Migrated = manifest.SchemaVersion < database.SchemaVersion
+	return result, nil
+}
+
+// 恢复前保存的备份任务记录
+type savedRuns struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func saveRuns() (*savedRuns, error) {
+	rows, err := database.DB.Query("SELECT * FROM backup_runs ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	saved := &savedRuns{}
+	if saved.columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(saved.columns))
+		pointers := make([]interface{}, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		saved.rows = append(saved.rows, values)
+	}
+	return saved, rows.Err()
+}
+
+// 用保存的记录替换恢复出的记录，保留原ID，恢复期间正在执行的任务完成后仍能更新自己的记录
+func (s *savedRuns) restore() error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM backup_runs"); err != nil {
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(s.columns)), ", ")
+	insert := "INSERT INTO backup_runs (" + strings.Join(s.columns, ", ") + ") VALUES (" + placeholders + ")"
+	for _, row := range s.rows {
+		if _, err := tx.Exec(insert, row...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// 按清单还原数据目录中的文件，并删除备份范围内(dataFiles)、备份中没有的文件，使作品集数据与备份时一致
+func restoreFiles(zr *zip.Reader, manifest Manifest) (restored, removed int, err error) {
+	root := database.DataDir()
+	wanted := map[string]bool{}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	for _, entry := range manifest.Files {
+		if entry.Path == databaseName {
+			continue
+		}
+		rel := strings.TrimPrefix(entry.Path, filesPrefix)
+		wanted[rel] = true
+		target := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return restored, removed, err
+		}
+		if err := extractFile(files[entry.Path], target); err != nil {
+			return restored, removed, err
+		}
+		restored++
+	}
+
+	current, err := dataFiles()
+	if err != nil {
+		return restored, removed, err
+	}
+	for _, rel := range current {
+		if !wanted[rel] {
+			if err := os.Remove(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+				return restored, removed, err
+			}
+			removed++
+		}
+	}
+	return restored, removed, nil
+}
+
+// 先写入临时文件再改名，避免留下写了一半的文件
+func extractFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	tmp := target + ".restore-tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, target)
+}
@@ -0,0 +1,166 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend/database"
+)
+
+func TestSafeFilePath(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("DATA_DIR", root)
+	t.Setenv("BACKUP_DIR", "")
+
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"files/uploads/avatar.png", true},
+		{"files/avatar.jpg", true},
+		{"files/uploads/2026/10/a b.pdf", true},
+		{"files/backups.txt", true},
+
+		{"files/../x", false},
+		{"files/uploads/../../x", false},
+		{"files/..", false},
+		{"files/", false},
+		{"files/./a", false},
+		{"files/uploads//a", false},
+		{"files/uploads/a/", false},
+		{"files//etc/passwd", false},
+		{"/etc/passwd", false},
+		{"/files/a", false},
+		{"x/files/a", false},
+		{"database/resume.db", false},
+		{"manifest.json", false},
+		{`files/uploads\..\..\x`, false},
+		{`files\uploads\a.png`, false},
+		// 数据库文件及其日志文件
+		{"files/resume.db", false},
+		{"files/resume.db-wal", false},
+		{"files/resume.db-journal", false},
+		{"files/resume.db-shm", false},
+		// 位于数据目录中的备份目录
+		{"files/backups", false},
+		{"files/backups/scheduled-20261016-030000.zip", false},
+	}
+	for _, tt := range tests {
+		if got := safeFilePath(tt.name); got != tt.ok {
+			t.Errorf("safeFilePath(%q) = %v, 期望 %v", tt.name, got, tt.ok)
+		}
+	}
+
+	// 备份目录在数据目录之外时不限制backups路径
+	t.Setenv("BACKUP_DIR", filepath.Join(t.TempDir(), "backups"))
+	if !safeFilePath("files/backups/a.zip") {
+		t.Error("备份目录在数据目录之外时 files/backups/a.zip 应被允许")
+	}
+	// 自定义的备份目录位于数据目录中
+	t.Setenv("BACKUP_DIR", filepath.Join(root, "archive", "zips"))
+	for name, want := range map[string]bool{
+		"files/archive/zips/a.zip": false,
+		"files/archive/zips":       false,
+		"files/archive/other.txt":  true,
+		"files/archive/zipsx/a":    true,
+	} {
+		if got := safeFilePath(name); got != want {
+			t.Errorf("BACKUP_DIR=archive/zips 时 safeFilePath(%q) = %v, 期望 %v", name, got, want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreFiles(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("DATA_DIR", root)
+	t.Setenv("BACKUP_DIR", "")
+	t.Setenv("STATIC_EXPORT_DIR", filepath.Join(root, "site"))
+	t.Setenv("MAIL_DEV_DIR", filepath.Join(root, "outbox"))
+
+	// 恢复前数据目录中的文件
+	writeFile(t, filepath.Join(root, "uploads", "avatar.png"), "旧头像")
+	writeFile(t, filepath.Join(root, "uploads", "stray.png"), "备份后上传的文件")
+	writeFile(t, filepath.Join(root, database.FileName), "数据库")
+	writeFile(t, filepath.Join(root, database.FileName+"-wal"), "日志")
+	writeFile(t, filepath.Join(root, "backups", "scheduled-20261016-030000.zip"), "备份")
+	writeFile(t, filepath.Join(root, "site", "index.html"), "静态站点")
+	writeFile(t, filepath.Join(root, "outbox", "mail.eml"), "邮件")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifest := Manifest{Files: []FileEntry{{Path: databaseName}}}
+	for name, content := range map[string]string{
+		databaseName:                 "快照",
+		"files/uploads/avatar.png":   "新头像",
+		"files/uploads/2026/cv.pdf":  "简历",
+		"files/uploads/unlisted.txt": "不在清单中",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+		if name != databaseName && name != "files/uploads/unlisted.txt" {
+			manifest.Files = append(manifest.Files, FileEntry{Path: name})
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, removed, err := restoreFiles(zr, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != 2 || removed != 1 {
+		t.Errorf("恢复 %d 个、删除 %d 个文件, 期望 2 和 1", restored, removed)
+	}
+
+	for rel, want := range map[string]string{
+		"uploads/avatar.png":                    "新头像",
+		"uploads/2026/cv.pdf":                   "简历",
+		database.FileName:                       "数据库",
+		database.FileName + "-wal":              "日志",
+		"backups/scheduled-20261016-030000.zip": "备份",
+		"site/index.html":                       "静态站点",
+		"outbox/mail.eml":                       "邮件",
+	} {
+		got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Errorf("%s 不应被删除: %v", rel, err)
+		} else if string(got) != want {
+			t.Errorf("%s 的内容 = %q, 期望 %q", rel, got, want)
+		}
+	}
+	for _, rel := range []string{"uploads/stray.png", "uploads/unlisted.txt"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel))); !os.IsNotExist(err) {
+			t.Errorf("%s 应不存在, 实际: %v", rel, err)
+		}
+	}
+
+	// 恢复后数据目录中需要备份的文件与清单一致
+	files, err := dataFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(files); got != 2 || files[0] != "uploads/2026/cv.pdf" || files[1] != "uploads/avatar.png" {
+		t.Errorf("恢复后的dataFiles() = %v", files)
+	}
+}
@@ -0,0 +1,89 @@
+package backup
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 定时备份的文件名前缀，只有这些备份按保留策略清理，手动备份和恢复前的备份不会被自动删除
+const schedulePrefix = "scheduled"
+
+var scheduledPattern = regexp.MustCompile(`^` + schedulePrefix + `-(\d{8}-\d{6})(?:-(\d+))?\.zip$`)
+
+// Retention 保留策略：每个周期保留最后一个备份，三者取并集。全部为0时不清理
+type Retention struct {
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
+// 从定时备份的文件名中解析生成时间和序号，同一秒内生成的多个备份依次带-1、-2等序号
+func scheduledTime(name string) (time.Time, int, bool) {
+	m := scheduledPattern.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation("20060102-150405", m[1], time.Local)
+	seq, _ := strconv.Atoi(m[2])
+	return t, seq, err == nil
+}
+
+// expired 按保留策略返回应删除的定时备份，其他名称的文件不会被删除，最新的备份始终保留
+func expired(names []string, r Retention) []string {
+	if r.Daily == 0 && r.Weekly == 0 && r.Monthly == 0 {
+		return nil
+	}
+
+	type entry struct {
+		name string
+		at   time.Time
+		seq  int
+	}
+	var backups []entry
+	for _, name := range names {
+		if at, seq, ok := scheduledTime(name); ok {
+			backups = append(backups, entry{name, at, seq})
+		}
+	}
+	if len(backups) == 0 {
+		return nil
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].at.Equal(backups[j].at) {
+			return backups[i].seq > backups[j].seq
+		}
+		return backups[i].at.After(backups[j].at)
+	})
+
+	keep := map[string]bool{backups[0].name: true}
+	// 从新到旧遍历，每个周期的第一个即该周期最后生成的备份
+	pick := func(count int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for _, b := range backups {
+			if len(seen) >= count {
+				return
+			}
+			key := period(b.at)
+			if !seen[key] {
+				seen[key] = true
+				keep[b.name] = true
+			}
+		}
+	}
+	pick(r.Daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	pick(r.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return strconv.Itoa(year) + "-W" + strconv.Itoa(week)
+	})
+	pick(r.Monthly, func(t time.Time) string { return t.Format("2006-01") })
+
+	var remove []string
+	for _, b := range backups {
+		if !keep[b.name] {
+			remove = append(remove, b.name)
+		}
+	}
+	return remove
+}
@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExpired(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		policy Retention
+		want   []string
+	}{
+		{
+			name:   "未设置保留策略时不清理",
+			files:  []string{"scheduled-20261001-030000.zip", "scheduled-20261016-030000.zip"},
+			policy: Retention{},
+			want:   nil,
+		},
+		{
+			name: "只清理定时备份",
+			files: []string{
+				"scheduled-20261016-030000.zip",
+				"scheduled-20261015-030000.zip",
+				"backup-20261001-120000.zip",
+				"pre-restore-20261002-120000.zip",
+				"scheduled-latest.zip",
+				"notes.txt",
+			},
+			policy: Retention{Daily: 1},
+			want:   []string{"scheduled-20261015-030000.zip"},
+		},
+		{
+			name: "同一天保留最后一个",
+			files: []string{
+				"scheduled-20261016-010000.zip",
+				"scheduled-20261016-130000.zip",
+				"scheduled-20261016-130000-1.zip",
+				"scheduled-20261015-230000.zip",
+			},
+			policy: Retention{Daily: 2},
+			want:   []string{"scheduled-20261016-010000.zip", "scheduled-20261016-130000.zip"},
+		},
+		{
+			name: "按天保留",
+			files: []string{
+				"scheduled-20261012-030000.zip",
+				"scheduled-20261013-030000.zip",
+				"scheduled-20261014-030000.zip",
+				"scheduled-20261015-030000.zip",
+				"scheduled-20261016-030000.zip",
+			},
+			policy: Retention{Daily: 3},
+			want:   []string{"scheduled-20261012-030000.zip", "scheduled-20261013-030000.zip"},
+		},
+		{
+			// 按ISO周划分，周一开始新的一周
+			name: "按周保留",
+			files: []string{
+				"scheduled-20261019-030000.zip", // 周一，第43周
+				"scheduled-20261018-030000.zip", // 周日，第42周
+				"scheduled-20261012-030000.zip", // 周一，第42周
+				"scheduled-20261011-030000.zip", // 周日，第41周
+				"scheduled-20261004-030000.zip", // 第40周
+			},
+			policy: Retention{Weekly: 2},
+			want: []string{
+				"scheduled-20261004-030000.zip",
+				"scheduled-20261011-030000.zip",
+				"scheduled-20261012-030000.zip",
+			},
+		},
+		{
+			name: "按月保留",
+			files: []string{
+				"scheduled-20261016-030000.zip",
+				"scheduled-20261001-030000.zip",
+				"scheduled-20260930-030000.zip",
+				"scheduled-20260901-030000.zip",
+				"scheduled-20260815-030000.zip",
+			},
+			policy: Retention{Monthly: 2},
+			want: []string{
+				"scheduled-20260815-030000.zip",
+				"scheduled-20260901-030000.zip",
+				"scheduled-20261001-030000.zip",
+			},
+		},
+		{
+			name: "多个周期取并集",
+			files: []string{
+				"scheduled-20261016-030000.zip",
+				"scheduled-20261015-030000.zip",
+				"scheduled-20261014-030000.zip",
+				"scheduled-20260930-030000.zip",
+				"scheduled-20260915-030000.zip",
+				"scheduled-20260831-030000.zip",
+			},
+			policy: Retention{Daily: 2, Monthly: 3},
+			want: []string{
+				"scheduled-20260915-030000.zip",
+				"scheduled-20261014-030000.zip",
+			},
+		},
+		{
+			name: "最新的备份始终保留",
+			files: []string{
+				"scheduled-20261016-030000.zip",
+			},
+			policy: Retention{Weekly: 1},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expired(tt.files, tt.policy)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expired() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package backup
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/database"
+)
+
+// 备份任务的触发方式
+const (
+	TriggerSchedule = "schedule" // 按cron表达式定时执行
+	TriggerManual   = "manual"   // 管理员手动执行
+)
+
+// 备份任务状态
+const (
+	RunRunning = "running"
+	RunSuccess = "success"
+	RunFailed  = "failed"
+)
+
+const (
+	checkInterval = 20 * time.Second // 检查是否到达执行时间的间隔
+	keepRuns      = 100              // 保留的任务记录数
+)
+
+// ErrRunning 已有备份任务正在执行
+var ErrRunning = errors.New("已有备份任务正在执行")
+
+var (
+	cfg         Config
+	schedule    *Cron
+	scheduleErr string
+
+	state   sync.Mutex
+	nextRun time.Time
+	running bool
+)
+
+// Run 一次备份任务：生成备份、上传到异地存储并按保留策略清理旧备份
+type Run struct {
+	ID           int        `json:"id"`
+	Trigger      string     `json:"trigger"`
+	Status       string     `json:"status"`
+	Backup       string     `json:"backup,omitempty"`
+	Size         int64      `json:"size"`
+	Uploaded     bool       `json:"uploaded"`      // 已上传到异地存储
+	Pruned       int        `json:"pruned"`        // 本地清理的旧备份数
+	RemotePruned int        `json:"remote_pruned"` // 异地存储清理的旧备份数
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// Remote 异地存储的位置，不包含密钥
+type Remote struct {
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix"`
+}
+
+// Status 定时备份的配置和最近的执行情况
+type Status struct {
+	Enabled             bool       `json:"enabled"`
+	Schedule            string     `json:"schedule"`
+	ScheduleError       string     `json:"schedule_error,omitempty"`
+	NextRun             *time.Time `json:"next_run,omitempty"`
+	Running             bool       `json:"running"`
+	Retention           Retention  `json:"retention"`
+	Remote              *Remote    `json:"remote,omitempty"`
+	LastSuccess         *Run       `json:"last_success,omitempty"`
+	LastFailure         *Run       `json:"last_failure,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"` // 最近一次成功之后的失败次数
+	Runs                []Run      `json:"runs"`
+}
+
+// Setup 创建备份任务记录表，上次退出时未完成的任务标记为失败
+func Setup() error {
+	_, err := database.DB.Exec(`
+	CREATE TABLE IF NOT EXISTS backup_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trigger_type TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'running',
+		backup TEXT,
+		size INTEGER NOT NULL DEFAULT 0,
+		uploaded INTEGER NOT NULL DEFAULT 0,
+		pruned INTEGER NOT NULL DEFAULT 0,
+		remote_pruned INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`
+		UPDATE backup_runs SET status = ?, error = ?, finished_at = ?
+		WHERE status = ?`, RunFailed, "服务在备份过程中停止", time.Now(), RunRunning)
+	return err
+}
+
+// StartScheduler 加载配置并按BACKUP_SCHEDULE启动定时备份，未设置时只能手动执行
+func StartScheduler() {
+	cfg = LoadConfig()
+	if cfg.S3.Enabled() {
+		log.Printf("备份将上传到S3存储: %s/%s/%s", remoteLocation(cfg.S3), cfg.S3.Bucket, cfg.S3.Prefix)
+	}
+	if cfg.Schedule == "" {
+		log.Printf("未设置BACKUP_SCHEDULE，定时备份未启用")
+		return
+	}
+	c, err := ParseCron(cfg.Schedule)
+	if err != nil {
+		scheduleErr = err.Error()
+		log.Printf("定时备份未启用: %v", err)
+		return
+	}
+	schedule = c
+	setNextRun(schedule.Next(time.Now()))
+	log.Printf("定时备份已启用: %s，下次执行时间 %s", cfg.Schedule, NextRun().Format("2006-01-02 15:04"))
+
+	go scheduler()
+}
+
+// 定期检查是否到达执行时间，按墙上时间比较，系统休眠或调整时间后不会错过太久
+func scheduler() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		next := NextRun()
+		if next.IsZero() || now.Before(next) {
+			continue
+		}
+		setNextRun(schedule.Next(now))
+		if _, err := RunNow(TriggerSchedule); err != nil && err != ErrRunning {
+			log.Printf("定时备份失败: %v", err)
+		}
+	}
+}
+
+// NextRun 下次定时备份的时间，未启用时为零值
+func NextRun() time.Time {
+	state.Lock()
+	defer state.Unlock()
+	return nextRun
+}
+
+func setNextRun(t time.Time) {
+	state.Lock()
+	nextRun = t
+	state.Unlock()
+}
+
+// RunNow 立即执行一次备份任务，返回任务记录。任务失败时记录中包含错误信息
+func RunNow(trigger string) (Run, error) {
+	state.Lock()
+	if running {
+		state.Unlock()
+		return Run{}, ErrRunning
+	}
+	running = true
+	state.Unlock()
+	defer func() {
+		state.Lock()
+		running = false
+		state.Unlock()
+	}()
+
+	run := Run{Trigger: trigger, Status: RunRunning, StartedAt: time.Now()}
+	result, err := database.DB.Exec(`
+		INSERT INTO backup_runs (trigger_type, status, started_at) VALUES (?, ?, ?)`,
+		run.Trigger, run.Status, run.StartedAt)
+	if err != nil {
+		return run, err
+	}
+	id, _ := result.LastInsertId()
+	run.ID = int(id)
+
+	var errs []string
+	if err := execute(&run); err != nil {
+		errs = append(errs, err.Error())
+	}
+	// 没有生成新备份时不清理旧备份
+	if run.Backup != "" {
+		for _, err := range prune(&run) {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = RunSuccess
+	if len(errs) > 0 {
+		run.Status = RunFailed
+		run.Error = strings.Join(errs, "; ")
+		log.Printf("备份任务%d失败: %s", run.ID, run.Error)
+	}
+	if err := finishRun(run); err != nil {
+		return run, err
+	}
+	if run.Status == RunFailed {
+		return run, errors.New(run.Error)
+	}
+	return run, nil
+}
+
+// 生成备份并上传，上传失败时本地备份保留
+func execute(run *Run) error {
+	info, err := Create(schedulePrefix)
+	if err != nil {
+		return errors.New("生成备份失败: " + err.Error())
+	}
+	run.Backup, run.Size = info.Name, info.Size
+
+	if !cfg.S3.Enabled() {
+		return nil
+	}
+	if cfg.S3.AccessKey == "" || cfg.S3.SecretKey == "" {
+		return errors.New("上传失败: 未设置BACKUP_S3_ACCESS_KEY或BACKUP_S3_SECRET_KEY")
+	}
+	path, err := Path(info.Name)
+	if err != nil {
+		return errors.New("上传失败: " + err.Error())
+	}
+	if err := newS3Client(cfg.S3).Upload(cfg.S3.Prefix+info.Name, path); err != nil {
+		return errors.New("上传失败: " + err.Error())
+	}
+	run.Uploaded = true
+	return nil
+}
+
+// 按保留策略清理本地和异地存储中的定时备份
+func prune(run *Run) []error {
+	var errs []error
+	backups, err := List()
+	if err != nil {
+		errs = append(errs, errors.New("清理本地备份失败: "+err.Error()))
+	} else {
+		names := make([]string, len(backups))
+		for i, b := range backups {
+			names[i] = b.Name
+		}
+		for _, name := range expired(names, cfg.Retention) {
+			if err := Delete(name); err != nil {
+				errs = append(errs, errors.New("删除本地备份"+name+"失败: "+err.Error()))
+				continue
+			}
+			run.Pruned++
+		}
+	}
+
+	// 上传失败时不清理异地存储，避免只剩旧备份时再删掉其中一部分
+	if !run.Uploaded {
+		return errs
+	}
+	client := newS3Client(cfg.S3)
+	objects, err := client.List(cfg.S3.Prefix + schedulePrefix + "-")
+	if err != nil {
+		return append(errs, errors.New("清理异地备份失败: "+err.Error()))
+	}
+	names := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		names = append(names, strings.TrimPrefix(obj.Key, cfg.S3.Prefix))
+	}
+	for _, name := range expired(names, cfg.Retention) {
+		if err := client.Delete(cfg.S3.Prefix + name); err != nil {
+			errs = append(errs, errors.New("删除异地备份"+name+"失败: "+err.Error()))
+			continue
+		}
+		run.RemotePruned++
+	}
+	return errs
+}
+
+// 保存任务结果，并只保留最近的任务记录
+func finishRun(run Run) error {
+	_, err := database.DB.Exec(`
+		UPDATE backup_runs SET status = ?, backup = ?, size = ?, uploaded = ?, pruned = ?,
+			remote_pruned = ?, error = ?, finished_at = ?
+		WHERE id = ?`,
+		run.Status, run.Backup, run.Size, run.Uploaded, run.Pruned,
+		run.RemotePruned, run.Error, run.FinishedAt, run.ID)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`
+		DELETE FROM backup_runs WHERE id NOT IN (SELECT id FROM backup_runs ORDER BY id DESC LIMIT ?)`, keepRuns)
+	return err
+}
+
+// Runs 按时间倒序返回最近的备份任务
+func Runs(limit int) ([]Run, error) {
+	return queryRuns(`SELECT `+runColumns+` FROM backup_runs ORDER BY id DESC LIMIT ?`, limit)
+}
+
+// GetStatus 返回定时备份的配置和最近的执行情况
+func GetStatus() (Status, error) {
+	status := Status{
+		Enabled:       schedule != nil,
+		Schedule:      cfg.Schedule,
+		ScheduleError: scheduleErr,
+		Retention:     cfg.Retention,
+	}
+	state.Lock()
+	status.Running = running
+	if !nextRun.IsZero() {
+		next := nextRun
+		status.NextRun = &next
+	}
+	state.Unlock()
+	if cfg.S3.Enabled() {
+		status.Remote = &Remote{Endpoint: remoteLocation(cfg.S3), Bucket: cfg.S3.Bucket, Prefix: cfg.S3.Prefix}
+	}
+
+	var err error
+	if status.Runs, err = Runs(20); err != nil {
+		return status, err
+	}
+	if status.LastSuccess, err = lastRun(RunSuccess); err != nil {
+		return status, err
+	}
+	if status.LastFailure, err = lastRun(RunFailed); err != nil {
+		return status, err
+	}
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*) FROM backup_runs
+		WHERE status = ? AND id > COALESCE((SELECT MAX(id) FROM backup_runs WHERE status = ?), 0)`,
+		RunFailed, RunSuccess).Scan(&status.ConsecutiveFailures)
+	return status, err
+}
+
+func lastRun(status string) (*Run, error) {
+	runs, err := queryRuns(`SELECT `+runColumns+` FROM backup_runs WHERE status = ? ORDER BY id DESC LIMIT 1`, status)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+const runColumns = `id, trigger_type, status, COALESCE(backup, ''), size, uploaded, pruned, remote_pruned,
+	COALESCE(error, ''), started_at, finished_at`
+
+func queryRuns(query string, args ...interface{}) ([]Run, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []Run{}
+	for rows.Next() {
+		var run Run
+		var finished sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Trigger, &run.Status, &run.Backup, &run.Size, &run.Uploaded,
+			&run.Pruned, &run.RemotePruned, &run.Error, &run.StartedAt, &finished); err != nil {
+			return nil, err
+		}
+		if finished.Valid {
+			run.FinishedAt = &finished.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// 异地存储的地址，用于日志和状态显示
+func remoteLocation(c S3Config) string {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + c.Region + ".amazonaws.com"
+	}
+	return endpoint
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"backend/backup"
+	"backend/database"
+	"backend/export"
+	"backend/feed"
+	"backend/portfolio"
+)
+
+// export-static 子命令：将作品集导出为静态站点目录或压缩包
+func runExportStatic(args []string) int {
+	fs := flag.NewFlagSet("export-static", flag.ContinueOnError)
+	output := fs.String("o", "./site", "输出目录，以.zip结尾时生成压缩包")
+	lang := fs.String("lang", "", "内容语言，默认使用原文语言")
+	variant := fs.Int("variant", 0, "只导出该简历版本中的内容")
+	contact := fs.Bool("contact", false, "包含邮箱和电话")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "使用方法: backend export-static [-o 输出目录或文件.zip] [-lang en] [-variant 版本ID] [-contact]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := database.SetupDatabase(); err != nil {
+		fmt.Fprintf(os.Stderr, "数据库初始化失败: %v\n", err)
+		return 1
+	}
+	// 静态站点只包含订阅源中公开的内容，需要发布记录表
+	if err := feed.Setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "数据库初始化失败: %v\n", err)
+		return 1
+	}
+
+	result, err := export.Static(portfolio.Options{
+		Locale:         *lang,
+		VariantID:      *variant,
+		IncludeContact: *contact,
+	}, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "导出静态站点失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("静态站点已导出到 %s\n", result.Output)
+	fmt.Printf("共%d个文件，写入%d个，未变化%d个，删除%d个\n", result.Files, len(result.Written), result.Unchanged, len(result.Removed))
+	for _, name := range result.Written {
+		fmt.Printf("  写入 %s\n", name)
+	}
+	for _, name := range result.Removed {
+		fmt.Printf("  删除 %s\n", name)
+	}
+	return 0
+}
+
+// backup 子命令：生成一致的在线备份，服务运行时也可以执行
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := fs.String("o", "", "备份文件路径，默认保存到备份目录(BACKUP_DIR)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "使用方法: backend backup [-o 备份文件.zip]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := database.SetupDatabase(); err != nil {
+		fmt.Fprintf(os.Stderr, "数据库初始化失败: %v\n", err)
+		return 1
+	}
+
+	path := *output
+	if path == "" {
+		info, err := backup.Create("backup")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "生成备份失败: %v\n", err)
+			return 1
+		}
+		path = filepath.Join(backup.Dir(), info.Name)
+	} else if _, err := backup.WriteTo(path); err != nil {
+		fmt.Fprintf(os.Stderr, "生成备份失败: %v\n", err)
+		return 1
+	}
+
+	manifest, err := backup.ReadManifest(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取备份清单失败: %v\n", err)
+		return 1
+	}
+	fmt.Printf("备份已保存到 %s\n", path)
+	fmt.Printf("数据库结构版本%d，共%d个文件\n", manifest.SchemaVersion, len(manifest.Files))
+	return 0
+}
+
+// restore 子命令：校验并恢复备份，-check只校验不恢复
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	check := fs.Bool("check", false, "只校验备份文件，不恢复")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "使用方法: backend restore [-check] 备份文件.zip")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+
+	if *check {
+		manifest, err := backup.Validate(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		fmt.Printf("备份有效：生成于%s，数据库结构版本%d，共%d个文件\n",
+			manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.SchemaVersion, len(manifest.Files))
+		return 0
+	}
+
+	if err := database.SetupDatabase(); err != nil {
+		fmt.Fprintf(os.Stderr, "数据库初始化失败: %v\n", err)
+		return 1
+	}
+	backup.SetMigrator(migrateRestored)
+	result, err := backup.Restore(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "恢复失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("已从 %s 恢复，恢复前的数据已备份为 %s\n", path, filepath.Join(backup.Dir(), result.SafetyBackup))
+	fmt.Printf("还原%d个文件，删除%d个文件\n", result.RestoredFiles, result.RemovedFiles)
+	if result.Migrated {
+		fmt.Printf("数据库结构已从版本%d迁移到版本%d\n", result.FromVersion, result.ToVersion)
+	}
+	return 0
+}
@@ -6,17 +6,34 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var DB *sql.DB
 
+// SchemaVersion 数据库结构版本，记录在PRAGMA user_version中。
+// 表结构变化时递增，备份中记录该版本，恢复较旧的备份时据此执行迁移
+const SchemaVersion = 1
+
+// FileName 数据库文件名
+const FileName = "resume.db"
+
+// DataDir 数据目录，多租户部署时每个租户使用独立的DATA_DIR
+func DataDir() string {
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		return dir
+	}
+	return "./data"
+}
+
 // 初始化数据库
 func SetupDatabase() error {
 	// 确保数据库目录存在
-	dbDir := "./data"
+	dbDir := DataDir()
 	if _, err := os.Stat(dbDir); os.IsNotExist(err) {
 		err = os.MkdirAll(dbDir, 0755)
 		if err != nil {
@@ -24,7 +41,7 @@ func SetupDatabase() error {
 		}
 	}
 
-	dbPath := filepath.Join(dbDir, "resume.db")
+	dbPath := filepath.Join(dbDir, FileName)
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return err
@@ -33,7 +50,7 @@ func SetupDatabase() error {
 	DB = db
 
 	// 创建表
-	err = createTables()
+	err = Migrate()
 	if err != nil {
 		return err
 	}
@@ -54,6 +71,15 @@ func SetupDatabase() error {
 	return nil
 }
 
+// Migrate 创建缺少的表和字段，并记录当前结构版本。可重复执行，恢复备份后也会调用
+func Migrate() error {
+	if err := createTables(); err != nil {
+		return err
+	}
+	_, err := DB.Exec("PRAGMA user_version = " + strconv.Itoa(SchemaVersion))
+	return err
+}
+
 // 创建数据库表
 func createTables() error {
 	// 个人信息表
@@ -187,10 +213,357 @@ func createTables() error {
 		return err
 	}
 
+	// 推荐信邀请表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS testimonial_invites (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		token TEXT UNIQUE NOT NULL,
+		recipient TEXT,
+		note TEXT,
+		experience_id INTEGER,
+		project_id INTEGER,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (experience_id) REFERENCES experiences(id) ON DELETE SET NULL,
+		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE SET NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 推荐信表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS testimonials (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		invite_id INTEGER,
+		author_name TEXT NOT NULL,
+		author_role TEXT,
+		relationship TEXT,
+		content TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		experience_id INTEGER,
+		project_id INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		reviewed_at TIMESTAMP,
+		FOREIGN KEY (invite_id) REFERENCES testimonial_invites(id) ON DELETE SET NULL,
+		FOREIGN KEY (experience_id) REFERENCES experiences(id) ON DELETE SET NULL,
+		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE SET NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 联系留言表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		body TEXT NOT NULL,
+		ip TEXT,
+		user_agent TEXT,
+		is_read BOOLEAN NOT NULL DEFAULT 0,
+		archived BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 邮件发送队列表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS mail_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template TEXT NOT NULL,
+		to_addr TEXT NOT NULL,
+		reply_to TEXT,
+		subject TEXT NOT NULL,
+		text_body TEXT,
+		html_body TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		sent_at TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 访客访问记录表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS visits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		access_key TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		route TEXT NOT NULL,
+		entity_type TEXT,
+		entity_id INTEGER,
+		user_agent TEXT,
+		ip TEXT,
+		verified_at TIMESTAMP,
+		visited_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_visits_access_key ON visits(access_key, visited_at)`)
+	if err != nil {
+		return err
+	}
+
+	// 回调地址表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL,
+		description TEXT,
+		active BOOLEAN NOT NULL DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 回调投递记录表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_status INTEGER,
+		response_body TEXT,
+		last_error TEXT,
+		next_attempt_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		delivered_at TIMESTAMP,
+		FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 技术/标签表，name为规范名称
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS technologies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		slug TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		category TEXT,
+		description TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 技术别名表，alias为归一化后的匹配键(包括规范名称本身)，name为原始写法
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS technology_aliases (
+		alias TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		technology_id INTEGER NOT NULL,
+		FOREIGN KEY (technology_id) REFERENCES technologies(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 技能、工作经历、项目与技术的关联表，position为在原数组中的顺序
+	for _, entity := range []string{"skill", "experience", "project"} {
+		_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS ` + entity + `_technologies (
+			` + entity + `_id INTEGER NOT NULL,
+			technology_id INTEGER NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (` + entity + `_id, technology_id)
+		)`)
+		if err != nil {
+			return err
+		}
+		_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_` + entity + `_technologies_tech ON ` + entity + `_technologies(technology_id)`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 简历版本表：针对不同职位整理的内容子集，可覆盖个人信息中的职位和简介
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS variants (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		title TEXT,
+		introduction TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 简历版本包含的内容，item_type为project/experience/skill/certificate，position为展示顺序
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS variant_items (
+		variant_id INTEGER NOT NULL,
+		item_type TEXT NOT NULL,
+		item_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (variant_id, item_type, item_id),
+		FOREIGN KEY (variant_id) REFERENCES variants(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 求职申请表，visitor_access_id关联为该公司发放的访客密码或分享链接
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS applications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		company TEXT NOT NULL,
+		role TEXT NOT NULL,
+		stage TEXT NOT NULL,
+		job_url TEXT,
+		applied_at TEXT,
+		contacts TEXT,
+		notes TEXT,
+		visitor_access_id INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 求职申请阶段变更记录
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS application_stages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		application_id INTEGER NOT NULL,
+		from_stage TEXT,
+		stage TEXT NOT NULL,
+		note TEXT,
+		changed_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_application_stages_application ON application_stages(application_id, changed_at)`)
+	if err != nil {
+		return err
+	}
+
+	// 内容翻译表：主表保存默认语言的原文，其他语言按字段保存，列表字段保存为JSON数组
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS translations (
+		entity_type TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		locale TEXT NOT NULL,
+		field TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (entity_type, entity_id, locale, field)
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_translations_locale ON translations(locale, entity_type)`)
+	if err != nil {
+		return err
+	}
+
+	// 一次性数据迁移记录表
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// 为已有表补充新增字段
+	if err = migrateColumns(); err != nil {
+		return err
+	}
+
 	log.Printf("所有数据库表已创建")
 	return nil
 }
 
+// 为旧版本数据库中已存在的表补充后来新增的字段
+func migrateColumns() error {
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"visitor_access", "first_used_at", "TIMESTAMP"},
+		{"visitor_access", "variant_id", "INTEGER"},
+		{"certificates", "expiry_date", "TEXT"},
+		{"certificates", "expiry_notified_for", "TEXT"},
+	}
+
+	for _, col := range columns {
+		if err := AddColumnIfMissing(col.table, col.column, col.definition); err != nil {
+			log.Printf("为表%s添加字段%s失败: %v", col.table, col.column, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrationApplied 检查一次性数据迁移是否已执行过
+func MigrationApplied(name string) (bool, error) {
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE name = ?", name).Scan(&count)
+	return count > 0, err
+}
+
+// MarkMigrationApplied 记录一次性数据迁移已执行
+func MarkMigrationApplied(name string) error {
+	_, err := DB.Exec("INSERT OR IGNORE INTO schema_migrations (name, applied_at) VALUES (?, ?)", name, time.Now())
+	return err
+}
+
+// AddColumnIfMissing 字段不存在时执行ALTER TABLE添加
+func AddColumnIfMissing(table, column, definition string) error {
+	rows, err := DB.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = DB.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	if err == nil {
+		log.Printf("已为表%s添加字段%s", table, column)
+	}
+	return err
+}
+
 // 检查数据库是否为空
 func isDatabaseEmpty() (bool, error) {
 	var count int
@@ -204,9 +577,17 @@ func isDatabaseEmpty() (bool, error) {
 
 // 初始化默认数据
 func initializeData() error {
-	// 初始化管理员用户
+	// 初始化管理员用户，设置了INITIAL_ADMIN_PASSWORD时使用该密码
+	adminPassword := "$2a$10$PYsUkJ2LUeAsbMtD3WkqNeETS4LjLxvg02ER2vKkBp0VIafe0nQTO" // 默认密码：admin123
+	if initial := os.Getenv("INITIAL_ADMIN_PASSWORD"); initial != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(initial), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		adminPassword = string(hashed)
+	}
 	_, err := DB.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, ?)",
-		"admin", "$2a$10$PYsUkJ2LUeAsbMtD3WkqNeETS4LjLxvg02ER2vKkBp0VIafe0nQTO", "admin") // 默认密码：admin123
+		"admin", adminPassword, "admin")
 	if err != nil {
 		return err
 	}
@@ -239,9 +620,14 @@ func initializeData() error {
 	log.Printf("当前访客密码记录数: %d", visitorCount)
 
 	if visitorCount == 0 {
+		// 设置了INITIAL_VISITOR_PASSWORD时使用该密码
+		visitorPassword := os.Getenv("INITIAL_VISITOR_PASSWORD")
+		if visitorPassword == "" {
+			visitorPassword = "default_password"
+		}
 		_, err = DB.Exec(`
 		INSERT INTO visitor_access (access_type, value, access_key) 
-		VALUES ('password', 'default_password', 'visitor_key');`)
+		VALUES ('password', ?, 'visitor_key');`, visitorPassword)
 		if err != nil {
 			log.Printf("创建默认访客密码失败: %v", err)
 			return err
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	VisitorVerified = "visitor.verified" // 访客验证成功
+	MessageReceived = "message.received" // 收到联系留言
+	LoginFailed     = "login.failed"     // 管理员登录失败
+
+	ProfileUpdated = "profile.updated"
+
+	SkillCreated = "skill.created"
+	SkillUpdated = "skill.updated"
+	SkillDeleted = "skill.deleted"
+
+	ExperienceCreated = "experience.created"
+	ExperienceUpdated = "experience.updated"
+	ExperienceDeleted = "experience.deleted"
+
+	ProjectCreated = "project.created"
+	ProjectUpdated = "project.updated"
+	ProjectDeleted = "project.deleted"
+
+	CertificateCreated = "certificate.created"
+	CertificateUpdated = "certificate.updated"
+	CertificateDeleted = "certificate.deleted"
+
+	FeedUpdated = "feed.updated" // 修改了订阅条目的可见性
+
+	BackupRestored = "backup.restored" // 从备份恢复了全部数据
+)
+
+// Event 一条发布到事件中心的事件
+type Event struct {
+	ID   int64       `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Hub 进程内发布订阅中心，保留最近的事件用于断线重连后的补发
+type Hub struct {
+	mu          sync.Mutex
+	nextID      int64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription 一个订阅者，事件从C读取；订阅者处理过慢时C会被关闭
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	hub    *Hub
+	filter func(Event) bool
+}
+
+// NewHub 创建事件中心，historySize为保留的历史事件数量
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		// 以毫秒时间戳作为起始ID，保证重启后事件ID仍然递增
+		nextID:      time.Now().UnixMilli(),
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish 发布事件给所有订阅者
+func (h *Hub) Publish(eventType string, data interface{}) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event := Event{ID: h.nextID, Type: eventType, Time: time.Now(), Data: data}
+
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for sub := range h.subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// 订阅者跟不上，断开后由客户端带Last-Event-ID重连补发
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return event
+}
+
+// Subscribe 订阅事件，lastID大于0时先补发该ID之后的历史事件；filter为nil表示接收全部事件
+func (h *Hub) Subscribe(lastID int64, buffer int, filter func(Event) bool) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if lastID > 0 {
+		for _, e := range h.history {
+			if e.ID > lastID && (filter == nil || filter(e)) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, buffer+len(replay))
+	for _, e := range replay {
+		ch <- e
+	}
+
+	sub := &Subscription{C: ch, ch: ch, hub: h, filter: filter}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if _, ok := s.hub.subscribers[s]; ok {
+		delete(s.hub.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// 默认事件中心
+var defaultHub = NewHub(500)
+
+// Publish 向默认事件中心发布事件
+func Publish(eventType string, data interface{}) Event {
+	return defaultHub.Publish(eventType, data)
+}
+
+// Subscribe 订阅默认事件中心
+func Subscribe(lastID int64, buffer int, filter func(Event) bool) *Subscription {
+	return defaultHub.Subscribe(lastID, buffer, filter)
+}
+
+// Match 判断事件类型是否匹配模式，模式支持"*"(全部)和以".*"结尾的前缀匹配
+func Match(pattern, eventType string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == eventType
+	}
+}
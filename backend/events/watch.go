@@ -0,0 +1,34 @@
+package events
+
+import "encoding/json"
+
+// Watch 在后台订阅默认事件中心，把符合filter的事件依次交给handle处理。
+// 订阅因处理过慢被断开时从上次处理的事件ID继续订阅，断开期间的事件从历史中补发
+func Watch(buffer int, filter func(Event) bool, handle func(Event)) {
+	go func() {
+		var lastID int64
+		for {
+			sub := Subscribe(lastID, buffer, filter)
+			for e := range sub.C {
+				lastID = e.ID
+				handle(e)
+			}
+		}
+	}()
+}
+
+// EntityID 取出内容变更事件中的实体ID。事件数据可能是模型结构体或map，统一通过JSON取出id，
+// 没有id时返回0
+func EntityID(e Event) int {
+	raw, err := json.Marshal(e.Data)
+	if err != nil {
+		return 0
+	}
+	var payload struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return 0
+	}
+	return payload.ID
+}
@@ -0,0 +1,420 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/markdown"
+	"backend/models"
+	"backend/seo"
+)
+
+// ResumeDocx Word简历格式
+const ResumeDocx = "docx"
+
+// DocxContentType Word文档的MIME类型
+const DocxContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// 正文使用的西文字体和中文字体
+const (
+	docxLatinFont = "Calibri"
+	docxCJKFont   = "Microsoft YaHei"
+)
+
+// 表格总宽度(A4页面减去左右边距，单位为1/20磅)
+const docxTableWidth = 9638
+
+// Word文档中的固定文字，与静态站点标签合用
+type docxLabels struct {
+	staticLabels
+	Category, Skill, Level, Keywords string
+}
+
+func docxLabelsFor(locale string) docxLabels {
+	if strings.HasPrefix(locale, "zh") {
+		return docxLabels{staticLabels: zhLabels, Category: "分类", Skill: "技能", Level: "熟练度", Keywords: "关键词"}
+	}
+	return docxLabels{staticLabels: enLabels, Category: "Category", Skill: "Skill", Level: "Level", Keywords: "Keywords"}
+}
+
+// 按顺序拼接document.xml的正文，并记录外部链接
+type docxWriter struct {
+	body  strings.Builder
+	links []string
+}
+
+// 一段文字及其格式
+type docxRun struct {
+	text         string
+	bold, italic bool
+	link         string // 外部链接地址
+}
+
+func (w *docxWriter) paragraph(style string, runs ...docxRun) {
+	w.body.WriteString("<w:p>")
+	if style != "" {
+		w.body.WriteString(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+	}
+	w.runs(runs)
+	w.body.WriteString("</w:p>")
+}
+
+// 项目符号列表中的一项
+func (w *docxWriter) bullet(runs ...docxRun) {
+	w.body.WriteString(`<w:p><w:pPr><w:pStyle w:val="ListBullet"/><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr>`)
+	w.runs(runs)
+	w.body.WriteString("</w:p>")
+}
+
+func (w *docxWriter) runs(runs []docxRun) {
+	for _, run := range runs {
+		if run.text == "" {
+			continue
+		}
+		if run.link != "" {
+			w.links = append(w.links, run.link)
+			w.body.WriteString(`<w:hyperlink r:id="rLink` + strconv.Itoa(len(w.links)) + `">`)
+		}
+		w.body.WriteString("<w:r>")
+		if run.bold || run.italic || run.link != "" {
+			w.body.WriteString("<w:rPr>")
+			if run.link != "" {
+				w.body.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+			}
+			if run.bold {
+				w.body.WriteString("<w:b/><w:bCs/>")
+			}
+			if run.italic {
+				w.body.WriteString("<w:i/><w:iCs/>")
+			}
+			w.body.WriteString("</w:rPr>")
+		}
+		w.body.WriteString(`<w:t xml:space="preserve">` + xmlText(run.text) + "</w:t></w:r>")
+		if run.link != "" {
+			w.body.WriteString("</w:hyperlink>")
+		}
+	}
+}
+
+// 表格，第一行为表头，widths为各列所占比例
+func (w *docxWriter) table(widths []int, header []string, rows [][]string) {
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	columns := make([]int, len(widths))
+	for i, width := range widths {
+		columns[i] = docxTableWidth * width / total
+	}
+
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="ResumeTable"/><w:tblW w:w="` + strconv.Itoa(docxTableWidth) +
+		`" w:type="dxa"/><w:tblLook w:val="04A0" w:firstRow="1" w:lastRow="0" w:firstColumn="1" w:lastColumn="0" w:noHBand="0" w:noVBand="1"/></w:tblPr><w:tblGrid>`)
+	for _, width := range columns {
+		w.body.WriteString(`<w:gridCol w:w="` + strconv.Itoa(width) + `"/>`)
+	}
+	w.body.WriteString("</w:tblGrid>")
+
+	row := func(cells []string, isHeader bool) {
+		w.body.WriteString("<w:tr>")
+		if isHeader {
+			w.body.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for i, cell := range cells {
+			w.body.WriteString(`<w:tc><w:tcPr><w:tcW w:w="` + strconv.Itoa(columns[i]) + `" w:type="dxa"/>`)
+			if isHeader {
+				w.body.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="DCE6F2"/>`)
+			}
+			w.body.WriteString(`</w:tcPr><w:p><w:pPr><w:pStyle w:val="TableText"/></w:pPr>`)
+			w.runs([]docxRun{{text: cell, bold: isHeader}})
+			w.body.WriteString("</w:p></w:tc>")
+		}
+		w.body.WriteString("</w:tr>")
+	}
+	row(header, true)
+	for _, cells := range rows {
+		row(cells, false)
+	}
+	// 表格后需要一个段落，否则相邻的表格会合并
+	w.body.WriteString(`</w:tbl><w:p/>`)
+}
+
+// Docx 将作品集快照渲染为Word文档(Office Open XML)
+func Docx(p models.Portfolio) ([]byte, error) {
+	L := docxLabelsFor(p.Locale)
+	w := &docxWriter{}
+	profile := p.Profile
+
+	w.paragraph("Title", docxRun{text: profile.Name})
+	w.paragraph("Subtitle", docxRun{text: profile.Title})
+	var facts []string
+	for _, fact := range []string{profile.Location, years(L.Years, profile.YearsOfExp), profile.Education, profile.Email, profile.Phone} {
+		if fact != "" {
+			facts = append(facts, fact)
+		}
+	}
+	w.paragraph("Contact", docxRun{text: strings.Join(facts, "  ·  ")})
+
+	if intro := paragraphs(profile.Introduction); len(intro) > 0 {
+		w.paragraph("Heading1", docxRun{text: L.About})
+		for _, text := range intro {
+			w.paragraph("", docxRun{text: text})
+		}
+		if profile.Philosophy != "" {
+			w.paragraph("Quote", docxRun{text: profile.Philosophy})
+		}
+	}
+
+	if len(p.SkillCategories) > 0 {
+		w.paragraph("Heading1", docxRun{text: L.Skills})
+		var rows [][]string
+		for _, category := range p.SkillCategories {
+			for i, skill := range category.Skills {
+				name := ""
+				if i == 0 {
+					name = category.Name
+				}
+				level := ""
+				if skill.Level > 0 {
+					level = strconv.Itoa(skill.Level) + "%"
+				}
+				rows = append(rows, []string{name, skill.Name, level, strings.Join(skill.Tags, ", ")})
+			}
+		}
+		w.table([]int{3, 3, 2, 5}, []string{L.Category, L.Skill, L.Level, L.Keywords}, rows)
+	}
+
+	if len(p.Experiences) > 0 {
+		w.paragraph("Heading1", docxRun{text: L.Experience})
+		for _, exp := range p.Experiences {
+			w.paragraph("Heading2", docxRun{text: joinNonEmpty(" · ", exp.Title, exp.Company)})
+			w.paragraph("Meta", docxRun{text: joinNonEmpty(" · ", exp.Period, exp.Location), italic: true})
+			w.list(L.Responsibilities, exp.Responsibilities)
+			w.list(L.Achievements, exp.Achievements)
+			w.techStack(L.TechStack, exp.Technologies)
+		}
+	}
+
+	if len(p.Projects) > 0 {
+		w.paragraph("Heading1", docxRun{text: L.Projects})
+		for _, project := range p.Projects {
+			w.paragraph("Heading2", docxRun{text: project.Title})
+			if project.Category != "" {
+				w.paragraph("Meta", docxRun{text: project.Category, italic: true})
+			}
+			for _, text := range paragraphs(project.Description) {
+				w.paragraph("", docxRun{text: text})
+			}
+			w.list(L.KeyPoints, project.KeyPoints)
+			w.techStack(L.TechStack, project.TechStack)
+			demo, repo := seo.AbsoluteURL("", project.DemoLink), seo.AbsoluteURL("", project.RepoLink)
+			if demo != "" || repo != "" {
+				runs := []docxRun{}
+				if demo != "" {
+					runs = append(runs, docxRun{text: L.Demo, link: demo})
+				}
+				if demo != "" && repo != "" {
+					runs = append(runs, docxRun{text: "  ·  "})
+				}
+				if repo != "" {
+					runs = append(runs, docxRun{text: L.Repo, link: repo})
+				}
+				w.paragraph("", runs...)
+			}
+		}
+	}
+
+	if len(p.Certificates) > 0 {
+		w.paragraph("Heading1", docxRun{text: L.Certificates})
+		for _, cert := range p.Certificates {
+			runs := []docxRun{{text: cert.Name, bold: true}}
+			if meta := joinNonEmpty(" · ", cert.Organization, cert.Date); meta != "" {
+				runs = append(runs, docxRun{text: " · " + meta})
+			}
+			if cert.Description != "" {
+				runs = append(runs, docxRun{text: ": " + cert.Description})
+			}
+			w.bullet(runs...)
+		}
+	}
+
+	return w.pack(p)
+}
+
+// 带标题的项目符号列表，列表项是Markdown
+func (w *docxWriter) list(title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	w.paragraph("ListTitle", docxRun{text: title, bold: true})
+	for _, item := range items {
+		w.bullet(docxRun{text: markdown.Text(item)})
+	}
+}
+
+func (w *docxWriter) techStack(label string, tech []string) {
+	if len(tech) == 0 {
+		return
+	}
+	w.paragraph("", docxRun{text: label + ": ", bold: true}, docxRun{text: strings.Join(tech, ", ")})
+}
+
+// 打包为docx文件
+func (w *docxWriter) pack(p models.Portfolio) ([]byte, error) {
+	modified := p.Profile.LastUpdated
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	lang := p.Locale
+	if lang == "" {
+		lang = "zh-CN"
+	}
+
+	var rels strings.Builder
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for i, link := range w.links {
+		rels.WriteString(`<Relationship Id="rLink` + strconv.Itoa(i+1) + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="` +
+			xmlText(link) + `" TargetMode="External"/>`)
+	}
+	rels.WriteString("</Relationships>")
+
+	document := xml.Header + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body>` + w.body.String() +
+		`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134" w:header="567" w:footer="567" w:gutter="0"/></w:sectPr>` +
+		`</w:body></w:document>`
+
+	core := xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" ` +
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<dc:title>` + xmlText(joinNonEmpty(" - ", p.Profile.Name, p.Profile.Title)) + `</dc:title>` +
+		`<dc:creator>` + xmlText(p.Profile.Name) + `</dc:creator>` +
+		`<dc:language>` + xmlText(lang) + `</dc:language>` +
+		`<dcterms:modified xsi:type="dcterms:W3CDTF">` + modified.UTC().Format(time.RFC3339) + `</dcterms:modified>` +
+		`</cp:coreProperties>`
+
+	files := map[string][]byte{
+		"[Content_Types].xml":          []byte(docxContentTypes),
+		"_rels/.rels":                  []byte(docxPackageRels),
+		"docProps/core.xml":            []byte(core),
+		"word/document.xml":            []byte(document),
+		"word/_rels/document.xml.rels": []byte(rels.String()),
+		"word/styles.xml":              []byte(docxStyles(lang)),
+		"word/numbering.xml":           []byte(docxNumbering),
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// [Content_Types].xml放在最前面，部分解析器依赖这个顺序
+	for _, name := range sortedNames(files) {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var xmlInvalidChars = regexp.MustCompile("[\x00-\x08\x0B\x0C\x0E-\x1F]")
+
+// 转义XML文本，去掉XML中不允许出现的控制字符
+func xmlText(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(xmlInvalidChars.ReplaceAllString(s, "")))
+	return buf.String()
+}
+
+// Markdown文本按空行分段并转为纯文本
+func paragraphs(src string) []string {
+	var result []string
+	for _, paragraph := range regexp.MustCompile(`\n\s*\n`).Split(markdown.Text(src), -1) {
+		if text := strings.Join(strings.Fields(paragraph), " "); text != "" {
+			result = append(result, text)
+		}
+	}
+	return result
+}
+
+func years(format string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Replace(format, "%d", strconv.Itoa(n), 1)
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var kept []string
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+const docxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`</Types>`
+
+const docxPackageRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rDocument" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`<Relationship Id="rCore" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+// 项目符号列表定义
+const docxNumbering = xml.Header + `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+	`<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="singleLevel"/>` +
+	`<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="•"/><w:lvlJc w:val="left"/>` +
+	`<w:pPr><w:ind w:left="420" w:hanging="280"/></w:pPr></w:lvl></w:abstractNum>` +
+	`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num></w:numbering>`
+
+// 样式表：标题、副标题、一二级标题、列表、表格和超链接
+func docxStyles(lang string) string {
+	paragraphStyle := func(id, name, pPr, rPr string) string {
+		return `<w:style w:type="paragraph" w:styleId="` + id + `"><w:name w:val="` + name + `"/><w:basedOn w:val="Normal"/>` +
+			`<w:next w:val="Normal"/><w:qFormat/><w:pPr>` + pPr + `</w:pPr><w:rPr>` + rPr + `</w:rPr></w:style>`
+	}
+	return xml.Header + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:docDefaults><w:rPrDefault><w:rPr>` +
+		`<w:rFonts w:ascii="` + docxLatinFont + `" w:hAnsi="` + docxLatinFont + `" w:eastAsia="` + docxCJKFont + `" w:cs="` + docxLatinFont + `"/>` +
+		`<w:sz w:val="21"/><w:szCs w:val="21"/><w:lang w:val="en-US" w:eastAsia="` + xmlText(lang) + `"/>` +
+		`</w:rPr></w:rPrDefault><w:pPrDefault><w:pPr><w:spacing w:after="80" w:line="300" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+		`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>` +
+		paragraphStyle("Title", "Title", `<w:spacing w:after="40"/>`, `<w:b/><w:color w:val="1F3864"/><w:sz w:val="48"/><w:szCs w:val="48"/>`) +
+		paragraphStyle("Subtitle", "Subtitle", `<w:spacing w:after="60"/>`, `<w:color w:val="2E74B5"/><w:sz w:val="28"/><w:szCs w:val="28"/>`) +
+		paragraphStyle("Contact", "Contact", `<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="4" w:color="2E74B5"/></w:pBdr><w:spacing w:after="200"/>`,
+			`<w:color w:val="595959"/><w:sz w:val="19"/><w:szCs w:val="19"/>`) +
+		paragraphStyle("Heading1", "heading 1", `<w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="0"/>`+
+			`<w:pBdr><w:bottom w:val="single" w:sz="4" w:space="2" w:color="BFBFBF"/></w:pBdr>`,
+			`<w:b/><w:color w:val="1F3864"/><w:sz w:val="30"/><w:szCs w:val="30"/>`) +
+		paragraphStyle("Heading2", "heading 2", `<w:keepNext/><w:spacing w:before="160" w:after="40"/><w:outlineLvl w:val="1"/>`,
+			`<w:b/><w:color w:val="2E74B5"/><w:sz w:val="24"/><w:szCs w:val="24"/>`) +
+		paragraphStyle("Meta", "Meta", `<w:keepNext/><w:spacing w:after="60"/>`, `<w:color w:val="7F7F7F"/>`) +
+		paragraphStyle("ListTitle", "List Title", `<w:keepNext/><w:spacing w:before="60" w:after="40"/>`, ``) +
+		paragraphStyle("ListBullet", "List Bullet", `<w:spacing w:after="20"/><w:ind w:left="420" w:hanging="280"/>`, ``) +
+		paragraphStyle("Quote", "Quote", `<w:ind w:left="420"/>`, `<w:i/><w:color w:val="595959"/>`) +
+		paragraphStyle("TableText", "Table Text", `<w:spacing w:before="20" w:after="20"/>`, `<w:sz w:val="19"/><w:szCs w:val="19"/>`) +
+		`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>` +
+		`<w:style w:type="table" w:styleId="ResumeTable"><w:name w:val="Resume Table"/><w:tblPr>` +
+		`<w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/>` +
+		`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/>` +
+		`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/></w:tblBorders>` +
+		`<w:tblCellMar><w:left w:w="80" w:type="dxa"/><w:right w:w="80" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>` +
+		`</w:styles>`
+}
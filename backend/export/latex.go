@@ -0,0 +1,366 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"backend/markdown"
+	"backend/models"
+	"backend/portfolio"
+	"backend/seo"
+)
+
+// 内置的LaTeX模板
+const (
+	LatexModernCV  = "moderncv"
+	LatexAwesomeCV = "awesome-cv"
+)
+
+// ErrUnknownTemplate 模板不存在
+var ErrUnknownTemplate = errors.New("LaTeX模板不存在")
+
+// LatexTemplateDir 自定义LaTeX模板目录，每个子目录是一个模板，与内置模板同名时优先使用
+func LatexTemplateDir() string {
+	return os.Getenv("LATEX_TEMPLATE_DIR")
+}
+
+// LatexOptions LaTeX导出选项
+type LatexOptions struct {
+	Template string // 模板名称，为空时使用moderncv
+	CJKFont  string // 中日韩字体名称，为空时由ctex自动选择
+	SiteURL  string // 写入简历的主页地址
+}
+
+// LatexTemplates 可选的模板名称
+func LatexTemplates() []string {
+	names := map[string]bool{LatexModernCV: true, LatexAwesomeCV: true}
+	if dir := LatexTemplateDir(); dir != "" {
+		if entries, err := os.ReadDir(dir); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					names[entry.Name()] = true
+				}
+			}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// 模板目录中以.tmpl结尾的文件渲染后去掉后缀，其余文件原样复制
+func latexTemplateFS(name string) (fs.FS, error) {
+	if name == "" || name != path.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, ErrUnknownTemplate
+	}
+	if dir := LatexTemplateDir(); dir != "" {
+		custom := filepath.Join(dir, name)
+		if info, err := os.Stat(custom); err == nil && info.IsDir() {
+			return os.DirFS(custom), nil
+		}
+	}
+	if name != LatexModernCV && name != LatexAwesomeCV {
+		return nil, ErrUnknownTemplate
+	}
+	return fs.Sub(templateFS, "templates/latex/"+name)
+}
+
+// LaTeX模板使用的数据，所有文本都已转义，模板中可以直接输出
+type latexData struct {
+	Labels       staticLabels
+	Locale       string
+	CJK          bool
+	CJKFont      string
+	Homepage     string // 可用于\href的地址
+	HomepageText string // 去掉协议的显示文本
+	Profile      latexProfile
+	Skills       []latexSkillCategory
+	Experiences  []latexEntry
+	Projects     []latexEntry
+	Certificates []latexCertificate
+	Date         string
+}
+
+type latexProfile struct {
+	Name, Title, Email, Phone, Location string
+	Education, JobStatus, Philosophy    string
+	Introduction                        []string // 段落
+	YearsOfExp                          int
+}
+
+type latexSkillCategory struct {
+	Name   string
+	Skills []string
+}
+
+type latexEntry struct {
+	Title, Subtitle, Period, Location, Description string
+	Responsibilities, Achievements, KeyPoints      []string
+	Technologies                                   string
+	Demo, Repo                                     string // 可用于\href的地址
+}
+
+type latexCertificate struct {
+	Name, Organization, Date, Description string
+}
+
+// Latex 将作品集快照渲染为LaTeX工程，返回相对路径到文件内容的映射
+func Latex(p models.Portfolio, opts LatexOptions) (map[string][]byte, error) {
+	name := opts.Template
+	if name == "" {
+		name = LatexModernCV
+	}
+	source, err := latexTemplateFS(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data := newLatexData(p, opts)
+	files := map[string][]byte{}
+	err = fs.WalkDir(source, ".", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		raw, err := fs.ReadFile(source, file)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(file, ".tmpl") {
+			files[file] = raw
+			return nil
+		}
+		// LaTeX中大量使用花括号，模板改用<< >>作为分隔符
+		tmpl, err := template.New(file).Delims("<<", ">>").Funcs(template.FuncMap{
+			"join": strings.Join,
+		}).Parse(string(raw))
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return err
+		}
+		files[strings.TrimSuffix(file, ".tmpl")] = tidy(buf.Bytes())
+		return nil
+	})
+	return files, err
+}
+
+// LatexZip 读取作品集快照，生成LaTeX工程压缩包
+func LatexZip(opts portfolio.Options, sections Sections, latexOpts LatexOptions) ([]byte, error) {
+	p, err := portfolio.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+	sections.Apply(&p)
+	files, err := Latex(p, latexOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := p.Profile.LastUpdated
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range sortedNames(files) {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newLatexData(p models.Portfolio, opts LatexOptions) latexData {
+	profile := p.Profile
+	data := latexData{
+		Labels:  escapeLabels(labelsFor(p.Locale)),
+		Locale:  p.Locale,
+		CJKFont: tex(opts.CJKFont),
+		Profile: latexProfile{
+			Name:         tex(profile.Name),
+			Title:        tex(profile.Title),
+			Email:        tex(profile.Email),
+			Phone:        tex(profile.Phone),
+			Location:     tex(profile.Location),
+			Education:    tex(profile.Education),
+			JobStatus:    tex(profile.JobStatus),
+			Philosophy:   tex(profile.Philosophy),
+			Introduction: texParagraphs(profile.Introduction),
+			YearsOfExp:   profile.YearsOfExp,
+		},
+		Date: time.Now().Format("2006-01-02"),
+	}
+	if u := seo.AbsoluteURL("", opts.SiteURL); u != "" {
+		data.Homepage = texURL(u)
+		data.HomepageText = tex(strings.TrimSuffix(strings.SplitN(u, "://", 2)[1], "/"))
+	}
+
+	for _, category := range p.SkillCategories {
+		item := latexSkillCategory{Name: tex(category.Name)}
+		for _, skill := range category.Skills {
+			item.Skills = append(item.Skills, tex(skill.Name))
+		}
+		if len(item.Skills) > 0 {
+			data.Skills = append(data.Skills, item)
+		}
+	}
+	for _, exp := range p.Experiences {
+		data.Experiences = append(data.Experiences, latexEntry{
+			Title:            tex(exp.Title),
+			Subtitle:         tex(exp.Company),
+			Period:           tex(exp.Period),
+			Location:         tex(exp.Location),
+			Responsibilities: texItems(exp.Responsibilities),
+			Achievements:     texItems(exp.Achievements),
+			Technologies:     tex(strings.Join(exp.Technologies, ", ")),
+		})
+	}
+	for _, project := range p.Projects {
+		data.Projects = append(data.Projects, latexEntry{
+			Title:        tex(project.Title),
+			Subtitle:     tex(project.Category),
+			Description:  tex(markdown.Text(project.Description)),
+			KeyPoints:    texItems(project.KeyPoints),
+			Technologies: tex(strings.Join(project.TechStack, ", ")),
+			Demo:         texURL(seo.AbsoluteURL("", project.DemoLink)),
+			Repo:         texURL(seo.AbsoluteURL("", project.RepoLink)),
+		})
+	}
+	for _, cert := range p.Certificates {
+		data.Certificates = append(data.Certificates, latexCertificate{
+			Name:         tex(cert.Name),
+			Organization: tex(cert.Organization),
+			Date:         tex(cert.Date),
+			Description:  tex(cert.Description),
+		})
+	}
+
+	// 内容或标签中有中日韩文字时需要加载ctex
+	data.CJK = strings.HasPrefix(p.Locale, "zh") || strings.HasPrefix(p.Locale, "ja") || strings.HasPrefix(p.Locale, "ko") ||
+		hasCJK(profile.Name, profile.Title, profile.Introduction, profile.Location, profile.Education) ||
+		hasCJKPortfolio(p)
+	return data
+}
+
+var texReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	"\r", "",
+)
+
+var texSpaces = regexp.MustCompile(`\s+`)
+
+// 转义LaTeX特殊字符，并把换行合并为空格(空行在LaTeX中表示分段)
+func tex(s string) string {
+	return texReplacer.Replace(strings.TrimSpace(texSpaces.ReplaceAllString(s, " ")))
+}
+
+// Markdown文本转为纯文本段落
+func texParagraphs(src string) []string {
+	var result []string
+	for _, paragraph := range regexp.MustCompile(`\n\s*\n`).Split(markdown.Text(src), -1) {
+		if p := tex(paragraph); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Markdown列表项转为纯文本
+func texItems(items []string) []string {
+	var result []string
+	for _, item := range items {
+		if t := tex(markdown.Text(item)); t != "" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// \href和\url中的地址只需要转义#、%和反斜杠，花括号会破坏参数，直接去掉
+func texURL(u string) string {
+	return strings.NewReplacer(`\`, "", "{", "", "}", "", "#", `\#`, "%", `\%`, " ", "%20").Replace(u)
+}
+
+func escapeLabels(l staticLabels) staticLabels {
+	return staticLabels{
+		About: tex(l.About), Skills: tex(l.Skills), Experience: tex(l.Experience), Projects: tex(l.Projects),
+		Certificates: tex(l.Certificates), Responsibilities: tex(l.Responsibilities), Achievements: tex(l.Achievements),
+		KeyPoints: tex(l.KeyPoints), Demo: tex(l.Demo), Repo: tex(l.Repo), Updated: tex(l.Updated),
+		// 包含printf占位符，只转义%以外的字符
+		Years:     strings.ReplaceAll(tex(strings.ReplaceAll(l.Years, "%d", "\x00")), "\x00", "%d"),
+		TechStack: tex(l.TechStack), Location: tex(l.Location), Education: tex(l.Education),
+		Email: tex(l.Email), Phone: tex(l.Phone),
+	}
+}
+
+func hasCJK(texts ...string) bool {
+	for _, text := range texts {
+		for _, r := range text {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasCJKPortfolio(p models.Portfolio) bool {
+	for _, category := range p.SkillCategories {
+		if hasCJK(category.Name) {
+			return true
+		}
+		for _, skill := range category.Skills {
+			if hasCJK(skill.Name) {
+				return true
+			}
+		}
+	}
+	for _, exp := range p.Experiences {
+		if hasCJK(append(append([]string{exp.Title, exp.Company, exp.Period, exp.Location}, exp.Responsibilities...), exp.Achievements...)...) {
+			return true
+		}
+	}
+	for _, project := range p.Projects {
+		if hasCJK(append([]string{project.Title, project.Category, project.Description}, project.KeyPoints...)...) {
+			return true
+		}
+	}
+	for _, cert := range p.Certificates {
+		if hasCJK(cert.Name, cert.Organization, cert.Date, cert.Description) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"backend/markdown"
+	"backend/models"
+	"backend/portfolio"
+	"backend/seo"
+)
+
+// 纯文本简历格式
+const (
+	ResumeMarkdown = "md"
+	ResumeText     = "txt"
+)
+
+// ResumeTemplateDir 自定义简历模板目录，其中的resume.md.tmpl和resume.txt.tmpl优先于内置模板
+func ResumeTemplateDir() string {
+	return os.Getenv("RESUME_TEMPLATE_DIR")
+}
+
+var resumeFuncs = template.FuncMap{
+	"plain":   markdown.Text,
+	"join":    strings.Join,
+	"heading": heading,
+	// 只保留http和https链接，占位链接(如#)不输出
+	"url": func(u string) string { return seo.AbsoluteURL("", u) },
+}
+
+// 每次生成时读取模板，修改自定义模板后无需重启
+func resumeTemplate(format string) (*template.Template, error) {
+	name := "resume." + format + ".tmpl"
+	if dir := ResumeTemplateDir(); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return template.New(name).Funcs(resumeFuncs).ParseFiles(path)
+		}
+	}
+	return template.New(name).Funcs(resumeFuncs).ParseFS(templateFS, "templates/resume/"+name)
+}
+
+// Resume 将作品集快照渲染为Markdown或纯文本简历
+func Resume(p models.Portfolio, format string) ([]byte, error) {
+	tmpl, err := resumeTemplate(format)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Portfolio": p,
+		"Labels":    labelsFor(p.Locale),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tidy(buf.Bytes()), nil
+}
+
+// LoadResume 读取作品集快照，只保留选中的章节并渲染简历，format为docx时生成Word文档
+func LoadResume(opts portfolio.Options, sections Sections, format string) ([]byte, error) {
+	p, err := portfolio.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+	sections.Apply(&p)
+	if format == ResumeDocx {
+		return Docx(p)
+	}
+	return Resume(p, format)
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// 去掉行尾空白，连续空行合并为一行，模板中不必精确控制空白
+func tidy(content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return []byte(strings.TrimSpace(text) + "\n")
+}
+
+// 纯文本标题，下面用同样显示宽度的字符画线，中日韩字符按两列计算
+func heading(title, char string) string {
+	width := 0
+	for _, r := range title {
+		if r >= 0x1100 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return title + "\n" + strings.Repeat(char, width)
+}
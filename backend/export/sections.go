@@ -0,0 +1,73 @@
+package export
+
+import (
+	"errors"
+	"strings"
+
+	"backend/models"
+)
+
+// 简历中可单独选择的章节
+const (
+	SectionAbout        = "about"
+	SectionSkills       = "skills"
+	SectionExperiences  = "experiences"
+	SectionProjects     = "projects"
+	SectionCertificates = "certificates"
+)
+
+var allSections = []string{SectionAbout, SectionSkills, SectionExperiences, SectionProjects, SectionCertificates}
+
+// Sections 选中的章节，nil表示全部章节
+type Sections map[string]bool
+
+// ParseSections 解析以逗号分隔的章节列表，为空时返回nil(全部章节)
+func ParseSections(raw string) (Sections, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	sections := Sections{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		valid := false
+		for _, known := range allSections {
+			if name == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, errors.New("未知的章节: " + name + "，可选: " + strings.Join(allSections, ", "))
+		}
+		sections[name] = true
+	}
+	return sections, nil
+}
+
+// Has 是否包含某个章节
+func (s Sections) Has(name string) bool {
+	return s == nil || s[name]
+}
+
+// Apply 清空未选择的章节，个人信息的姓名、职位和联系方式始终保留
+func (s Sections) Apply(p *models.Portfolio) {
+	if !s.Has(SectionAbout) {
+		p.Profile.Introduction, p.Profile.IntroductionHTML = "", ""
+		p.Profile.Philosophy = ""
+	}
+	if !s.Has(SectionSkills) {
+		p.SkillCategories = []models.SkillCategory{}
+	}
+	if !s.Has(SectionExperiences) {
+		p.Experiences = []models.Experience{}
+	}
+	if !s.Has(SectionProjects) {
+		p.Projects = []models.Project{}
+	}
+	if !s.Has(SectionCertificates) {
+		p.Certificates = []models.Certificate{}
+	}
+}
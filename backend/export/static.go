@@ -0,0 +1,344 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/feed"
+	"backend/markdown"
+	"backend/models"
+	"backend/portfolio"
+	"backend/seo"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// 静态站点导出格式
+const (
+	FormatDir = "dir"
+	FormatZip = "zip"
+)
+
+// 静态站点中记录各文件校验值的清单，用于增量生成
+const manifestFile = "manifest.json"
+
+// 静态站点页面中的固定文字
+type staticLabels struct {
+	About, Skills, Experience, Projects, Certificates string
+	Responsibilities, Achievements, KeyPoints         string
+	Demo, Repo, Updated, Years                        string
+	TechStack, Location, Education, Email, Phone      string
+}
+
+var (
+	zhLabels = staticLabels{
+		About: "关于我", Skills: "专业技能", Experience: "工作经历", Projects: "项目经验", Certificates: "证书认证",
+		Responsibilities: "工作职责", Achievements: "主要成就", KeyPoints: "技术要点",
+		Demo: "演示", Repo: "源码", Updated: "更新于", Years: "%d年经验",
+		TechStack: "技术栈", Location: "所在地", Education: "学历", Email: "邮箱", Phone: "电话",
+	}
+	enLabels = staticLabels{
+		About: "About", Skills: "Skills", Experience: "Experience", Projects: "Projects", Certificates: "Certificates",
+		Responsibilities: "Responsibilities", Achievements: "Achievements", KeyPoints: "Highlights",
+		Demo: "Demo", Repo: "Source", Updated: "Updated", Years: "%d years of experience",
+		TechStack: "Tech stack", Location: "Location", Education: "Education", Email: "Email", Phone: "Phone",
+	}
+)
+
+// 中文内容使用中文标签，其余语言使用英文标签
+func labelsFor(locale string) staticLabels {
+	if strings.HasPrefix(locale, "zh") {
+		return zhLabels
+	}
+	return enLabels
+}
+
+var staticTemplate = template.Must(template.New("index.html").Funcs(template.FuncMap{
+	// Markdown字段由markdown包渲染，已转义原文中的HTML并过滤了链接协议
+	"safe": func(s string) template.HTML { return template.HTML(s) },
+}).ParseFS(templateFS, "templates/static/index.html"))
+
+// 静态站点清单
+type staticManifest struct {
+	Locale      string            `json:"locale"`
+	VariantID   int               `json:"variant_id,omitempty"`
+	ContentHash string            `json:"content_hash"`
+	UpdatedAt   time.Time         `json:"updated_at"` // 内容最近一次变化的时间
+	Files       map[string]string `json:"files"`      // 文件路径 -> SHA-256
+}
+
+// BuildStatic 将作品集快照渲染为静态站点文件，返回相对路径到文件内容的映射(不含清单)
+func BuildStatic(p models.Portfolio) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	css, err := templateFS.ReadFile("templates/static/style.css")
+	if err != nil {
+		return nil, err
+	}
+	files["style.css"] = css
+
+	data := map[string]interface{}{
+		"data/portfolio.json":    p,
+		"data/profile.json":      p.Profile,
+		"data/skills.json":       p.SkillCategories,
+		"data/experiences.json":  p.Experiences,
+		"data/projects.json":     p.Projects,
+		"data/certificates.json": p.Certificates,
+	}
+	for name, v := range data {
+		encoded, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[name] = append(encoded, '\n')
+	}
+
+	updated := ""
+	if !p.Profile.LastUpdated.IsZero() {
+		updated = p.Profile.LastUpdated.Format("2006-01-02")
+	}
+	var page bytes.Buffer
+	err = staticTemplate.Execute(&page, map[string]interface{}{
+		"Portfolio": p,
+		"Labels":    labelsFor(p.Locale),
+		"Summary":   seo.Summary(markdown.Text(p.Profile.Introduction), 160),
+		"Updated":   updated,
+	})
+	if err != nil {
+		return nil, err
+	}
+	files["index.html"] = page.Bytes()
+	return files, nil
+}
+
+// 静态站点可以被匿名访问，只包含在订阅源中公开的项目、工作经历和证书
+func loadStatic(opts portfolio.Options) (models.Portfolio, error) {
+	p, err := portfolio.Load(opts)
+	if err != nil {
+		return p, err
+	}
+	return feed.PublicOnly(p)
+}
+
+// Static 导出静态站点：output以.zip结尾时生成压缩包，否则写入目录。
+// 两种方式都只在内容变化时写入，目录方式只更新变化的文件并删除已不存在的文件
+func Static(opts portfolio.Options, output string) (models.StaticExport, error) {
+	p, err := loadStatic(opts)
+	if err != nil {
+		return models.StaticExport{}, err
+	}
+	files, err := BuildStatic(p)
+	if err != nil {
+		return models.StaticExport{}, err
+	}
+	if strings.HasSuffix(strings.ToLower(output), ".zip") {
+		return writeStaticZip(output, p, files)
+	}
+	return writeStaticDir(output, p, files)
+}
+
+// StaticZip 生成静态站点压缩包，用于直接下载
+func StaticZip(opts portfolio.Options) ([]byte, error) {
+	p, err := loadStatic(opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := BuildStatic(p)
+	if err != nil {
+		return nil, err
+	}
+	manifest := newManifest(p, files, time.Now())
+	return zipFiles(files, manifest)
+}
+
+func newManifest(p models.Portfolio, files map[string][]byte, now time.Time) staticManifest {
+	manifest := staticManifest{
+		Locale:    p.Locale,
+		VariantID: p.VariantID,
+		UpdatedAt: now,
+		Files:     map[string]string{},
+	}
+	overall := sha256.New()
+	for _, name := range sortedNames(files) {
+		sum := sha256.Sum256(files[name])
+		manifest.Files[name] = hex.EncodeToString(sum[:])
+		io.WriteString(overall, name+"\x00"+manifest.Files[name]+"\n")
+	}
+	manifest.ContentHash = hex.EncodeToString(overall.Sum(nil))
+	return manifest
+}
+
+func writeStaticDir(dir string, p models.Portfolio, files map[string][]byte) (models.StaticExport, error) {
+	now := time.Now()
+	manifest := newManifest(p, files, now)
+	result := models.StaticExport{
+		Output:      dir,
+		Format:      FormatDir,
+		ContentHash: manifest.ContentHash,
+		Files:       len(files),
+		Written:     []string{},
+		Removed:     []string{},
+		GeneratedAt: now,
+	}
+
+	var previous staticManifest
+	if raw, err := os.ReadFile(filepath.Join(dir, manifestFile)); err == nil {
+		json.Unmarshal(raw, &previous)
+	}
+
+	for _, name := range sortedNames(files) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		// 清单记录的校验值一致且文件仍在时跳过
+		if previous.Files[name] == manifest.Files[name] {
+			if _, err := os.Stat(path); err == nil {
+				result.Unchanged++
+				continue
+			}
+		}
+		if err := writeFileAtomic(path, files[name]); err != nil {
+			return result, err
+		}
+		result.Written = append(result.Written, name)
+	}
+
+	// 删除上次生成、这次已不存在的文件
+	for name := range previous.Files {
+		// 清单被改动过时不删除导出目录以外的文件
+		if _, ok := files[name]; ok || !filepath.IsLocal(filepath.FromSlash(name)) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+		result.Removed = append(result.Removed, name)
+	}
+	sort.Strings(result.Removed)
+
+	if previous.ContentHash == manifest.ContentHash {
+		if len(result.Written) == 0 && len(result.Removed) == 0 {
+			return result, nil
+		}
+		// 只是补回了被删除的文件，内容本身没有变化
+		manifest.UpdatedAt = previous.UpdatedAt
+	}
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	return result, writeFileAtomic(filepath.Join(dir, manifestFile), append(encoded, '\n'))
+}
+
+func writeStaticZip(path string, p models.Portfolio, files map[string][]byte) (models.StaticExport, error) {
+	now := time.Now()
+	manifest := newManifest(p, files, now)
+	result := models.StaticExport{
+		Output:      path,
+		Format:      FormatZip,
+		ContentHash: manifest.ContentHash,
+		Files:       len(files),
+		Written:     []string{},
+		Removed:     []string{},
+		GeneratedAt: now,
+	}
+
+	// 压缩包注释中保存内容校验值，内容未变化时不重新生成
+	if r, err := zip.OpenReader(path); err == nil {
+		unchanged := r.Comment == manifest.ContentHash
+		r.Close()
+		if unchanged {
+			result.Unchanged = len(files)
+			return result, nil
+		}
+	}
+
+	archive, err := zipFiles(files, manifest)
+	if err != nil {
+		return result, err
+	}
+	if err := writeFileAtomic(path, archive); err != nil {
+		return result, err
+	}
+	result.Written = sortedNames(files)
+	return result, nil
+}
+
+// 将文件和清单打包，文件时间统一为内容更新时间
+func zipFiles(files map[string][]byte, manifest staticManifest) ([]byte, error) {
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	all := map[string][]byte{manifestFile: append(encoded, '\n')}
+	for name, content := range files {
+		all[name] = content
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range sortedNames(all) {
+		f, err := w.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: manifest.UpdatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(all[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.SetComment(manifest.ContentHash); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 先写临时文件再改名，避免静态服务器读到写了一半的文件
+func writeFileAtomic(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/events"
+	"backend/models"
+	"backend/portfolio"
+)
+
+// 内容变更后等待的时间，合并短时间内的连续修改
+const regenerateDelay = 2 * time.Second
+
+var (
+	staticMu   sync.Mutex
+	lastStatic *models.StaticExport
+)
+
+// StaticDir 自动生成静态站点的输出位置(目录或.zip)，为空表示不自动生成
+func StaticDir() string {
+	return os.Getenv("STATIC_EXPORT_DIR")
+}
+
+// StaticOptions 自动生成静态站点使用的选项
+func StaticOptions() portfolio.Options {
+	variantID, _ := strconv.Atoi(os.Getenv("STATIC_EXPORT_VARIANT"))
+	return portfolio.Options{
+		Locale:         os.Getenv("STATIC_EXPORT_LANG"),
+		VariantID:      variantID,
+		IncludeContact: os.Getenv("STATIC_EXPORT_CONTACT") == "true",
+	}
+}
+
+// LastStatic 最近一次生成到STATIC_EXPORT_DIR的结果
+func LastStatic() (models.StaticExport, bool) {
+	staticMu.Lock()
+	defer staticMu.Unlock()
+	if lastStatic == nil {
+		return models.StaticExport{}, false
+	}
+	return *lastStatic, true
+}
+
+// RegenerateStatic 重新生成STATIC_EXPORT_DIR中的静态站点
+func RegenerateStatic() (models.StaticExport, error) {
+	staticMu.Lock()
+	defer staticMu.Unlock()
+
+	result, err := Static(StaticOptions(), StaticDir())
+	if err != nil {
+		result = models.StaticExport{Output: StaticDir(), GeneratedAt: time.Now(), Error: err.Error()}
+	}
+	lastStatic = &result
+	return result, err
+}
+
+// StartStaticWatcher 设置了STATIC_EXPORT_DIR时，启动时生成一次静态站点，之后在内容变更时增量更新
+func StartStaticWatcher() {
+	if StaticDir() == "" {
+		return
+	}
+	regenerate := func() {
+		result, err := RegenerateStatic()
+		if err != nil {
+			log.Printf("生成静态站点失败: %v", err)
+			return
+		}
+		if len(result.Written) > 0 || len(result.Removed) > 0 {
+			log.Printf("静态站点已更新: %s，写入%d个文件，删除%d个文件", result.Output, len(result.Written), len(result.Removed))
+		}
+	}
+
+	go func() {
+		regenerate()
+
+		var timer *time.Timer
+		events.Watch(64, func(e events.Event) bool {
+			return isContentEvent(e.Type)
+		}, func(events.Event) {
+			if timer == nil {
+				timer = time.AfterFunc(regenerateDelay, regenerate)
+			} else {
+				timer.Reset(regenerateDelay)
+			}
+		})
+	}()
+}
+
+// 会影响公开内容的事件
+func isContentEvent(eventType string) bool {
+	switch strings.SplitN(eventType, ".", 2)[0] {
+	case "profile", "skill", "experience", "project", "certificate", "feed":
+		return true
+	}
+	// 从备份恢复后全部内容都可能变化
+	if eventType == events.BackupRestored {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,304 @@
+package feed
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/events"
+	"backend/models"
+)
+
+// 订阅源包含的实体类型
+const (
+	TypeProject     = "project"
+	TypeExperience  = "experience"
+	TypeCertificate = "certificate"
+)
+
+// 条目可见性
+const (
+	Public  = "public"
+	Private = "private"
+)
+
+// 每种实体对应的数据表
+var tables = map[string]string{
+	TypeProject:     "projects",
+	TypeExperience:  "experiences",
+	TypeCertificate: "certificates",
+}
+
+// ErrNotFound 条目不存在
+var ErrNotFound = errors.New("订阅条目不存在")
+
+// Entry 一条内容的发布记录，ID由实体类型和实体ID生成，内容修改后保持不变。
+// 发布时间为首次设为公开的时间，之后再改为不公开、公开都不会改变
+type Entry struct {
+	ID          string    `json:"id"`
+	EntityType  string    `json:"entity_type"`
+	EntityID    int       `json:"entity_id"`
+	Visibility  string    `json:"visibility"`
+	PublishedAt time.Time `json:"published_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Setup 创建发布记录表，并为还没有记录的内容补上记录
+func Setup() error {
+	_, err := database.DB.Exec(`
+	CREATE TABLE IF NOT EXISTS feed_entries (
+		entity_type TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		visibility TEXT NOT NULL DEFAULT 'private',
+		published_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		first_public_at TIMESTAMP,
+		PRIMARY KEY (entity_type, entity_id)
+	)`)
+	if err != nil {
+		return err
+	}
+	// first_public_at 首次设为公开的时间，为空表示从未公开过
+	if err := database.AddColumnIfMissing("feed_entries", "first_public_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	// 添加字段前已经公开的条目保留原来的发布时间
+	_, err = database.DB.Exec("UPDATE feed_entries SET first_public_at = published_at WHERE visibility = ? AND first_public_at IS NULL", Public)
+	if err != nil {
+		return err
+	}
+
+	// 生成条目ID使用的站点命名空间，首次运行时随机生成，随数据库一起备份和恢复
+	_, err = database.DB.Exec(`
+	CREATE TABLE IF NOT EXISTS feed_settings (
+		name TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	_, err = database.DB.Exec("INSERT OR IGNORE INTO feed_settings (name, value) VALUES ('namespace', ?)", hex.EncodeToString(random))
+	if err != nil {
+		return err
+	}
+	return Sync()
+}
+
+// Sync 为已有内容补充发布记录并清理已删除内容的记录。
+// 补充的记录以当前时间作为发布时间，默认不公开，由管理员逐条设为public后才出现在订阅源和静态站点中
+func Sync() error {
+	now := time.Now()
+	for entityType, table := range tables {
+		result, err := database.DB.Exec(`
+			INSERT OR IGNORE INTO feed_entries (entity_type, entity_id, visibility, published_at, updated_at)
+			SELECT ?, id, ?, ?, ? FROM `+table, entityType, Private, now, now)
+		if err != nil {
+			return err
+		}
+		if added, _ := result.RowsAffected(); added > 0 {
+			log.Printf("已为%d条%s补充订阅记录", added, entityType)
+		}
+		_, err = database.DB.Exec(`
+			DELETE FROM feed_entries WHERE entity_type = ? AND entity_id NOT IN (SELECT id FROM `+table+`)`, entityType)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start 订阅内容变更事件，记录发布和更新时间
+func Start() {
+	events.Watch(64, func(e events.Event) bool {
+		return entityTypeOf(e.Type) != ""
+	}, handleEvent)
+}
+
+// 根据事件类型(如project.updated)得到实体类型
+func entityTypeOf(eventType string) string {
+	prefix := strings.SplitN(eventType, ".", 2)[0]
+	if _, ok := tables[prefix]; ok {
+		return prefix
+	}
+	return ""
+}
+
+func handleEvent(e events.Event) {
+	entityType := entityTypeOf(e.Type)
+
+	id := events.EntityID(e)
+	if id == 0 {
+		return
+	}
+
+	var err error
+	switch {
+	case strings.HasSuffix(e.Type, ".deleted"):
+		_, err = database.DB.Exec("DELETE FROM feed_entries WHERE entity_type = ? AND entity_id = ?", entityType, id)
+	case strings.HasSuffix(e.Type, ".created"):
+		_, err = database.DB.Exec(`
+			INSERT OR IGNORE INTO feed_entries (entity_type, entity_id, visibility, published_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)`, entityType, id, Private, e.Time, e.Time)
+	default:
+		_, err = database.DB.Exec(`
+			INSERT INTO feed_entries (entity_type, entity_id, visibility, published_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(entity_type, entity_id) DO UPDATE SET updated_at = excluded.updated_at`,
+			entityType, id, Private, e.Time, e.Time)
+	}
+	if err != nil {
+		log.Printf("更新订阅记录失败(%s %d): %v", entityType, id, err)
+	}
+}
+
+// List 按发布时间倒序返回发布记录，publicOnly为true时只返回公开的记录，limit为0时不限数量
+func List(publicOnly bool, limit int) ([]Entry, error) {
+	query := "SELECT entity_type, entity_id, visibility, published_at, updated_at FROM feed_entries"
+	if publicOnly {
+		query += " WHERE visibility = '" + Public + "'"
+	}
+	query += " ORDER BY published_at DESC, entity_type, entity_id DESC"
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	ns, err := namespace()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(&entry.EntityType, &entry.EntityID, &entry.Visibility, &entry.PublishedAt, &entry.UpdatedAt); err != nil {
+			return nil, err
+		}
+		entry.ID = uuidURN(ns, entry.EntityType+"-"+strconv.Itoa(entry.EntityID))
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// FeedID 订阅源本身的ID
+func FeedID() (string, error) {
+	ns, err := namespace()
+	if err != nil {
+		return "", err
+	}
+	return uuidURN(ns, "feed"), nil
+}
+
+// 读取站点命名空间
+func namespace() ([]byte, error) {
+	var value string
+	if err := database.DB.QueryRow("SELECT value FROM feed_settings WHERE name = 'namespace'").Scan(&value); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(value)
+}
+
+// 由命名空间和名称生成名称型UUID(RFC 4122第5版)。ID不包含站点地址，
+// 通过不同域名、租户路径访问或以后配置SITE_URL时，同一条目的ID都不变
+func uuidURN(ns []byte, name string) string {
+	h := sha1.New()
+	h.Write(ns)
+	h.Write([]byte(name))
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// SetVisibility 修改条目的可见性。首次设为公开时发布时间改为当前时间，
+// 避免不公开期间积累的旧日期让条目在阅读器中排在后面
+func SetVisibility(entityType string, entityID int, visibility string) (Entry, error) {
+	entry := Entry{EntityType: entityType, EntityID: entityID}
+	ns, err := namespace()
+	if err != nil {
+		return entry, err
+	}
+	entry.ID = uuidURN(ns, entityType+"-"+strconv.Itoa(entityID))
+	firstPublic, now := visibility == Public, time.Now()
+	err = database.DB.QueryRow(`
+		UPDATE feed_entries SET visibility = ?,
+			published_at = CASE WHEN ? AND first_public_at IS NULL THEN ? ELSE published_at END,
+			first_public_at = CASE WHEN ? AND first_public_at IS NULL THEN ? ELSE first_public_at END
+		WHERE entity_type = ? AND entity_id = ?
+		RETURNING visibility, published_at, updated_at`,
+		visibility, firstPublic, now, firstPublic, now, entityType, entityID).Scan(
+		&entry.Visibility, &entry.PublishedAt, &entry.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return entry, ErrNotFound
+	}
+	return entry, err
+}
+
+// PublicOnly 去掉作品集中未公开的项目、工作经历和证书，用于静态站点等匿名可见的输出。
+// 还没有发布记录的内容(如刚创建、尚未同步)视为未公开
+func PublicOnly(p models.Portfolio) (models.Portfolio, error) {
+	rows, err := database.DB.Query("SELECT entity_type, entity_id FROM feed_entries WHERE visibility = ?", Public)
+	if err != nil {
+		return p, err
+	}
+	defer rows.Close()
+	public := map[string]map[int]bool{}
+	for rows.Next() {
+		var entityType string
+		var entityID int
+		if err := rows.Scan(&entityType, &entityID); err != nil {
+			return p, err
+		}
+		if public[entityType] == nil {
+			public[entityType] = map[int]bool{}
+		}
+		public[entityType][entityID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return p, err
+	}
+
+	projects := []models.Project{}
+	for _, project := range p.Projects {
+		if public[TypeProject][project.ID] {
+			projects = append(projects, project)
+		}
+	}
+	experiences := []models.Experience{}
+	for _, exp := range p.Experiences {
+		if public[TypeExperience][exp.ID] {
+			experiences = append(experiences, exp)
+		}
+	}
+	certificates := []models.Certificate{}
+	for _, cert := range p.Certificates {
+		if public[TypeCertificate][cert.ID] {
+			certificates = append(certificates, cert)
+		}
+	}
+	p.Projects, p.Experiences, p.Certificates = projects, experiences, certificates
+	return p, nil
+}
+
+// ValidType 是否为订阅源包含的实体类型
+func ValidType(entityType string) bool {
+	_, ok := tables[entityType]
+	return ok
+}
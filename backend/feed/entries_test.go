@@ -0,0 +1,74 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"backend/database"
+)
+
+func TestSetVisibilityPublishedAt(t *testing.T) {
+	t.Setenv("DATA_DIR", t.TempDir())
+	if err := database.SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.DB.Close()
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	var projectID, certID int
+	if err := database.DB.QueryRow("SELECT id FROM projects ORDER BY id LIMIT 1").Scan(&projectID); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.QueryRow("SELECT id FROM certificates ORDER BY id LIMIT 1").Scan(&certID); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := database.DB.Exec("UPDATE feed_entries SET published_at = ?", old); err != nil {
+		t.Fatal(err)
+	}
+	// 添加first_public_at字段前已经公开的条目
+	if _, err := database.DB.Exec("UPDATE feed_entries SET visibility = ?, first_public_at = NULL WHERE entity_type = ? AND entity_id = ?",
+		Public, TypeCertificate, certID); err != nil {
+		t.Fatal(err)
+	}
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 首次公开时发布时间改为当前时间
+	before := time.Now().Add(-time.Second)
+	entry, err := SetVisibility(TypeProject, projectID, Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := entry.PublishedAt
+	if published.Before(before) {
+		t.Errorf("首次公开后发布时间 = %s, 期望为当前时间", published)
+	}
+
+	// 之后改为不公开再公开，发布时间不变
+	for _, visibility := range []string{Private, Public} {
+		entry, err = SetVisibility(TypeProject, projectID, visibility)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !entry.PublishedAt.Equal(published) {
+			t.Errorf("设为%s后发布时间 = %s, 期望保持 %s", visibility, entry.PublishedAt, published)
+		}
+	}
+
+	if _, err := SetVisibility(TypeProject, projectID+1000, Public); err != ErrNotFound {
+		t.Errorf("不存在的条目应返回ErrNotFound, 实际: %v", err)
+	}
+
+	// 升级前已公开的条目再次设为公开时不改变发布时间
+	entry, err = SetVisibility(TypeCertificate, certID, Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entry.PublishedAt.Equal(old) {
+		t.Errorf("已公开的条目发布时间 = %s, 期望保持 %s", entry.PublishedAt, old)
+	}
+}
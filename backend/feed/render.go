@@ -0,0 +1,435 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/markdown"
+	"backend/models"
+	"backend/seo"
+)
+
+// 订阅源最多包含的条目数
+const Limit = 50
+
+// 条目摘要的最大长度
+const summaryLimit = 300
+
+// 订阅源地址，相对于站点根地址
+const (
+	AtomPath = "/feed.atom"
+	RSSPath  = "/feed.rss"
+	JSONPath = "/feed.json"
+)
+
+// Feed 与格式无关的订阅源内容
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	HomeURL     string
+	Author      string
+	Language    string
+	Icon        string
+	Updated     time.Time
+	Items       []Item
+
+	site seo.Site
+}
+
+// Item 订阅源中的一条内容
+type Item struct {
+	ID        string // 稳定的条目ID，不随访问域名变化
+	URL       string
+	Title     string
+	Summary   string
+	Content   string // HTML
+	Category  string
+	Tags      []string
+	Image     string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Build 根据作品集内容和发布记录生成订阅源，id为FeedID返回的订阅源ID。
+// 作品集中不存在的条目(如尚未翻译缓存、已删除)直接跳过
+func Build(site seo.Site, id string, entries []Entry) Feed {
+	p := site.Portfolio
+	f := Feed{
+		ID:          id,
+		Title:       seo.HomePage(p).Title,
+		Description: seo.Summary(markdown.Text(p.Profile.Introduction), summaryLimit),
+		HomeURL:     site.URL("/", site.Locale),
+		Author:      p.Profile.Name,
+		Language:    site.Locale,
+		Icon:        seo.AbsoluteURL(site.BaseURL, p.Profile.Avatar),
+		Updated:     p.Profile.LastUpdated,
+		Items:       []Item{},
+		site:        site,
+	}
+
+	projects := map[int]models.Project{}
+	for _, project := range p.Projects {
+		projects[project.ID] = project
+	}
+	experiences := map[int]models.Experience{}
+	for _, exp := range p.Experiences {
+		experiences[exp.ID] = exp
+	}
+	certificates := map[int]models.Certificate{}
+	for _, cert := range p.Certificates {
+		certificates[cert.ID] = cert
+	}
+
+	for _, entry := range entries {
+		var item Item
+		var ok bool
+		switch entry.EntityType {
+		case TypeProject:
+			var project models.Project
+			if project, ok = projects[entry.EntityID]; ok {
+				item = projectItem(site, project)
+			}
+		case TypeExperience:
+			var exp models.Experience
+			if exp, ok = experiences[entry.EntityID]; ok {
+				item = experienceItem(site, exp)
+			}
+		case TypeCertificate:
+			var cert models.Certificate
+			if cert, ok = certificates[entry.EntityID]; ok {
+				item = certificateItem(site, cert)
+			}
+		}
+		if !ok {
+			continue
+		}
+		item.ID = entry.ID
+		if item.URL == "" {
+			item.URL = f.HomeURL + "#" + entry.EntityType + "-" + strconv.Itoa(entry.EntityID)
+		}
+		item.Published, item.Updated = entry.PublishedAt, entry.UpdatedAt
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		f.Items = append(f.Items, item)
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+	return f
+}
+
+func projectItem(site seo.Site, project models.Project) Item {
+	var content strings.Builder
+	content.WriteString(project.DescriptionHTML)
+	writeList(&content, project.KeyPoints, true)
+	if len(project.TechStack) > 0 {
+		content.WriteString("<p>" + html.EscapeString(strings.Join(project.TechStack, " · ")) + "</p>")
+	}
+	return Item{
+		URL:      seo.AbsoluteURL(site.BaseURL, project.DemoLink),
+		Title:    project.Title,
+		Summary:  seo.Summary(markdown.Text(project.Description), summaryLimit),
+		Content:  content.String(),
+		Category: project.Category,
+		Tags:     project.TechStack,
+		Image:    seo.AbsoluteURL(site.BaseURL, project.Image),
+	}
+}
+
+func experienceItem(site seo.Site, exp models.Experience) Item {
+	title := exp.Title
+	if exp.Company != "" {
+		title += " @ " + exp.Company
+	}
+	meta := exp.Period
+	if exp.Location != "" {
+		meta += " · " + exp.Location
+	}
+
+	var content strings.Builder
+	content.WriteString("<p>" + html.EscapeString(meta) + "</p>")
+	writeList(&content, exp.ResponsibilitiesHTML, false)
+	writeList(&content, exp.AchievementsHTML, false)
+
+	var summary []string
+	for _, item := range append(append([]string{}, exp.Responsibilities...), exp.Achievements...) {
+		summary = append(summary, markdown.Text(item))
+	}
+	return Item{
+		Title:   title,
+		Summary: seo.Summary(meta+" "+strings.Join(summary, " "), summaryLimit),
+		Content: content.String(),
+		Tags:    exp.Technologies,
+	}
+}
+
+func certificateItem(site seo.Site, cert models.Certificate) Item {
+	meta := cert.Organization
+	if cert.Date != "" {
+		if meta != "" {
+			meta += " · "
+		}
+		meta += cert.Date
+	}
+
+	var content strings.Builder
+	if meta != "" {
+		content.WriteString("<p>" + html.EscapeString(meta) + "</p>")
+	}
+	if cert.Description != "" {
+		content.WriteString("<p>" + html.EscapeString(cert.Description) + "</p>")
+	}
+	return Item{
+		URL:     seo.AbsoluteURL(site.BaseURL, cert.Link),
+		Title:   cert.Name,
+		Summary: seo.Summary(strings.TrimSpace(meta+" "+cert.Description), summaryLimit),
+		Content: content.String(),
+	}
+}
+
+// 写入列表，escape为true时列表项是纯文本，否则是已渲染的HTML
+func writeList(b *strings.Builder, items []string, escape bool) {
+	if len(items) == 0 {
+		return
+	}
+	b.WriteString("<ul>")
+	for _, item := range items {
+		if escape {
+			item = html.EscapeString(item)
+		}
+		b.WriteString("<li>" + item + "</li>")
+	}
+	b.WriteString("</ul>")
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// Atom 生成Atom 1.0订阅源
+func (f Feed) Atom() ([]byte, error) {
+	var entries []atomEntry
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: item.URL}},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: item.Content}
+		}
+		for _, term := range categories(item) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: term})
+		}
+		entries = append(entries, entry)
+	}
+
+	feed := struct {
+		XMLName  xml.Name    `xml:"feed"`
+		XMLNS    string      `xml:"xmlns,attr"`
+		Lang     string      `xml:"xml:lang,attr,omitempty"`
+		ID       string      `xml:"id"`
+		Title    string      `xml:"title"`
+		Subtitle string      `xml:"subtitle,omitempty"`
+		Updated  string      `xml:"updated"`
+		Author   atomPerson  `xml:"author"`
+		Links    []atomLink  `xml:"link"`
+		Icon     string      `xml:"icon,omitempty"`
+		Entries  []atomEntry `xml:"entry"`
+	}{
+		XMLNS:    "http://www.w3.org/2005/Atom",
+		Lang:     f.Language,
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Author:   atomPerson{Name: f.Author, URI: f.HomeURL},
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.site.URL(AtomPath, f.Language)},
+			{Rel: "alternate", Type: "text/html", Href: f.HomeURL},
+		},
+		Icon:    f.Icon,
+		Entries: entries,
+	}
+	return marshalXML(feed)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Body        string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+// RSS 生成RSS 2.0订阅源。RSS没有更新时间字段，条目使用发布时间，频道使用最后更新时间
+func (f Feed) RSS() ([]byte, error) {
+	var items []rssItem
+	for _, item := range f.Items {
+		description := item.Content
+		if description == "" {
+			description = html.EscapeString(item.Summary)
+		}
+		items = append(items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: false, Body: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Description: description,
+			Categories:  categories(item),
+		})
+	}
+
+	type channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		Language      string    `xml:"language,omitempty"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Self          atomLink  `xml:"atom:link"`
+		Items         []rssItem `xml:"item"`
+	}
+	feed := struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Atom    string   `xml:"xmlns:atom,attr"`
+		Channel channel  `xml:"channel"`
+	}{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: channel{
+			Title:         f.Title,
+			Link:          f.HomeURL,
+			Description:   f.Description,
+			Language:      f.Language,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Self:          atomLink{Rel: "self", Type: "application/rss+xml", Href: f.site.URL(RSSPath, f.Language)},
+			Items:         items,
+		},
+	}
+	return marshalXML(feed)
+}
+
+// JSON 生成JSON Feed 1.1订阅源
+func (f Feed) JSON() ([]byte, error) {
+	type author struct {
+		Name   string `json:"name,omitempty"`
+		URL    string `json:"url,omitempty"`
+		Avatar string `json:"avatar,omitempty"`
+	}
+	type item struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url,omitempty"`
+		Title         string   `json:"title"`
+		Summary       string   `json:"summary,omitempty"`
+		ContentHTML   string   `json:"content_html"`
+		Image         string   `json:"image,omitempty"`
+		DatePublished string   `json:"date_published"`
+		DateModified  string   `json:"date_modified"`
+		Tags          []string `json:"tags,omitempty"`
+	}
+
+	items := []item{}
+	for _, it := range f.Items {
+		items = append(items, item{
+			ID:            it.ID,
+			URL:           it.URL,
+			Title:         it.Title,
+			Summary:       it.Summary,
+			ContentHTML:   it.Content,
+			Image:         it.Image,
+			DatePublished: it.Published.UTC().Format(time.RFC3339),
+			DateModified:  it.Updated.UTC().Format(time.RFC3339),
+			Tags:          categories(it),
+		})
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(struct {
+		Version     string   `json:"version"`
+		Title       string   `json:"title"`
+		HomePageURL string   `json:"home_page_url"`
+		FeedURL     string   `json:"feed_url"`
+		Description string   `json:"description,omitempty"`
+		Icon        string   `json:"icon,omitempty"`
+		Language    string   `json:"language,omitempty"`
+		Authors     []author `json:"authors,omitempty"`
+		Items       []item   `json:"items"`
+	}{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.site.URL(JSONPath, f.Language),
+		Description: f.Description,
+		Icon:        f.Icon,
+		Language:    f.Language,
+		Authors:     []author{{Name: f.Author, URL: f.HomeURL, Avatar: f.Icon}},
+		Items:       items,
+	})
+	return buf.Bytes(), err
+}
+
+// 条目分类：项目类别在前，其后是技术标签
+func categories(item Item) []string {
+	var result []string
+	if item.Category != "" {
+		result = append(result, item.Category)
+	}
+	return append(result, item.Tags...)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	encoded, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(encoded, '\n')...), nil
+}
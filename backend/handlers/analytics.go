@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 访问路由对应的实体类型
+var visitEntityTypes = map[string]string{
+	"profile":      "profile",
+	"skills":       "skill",
+	"experiences":  "experience",
+	"projects":     "project",
+	"certificates": "certificate",
+	"testimonials": "testimonial",
+}
+
+// VisitTrackingMiddleware 访客访问记录中间件，需放在VisitorAuthMiddleware之后
+func VisitTrackingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// 只记录成功的请求
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		accessKey, _ := c.Get("visitorAccessKey")
+		key, _ := accessKey.(string)
+		if key == "" {
+			return
+		}
+
+		route := c.FullPath()
+		entityType, entityID := visitEntity(route, c.Param("id"))
+
+		var verifiedAt interface{}
+		if iat, ok := c.Get("visitorVerifiedAt"); ok {
+			if ts, ok := iat.(float64); ok {
+				verifiedAt = time.Unix(int64(ts), 0)
+			}
+		}
+
+		_, err := database.DB.Exec(`
+			INSERT INTO visits (access_key, session_id, route, entity_type, entity_id, user_agent, ip, verified_at, visited_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			key, visitSessionID(c), route, entityType, entityID,
+			c.Request.UserAgent(), coarseIP(c.ClientIP()), verifiedAt, time.Now())
+		if err != nil {
+			log.Printf("记录访客访问失败: %v", err)
+		}
+	}
+}
+
+// 解析路由对应的实体类型和ID
+func visitEntity(route, idParam string) (string, interface{}) {
+	segments := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+	entityType := visitEntityTypes[segments[0]]
+	if entityType == "" {
+		return "", nil
+	}
+	if id, err := strconv.Atoi(idParam); err == nil {
+		return entityType, id
+	}
+	return entityType, nil
+}
+
+// 获取访客会话ID，旧令牌没有sid时使用令牌哈希代替
+func visitSessionID(c *gin.Context) string {
+	if sid, ok := c.Get("visitorSessionID"); ok {
+		if s, ok := sid.(string); ok && s != "" {
+			return s
+		}
+	}
+	sum := sha256.Sum256([]byte(c.GetHeader("Authorization")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// 将IP粗化处理：IPv4保留/24网段，IPv6保留/48网段
+func coarseIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// 解析SQLite聚合函数返回的时间字符串
+func parseDBTime(value string) time.Time {
+	formats := []string{
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02T15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	}
+	value = strings.TrimSuffix(value, "Z")
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, value, time.UTC); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// 解析统计时间范围参数from/to(YYYY-MM-DD)，默认最近30天
+func analyticsRange(c *gin.Context) (time.Time, time.Time, bool) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, false
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, false
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
+
+// 查询访问统计汇总，accessKey非空时只统计该访客
+func queryVisitAnalytics(from, to time.Time, accessKey string) (models.VisitAnalytics, error) {
+	result := models.VisitAnalytics{
+		ByAccessKey: []models.VisitorKeyStats{},
+		ByProject:   []models.ProjectViewStats{},
+		ByDay:       []models.DailyVisitStats{},
+	}
+
+	where := " WHERE v.visited_at >= ? AND v.visited_at < ?"
+	args := []interface{}{from, to}
+	if accessKey != "" {
+		where += " AND v.access_key = ?"
+		args = append(args, accessKey)
+	}
+
+	err := database.DB.QueryRow(
+		"SELECT COUNT(*), COUNT(DISTINCT v.session_id) FROM visits v"+where, args...,
+	).Scan(&result.TotalViews, &result.UniqueSessions)
+	if err != nil {
+		return result, err
+	}
+
+	// 按访客密码统计。access_key不唯一，用子查询取一个密码，直接关联会使访问记录重复计数
+	rows, err := database.DB.Query(`
+		SELECT v.access_key,
+		COALESCE((SELECT MAX(va.value) FROM visitor_access va
+			WHERE va.access_key = v.access_key AND va.access_type = 'password'), ''),
+		COUNT(*), COUNT(DISTINCT v.session_id), MIN(v.visited_at), MAX(v.visited_at)
+		FROM visits v`+where+`
+		GROUP BY v.access_key
+		ORDER BY COUNT(*) DESC`, args...)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var s models.VisitorKeyStats
+		var firstSeen, lastSeen string
+		if err := rows.Scan(&s.AccessKey, &s.Password, &s.Views, &s.Sessions, &firstSeen, &lastSeen); err != nil {
+			rows.Close()
+			return result, err
+		}
+		s.FirstSeen = parseDBTime(firstSeen)
+		s.LastSeen = parseDBTime(lastSeen)
+		result.ByAccessKey = append(result.ByAccessKey, s)
+	}
+	rows.Close()
+
+	// 按项目统计
+	rows, err = database.DB.Query(`
+		SELECT v.entity_id, COALESCE(p.title, ''), COUNT(*), COUNT(DISTINCT v.session_id)
+		FROM visits v
+		LEFT JOIN projects p ON p.id = v.entity_id`+where+` AND v.entity_type = 'project' AND v.entity_id IS NOT NULL
+		GROUP BY v.entity_id
+		ORDER BY COUNT(*) DESC`, args...)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var s models.ProjectViewStats
+		if err := rows.Scan(&s.ProjectID, &s.Title, &s.Views, &s.Sessions); err != nil {
+			rows.Close()
+			return result, err
+		}
+		result.ByProject = append(result.ByProject, s)
+	}
+	rows.Close()
+
+	// 按天统计
+	rows, err = database.DB.Query(`
+		SELECT substr(v.visited_at, 1, 10) AS day, COUNT(*), COUNT(DISTINCT v.session_id)
+		FROM visits v`+where+`
+		GROUP BY day
+		ORDER BY day`, args...)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var s models.DailyVisitStats
+		if err := rows.Scan(&s.Day, &s.Views, &s.Sessions); err != nil {
+			rows.Close()
+			return result, err
+		}
+		result.ByDay = append(result.ByDay, s)
+	}
+	rows.Close()
+
+	return result, nil
+}
+
+// 查询访客会话列表，accessKey非空时只返回该访客的会话
+func queryVisitSessions(from, to time.Time, accessKey string, limit int) ([]models.VisitSession, error) {
+	where := " WHERE visited_at >= ? AND visited_at < ?"
+	args := []interface{}{from, to}
+	if accessKey != "" {
+		where += " AND access_key = ?"
+		args = append(args, accessKey)
+	}
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(`
+		SELECT session_id, MAX(access_key), COALESCE(MIN(verified_at), MIN(visited_at)), MAX(visited_at),
+		COUNT(*), MAX(COALESCE(user_agent, '')), MAX(COALESCE(ip, ''))
+		FROM visits`+where+`
+		GROUP BY session_id
+		ORDER BY MAX(visited_at) DESC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.VisitSession{}
+	for rows.Next() {
+		var s models.VisitSession
+		var verifiedAt, lastActivity string
+		if err := rows.Scan(&s.SessionID, &s.AccessKey, &verifiedAt, &lastActivity,
+			&s.Views, &s.UserAgent, &s.IP); err != nil {
+			return nil, err
+		}
+		s.VerifiedAt = parseDBTime(verifiedAt)
+		s.LastActivity = parseDBTime(lastActivity)
+		s.ActiveSeconds = int64(s.LastActivity.Sub(s.VerifiedAt).Seconds())
+		if s.ActiveSeconds < 0 {
+			s.ActiveSeconds = 0
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetVisitAnalytics 管理员获取访客统计汇总
+func GetVisitAnalytics(c *gin.Context) {
+	from, to, ok := analyticsRange(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "日期格式应为YYYY-MM-DD",
+		})
+		return
+	}
+
+	result, err := queryVisitAnalytics(from, to, c.Query("access_key"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取访客统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取访客统计成功",
+		Data:    result,
+	})
+}
+
+// GetVisitSessions 管理员获取访客会话列表，包含验证到最后活动的时长
+func GetVisitSessions(c *gin.Context) {
+	from, to, ok := analyticsRange(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "日期格式应为YYYY-MM-DD",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	sessions, err := queryVisitSessions(from, to, c.Query("access_key"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取访客会话失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取访客会话成功",
+		Data:    sessions,
+	})
+}
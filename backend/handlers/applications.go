@@ -0,0 +1,586 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 求职申请的阶段
+var applicationStages = map[string]bool{
+	"wishlist":  true, // 准备投递
+	"applied":   true, // 已投递
+	"screening": true, // 简历筛选
+	"interview": true, // 面试中
+	"offer":     true, // 已发offer
+	"accepted":  true, // 已接受
+	"rejected":  true, // 未通过
+	"withdrawn": true, // 主动放弃
+}
+
+// 查询求职申请及关联访客的浏览情况
+const applicationSelect = `
+	SELECT a.id, a.company, a.role, a.stage, COALESCE(a.job_url, ''), COALESCE(a.applied_at, ''),
+	COALESCE(a.contacts, ''), COALESCE(a.notes, ''), a.visitor_access_id,
+	COALESCE(va.access_key, ''), COALESCE(va.access_type, ''), a.created_at, a.updated_at,
+	(SELECT COUNT(*) FROM visits v WHERE v.access_key = va.access_key),
+	(SELECT MAX(v.visited_at) FROM visits v WHERE v.access_key = va.access_key)
+	FROM applications a
+	LEFT JOIN visitor_access va ON va.id = a.visitor_access_id`
+
+// 扫描一行求职申请数据
+func scanApplication(scanner interface{ Scan(...interface{}) error }) (models.Application, error) {
+	var app models.Application
+	var contactsJSON string
+	var lastViewed sql.NullString
+	err := scanner.Scan(
+		&app.ID, &app.Company, &app.Role, &app.Stage, &app.JobURL, &app.AppliedAt,
+		&contactsJSON, &app.Notes, &app.VisitorAccessID,
+		&app.AccessKey, &app.AccessType, &app.CreatedAt, &app.UpdatedAt,
+		&app.Views, &lastViewed,
+	)
+	if err != nil {
+		return app, err
+	}
+
+	app.Contacts = []models.ApplicationContact{}
+	if contactsJSON != "" {
+		if err := json.Unmarshal([]byte(contactsJSON), &app.Contacts); err != nil {
+			return app, err
+		}
+	}
+	if lastViewed.Valid {
+		t := parseDBTime(lastViewed.String)
+		app.LastViewedAt = &t
+	}
+	return app, nil
+}
+
+// 检查求职申请数据，返回错误提示，数据有效时返回空字符串
+func checkApplication(app *models.Application) string {
+	if app.Stage == "" {
+		app.Stage = "applied"
+	}
+	if !applicationStages[app.Stage] {
+		return "无效的申请阶段"
+	}
+	if app.AppliedAt != "" {
+		if _, err := time.Parse("2006-01-02", app.AppliedAt); err != nil {
+			return "投递日期格式应为YYYY-MM-DD"
+		}
+	}
+	if app.VisitorAccessID != nil {
+		var exists int
+		err := database.DB.QueryRow("SELECT 1 FROM visitor_access WHERE id = ?", *app.VisitorAccessID).Scan(&exists)
+		if err != nil {
+			return "关联的访客密码或分享链接不存在"
+		}
+	}
+	if app.Contacts == nil {
+		app.Contacts = []models.ApplicationContact{}
+	}
+	return ""
+}
+
+// 记录一次阶段变更
+func recordApplicationStage(tx *sql.Tx, applicationID int, fromStage, stage, note string, changedAt time.Time) error {
+	_, err := tx.Exec(
+		"INSERT INTO application_stages (application_id, from_stage, stage, note, changed_at) VALUES (?, ?, ?, ?, ?)",
+		applicationID, fromStage, stage, note, changedAt,
+	)
+	return err
+}
+
+// 读取求职申请的阶段变更记录，按时间先后排列
+func loadApplicationTimeline(applicationID int) ([]models.ApplicationStage, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, COALESCE(from_stage, ''), stage, COALESCE(note, ''), changed_at
+		FROM application_stages
+		WHERE application_id = ?
+		ORDER BY changed_at, id`, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	timeline := []models.ApplicationStage{}
+	for rows.Next() {
+		var stage models.ApplicationStage
+		if err := rows.Scan(&stage.ID, &stage.FromStage, &stage.Stage, &stage.Note, &stage.ChangedAt); err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, stage)
+	}
+	return timeline, rows.Err()
+}
+
+// 读取单个求职申请及阶段变更记录
+func loadApplication(id int) (models.Application, error) {
+	app, err := scanApplication(database.DB.QueryRow(applicationSelect+" WHERE a.id = ?", id))
+	if err != nil {
+		return app, err
+	}
+	app.Timeline, err = loadApplicationTimeline(id)
+	return app, err
+}
+
+// GetApplications 获取求职申请列表，可按stage筛选
+func GetApplications(c *gin.Context) {
+	query := applicationSelect
+	var args []interface{}
+	if stage := c.Query("stage"); stage != "" {
+		query += " WHERE a.stage = ?"
+		args = append(args, stage)
+	}
+	query += " ORDER BY a.updated_at DESC, a.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取求职申请列表失败: " + err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	applications := []models.Application{}
+	for rows.Next() {
+		app, err := scanApplication(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "读取求职申请数据失败: " + err.Error(),
+			})
+			return
+		}
+		applications = append(applications, app)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取求职申请列表成功",
+		Data:    applications,
+	})
+}
+
+// GetApplication 获取单个求职申请，包含阶段变更时间线
+func GetApplication(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请ID",
+		})
+		return
+	}
+
+	app, err := loadApplication(id)
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取求职申请成功",
+		Data:    app,
+	})
+}
+
+// CreateApplication 创建求职申请，同时记录初始阶段
+func CreateApplication(c *gin.Context) {
+	var app models.Application
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请数据: " + err.Error(),
+		})
+		return
+	}
+	if msg := checkApplication(&app); msg != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	contactsJSON, _ := json.Marshal(app.Contacts)
+	now := time.Now()
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建求职申请失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO applications
+		(company, role, stage, job_url, applied_at, contacts, notes, visitor_access_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		app.Company, app.Role, app.Stage, app.JobURL, app.AppliedAt, string(contactsJSON), app.Notes,
+		app.VisitorAccessID, now, now,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建求职申请失败: " + err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := recordApplicationStage(tx, int(id), "", app.Stage, "", now); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "记录申请阶段失败: " + err.Error(),
+		})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建求职申请失败: " + err.Error(),
+		})
+		return
+	}
+
+	created, err := loadApplication(int(id))
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "创建求职申请成功",
+		Data:    created,
+	})
+}
+
+// UpdateApplication 更新求职申请，阶段变化时记录到时间线
+func UpdateApplication(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请ID",
+		})
+		return
+	}
+
+	var app models.Application
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请数据: " + err.Error(),
+		})
+		return
+	}
+	if msg := checkApplication(&app); msg != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	var currentStage string
+	err = database.DB.QueryRow("SELECT stage FROM applications WHERE id = ?", id).Scan(&currentStage)
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+
+	contactsJSON, _ := json.Marshal(app.Contacts)
+	now := time.Now()
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新求职申请失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE applications SET company = ?, role = ?, stage = ?, job_url = ?, applied_at = ?,
+		contacts = ?, notes = ?, visitor_access_id = ?, updated_at = ?
+		WHERE id = ?`,
+		app.Company, app.Role, app.Stage, app.JobURL, app.AppliedAt,
+		string(contactsJSON), app.Notes, app.VisitorAccessID, now, id,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新求职申请失败: " + err.Error(),
+		})
+		return
+	}
+
+	if app.Stage != currentStage {
+		if err := recordApplicationStage(tx, id, currentStage, app.Stage, "", now); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "记录申请阶段失败: " + err.Error(),
+			})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新求职申请失败: " + err.Error(),
+		})
+		return
+	}
+
+	updated, err := loadApplication(id)
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "更新求职申请成功",
+		Data:    updated,
+	})
+}
+
+// ChangeApplicationStage 变更求职申请阶段并附带备注，如面试反馈
+func ChangeApplicationStage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请ID",
+		})
+		return
+	}
+
+	var req struct {
+		Stage string `json:"stage" binding:"required"`
+		Note  string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	if !applicationStages[req.Stage] {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的申请阶段",
+		})
+		return
+	}
+
+	var currentStage string
+	err = database.DB.QueryRow("SELECT stage FROM applications WHERE id = ?", id).Scan(&currentStage)
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+
+	now := time.Now()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "变更申请阶段失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE applications SET stage = ?, updated_at = ? WHERE id = ?", req.Stage, now, id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "变更申请阶段失败: " + err.Error(),
+		})
+		return
+	}
+	// 阶段不变时也记录，便于在同一阶段追加备注(如多轮面试)
+	if err := recordApplicationStage(tx, id, currentStage, req.Stage, req.Note, now); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "记录申请阶段失败: " + err.Error(),
+		})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "变更申请阶段失败: " + err.Error(),
+		})
+		return
+	}
+
+	updated, err := loadApplication(id)
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "变更申请阶段成功",
+		Data:    updated,
+	})
+}
+
+// DeleteApplication 删除求职申请及其阶段记录，关联的访客密码保留
+func DeleteApplication(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请ID",
+		})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除求职申请失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM applications WHERE id = ?", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除求职申请失败: " + err.Error(),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		respondApplicationError(c, sql.ErrNoRows, "")
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM application_stages WHERE application_id = ?", id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除申请阶段记录失败: " + err.Error(),
+		})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除求职申请失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "删除求职申请成功",
+	})
+}
+
+// GetApplicationAnalytics 获取求职申请关联访客的访问统计和会话，
+// 未指定from时从投递日期(没有时为创建日期)开始统计
+func GetApplicationAnalytics(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的求职申请ID",
+		})
+		return
+	}
+
+	app, err := loadApplication(id)
+	if err != nil {
+		respondApplicationError(c, err, "获取求职申请失败")
+		return
+	}
+	if app.AccessKey == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "该求职申请未关联访客密码或分享链接",
+		})
+		return
+	}
+
+	from, to, ok := analyticsRange(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "日期格式应为YYYY-MM-DD",
+		})
+		return
+	}
+	if c.Query("from") == "" {
+		from = app.CreatedAt
+		if t, err := time.ParseInLocation("2006-01-02", app.AppliedAt, time.Local); err == nil && t.Before(from) {
+			from = t
+		}
+	}
+
+	summary, err := queryVisitAnalytics(from, to, app.AccessKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取访客统计失败: " + err.Error(),
+		})
+		return
+	}
+	sessions, err := queryVisitSessions(from, to, app.AccessKey, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取访客会话失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取求职申请访问统计成功",
+		Data: models.ApplicationAnalytics{
+			ApplicationID: app.ID,
+			Company:       app.Company,
+			AccessKey:     app.AccessKey,
+			Summary:       summary,
+			Sessions:      sessions,
+		},
+	})
+}
+
+// 输出求职申请操作的错误
+func respondApplicationError(c *gin.Context, err error, message string) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定求职申请",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Message: message + ": " + err.Error(),
+	})
+}
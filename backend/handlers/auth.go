@@ -12,6 +12,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"backend/database"
+	"backend/events"
+	"backend/mailer"
 	"backend/models"
 )
 
@@ -27,6 +29,36 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+// 登录失败统计：同一IP在窗口期内失败次数达到阈值时通知站长
+const (
+	loginFailureThreshold = 5
+	loginFailureWindow    = 15 * time.Minute
+)
+
+var loginFailures = newRateLimiter(loginFailureThreshold, loginFailureWindow)
+
+// 记录一次登录失败，恰好达到阈值时发送一次告警邮件
+func recordLoginFailure(c *gin.Context, username string) {
+	ip := c.ClientIP()
+	count := loginFailures.Hit(ip)
+	events.Publish(events.LoginFailed, gin.H{
+		"username": username,
+		"ip":       ip,
+		"count":    count,
+	})
+	log.Printf("登录失败，来源IP: %s，用户名: %s，窗口内失败次数: %d", ip, username, count)
+
+	if count == loginFailureThreshold {
+		mailer.NotifyOwner(mailer.TemplateLoginFailures, map[string]interface{}{
+			"IP":       ip,
+			"Username": username,
+			"Count":    count,
+			"Window":   "15分钟",
+			"At":       time.Now(),
+		})
+	}
+}
+
 // 自定义JWT声明结构
 type Claims struct {
 	UserID   int    `json:"user_id"`
@@ -56,6 +88,7 @@ func Login(c *gin.Context) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
+			recordLoginFailure(c, loginReq.Username)
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Success: false,
 				Message: "用户名或密码错误",
@@ -72,6 +105,7 @@ func Login(c *gin.Context) {
 	// 验证密码
 	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(loginReq.Password))
 	if err != nil {
+		recordLoginFailure(c, loginReq.Username)
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
 			Message: "用户名或密码错误",
@@ -112,12 +146,38 @@ func Login(c *gin.Context) {
 	})
 }
 
+// 管理员事件流的路由
+const adminEventsRoute = "/api/admin/events"
+
+// 将管理员信息存入上下文
+func setAdminClaims(c *gin.Context, claims *Claims) {
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+}
+
 // AuthMiddleware 身份验证中间件
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log.Printf("管理员验证中间件处理请求: %s %s", c.Request.Method, c.Request.URL.Path)
 
 		authHeader := c.GetHeader("Authorization")
+		// 浏览器的EventSource无法设置请求头，事件流接口(且只有该接口)接受POST /api/admin/events/ticket换取的一次性票据
+		if authHeader == "" && c.FullPath() == adminEventsRoute {
+			if value := c.Query("ticket"); value != "" {
+				if claims, ok := redeemTicket(ticketAdminEvents, value); ok {
+					setAdminClaims(c, claims.(*Claims))
+					c.Next()
+					return
+				}
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "票据无效或已过期",
+				})
+				c.Abort()
+				return
+			}
+		}
 		if authHeader == "" {
 			log.Printf("请求缺少Authorization头")
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -149,9 +209,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 			// 将用户信息存入上下文
 			log.Printf("令牌验证成功，用户角色: %s", claims.Role)
-			c.Set("userID", claims.UserID)
-			c.Set("username", claims.Username)
-			c.Set("role", claims.Role)
+			setAdminClaims(c, claims)
 			c.Next()
 		} else {
 			log.Printf("令牌有效性检查失败")
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/backup"
+	"backend/events"
+	"backend/models"
+)
+
+// 上传恢复的备份压缩包大小上限
+const maxBackupUpload = 1 << 30
+
+// 输出备份操作的错误
+func respondBackupError(c *gin.Context, err error, message string) {
+	switch {
+	case err == backup.ErrNotFound:
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: err.Error()})
+	case err == backup.ErrInvalidName, errors.Is(err, backup.ErrInvalidArchive):
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message + ": " + err.Error(),
+		})
+	}
+}
+
+// GetBackups 列出备份目录中的备份
+func GetBackups(c *gin.Context) {
+	backups, err := backup.List()
+	if err != nil {
+		respondBackupError(c, err, "获取备份列表失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取备份列表成功",
+		Data:    backups,
+	})
+}
+
+// CreateBackup 立即生成一个备份，服务无需停止
+func CreateBackup(c *gin.Context) {
+	info, err := backup.Create("backup")
+	if err != nil {
+		respondBackupError(c, err, "生成备份失败")
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "备份已生成",
+		Data:    info,
+	})
+}
+
+// GetBackupStatus 定时备份的配置、下次执行时间和最近的执行记录(包括失败原因)
+func GetBackupStatus(c *gin.Context) {
+	status, err := backup.GetStatus()
+	if err != nil {
+		respondBackupError(c, err, "获取备份状态失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取备份状态成功",
+		Data:    status,
+	})
+}
+
+// RunBackupJob 立即执行一次定时备份任务：生成备份、上传到异地存储并清理旧备份
+func RunBackupJob(c *gin.Context) {
+	run, err := backup.RunNow(backup.TriggerManual)
+	switch {
+	case err == backup.ErrRunning:
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: err.Error()})
+	case err != nil && run.ID != 0:
+		// 任务已记录，返回记录以便查看生成了哪个备份、哪一步失败
+		c.JSON(http.StatusBadGateway, models.APIResponse{
+			Success: false,
+			Message: "备份任务失败: " + err.Error(),
+			Data:    run,
+		})
+	case err != nil:
+		respondBackupError(c, err, "备份任务失败")
+	default:
+		c.JSON(http.StatusCreated, models.APIResponse{
+			Success: true,
+			Message: "备份任务已完成",
+			Data:    run,
+		})
+	}
+}
+
+// DownloadBackup 下载备份压缩包
+func DownloadBackup(c *gin.Context) {
+	path, err := backup.Path(c.Param("name"))
+	if err != nil {
+		respondBackupError(c, err, "下载备份失败")
+		return
+	}
+	c.FileAttachment(path, c.Param("name"))
+}
+
+// DeleteBackup 删除备份
+func DeleteBackup(c *gin.Context) {
+	if err := backup.Delete(c.Param("name")); err != nil {
+		respondBackupError(c, err, "删除备份失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "备份已删除",
+	})
+}
+
+// RestoreBackup 从备份目录中的备份恢复
+func RestoreBackup(c *gin.Context) {
+	path, err := backup.Path(c.Param("name"))
+	if err != nil {
+		respondBackupError(c, err, "恢复备份失败")
+		return
+	}
+	restore(c, path)
+}
+
+// UploadRestore 上传备份压缩包(表单字段file)并恢复
+func UploadRestore(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "请上传备份压缩包(表单字段file)",
+		})
+		return
+	}
+	if file.Size > maxBackupUpload {
+		c.JSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+			Success: false,
+			Message: "备份压缩包不能超过1GB",
+		})
+		return
+	}
+
+	if err := os.MkdirAll(backup.Dir(), 0755); err != nil {
+		respondBackupError(c, err, "保存上传文件失败")
+		return
+	}
+	tmp, err := os.CreateTemp(backup.Dir(), ".upload-*.zip")
+	if err != nil {
+		respondBackupError(c, err, "保存上传文件失败")
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := c.SaveUploadedFile(file, tmp.Name()); err != nil {
+		respondBackupError(c, err, "保存上传文件失败")
+		return
+	}
+	restore(c, tmp.Name())
+}
+
+func restore(c *gin.Context, path string) {
+	result, err := backup.Restore(path)
+	if err != nil {
+		respondBackupError(c, err, "恢复备份失败")
+		return
+	}
+	events.Publish(events.BackupRestored, result)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "恢复成功，恢复前的数据已备份为" + result.SafetyBackup,
+		Data:    result,
+	})
+}
@@ -2,18 +2,23 @@ package handlers
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"backend/database"
+	"backend/events"
+	"backend/i18n"
 	"backend/models"
 )
 
 // GetCertificates 获取所有证书
 func GetCertificates(c *gin.Context) {
-	rows, err := database.DB.Query(`SELECT id, name, organization, date, description, icon, link, sort_order 
+	rows, err := database.DB.Query(`SELECT id, name, organization, date, description, icon, link, COALESCE(expiry_date, ''), sort_order 
 	                               FROM certificates ORDER BY sort_order ASC`)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -24,13 +29,15 @@ func GetCertificates(c *gin.Context) {
 	}
 	defer rows.Close()
 
+	scope := currentVariant(c)
+	translations := contentTranslations(c, i18n.Certificate)
 	var certificates []models.Certificate
 	for rows.Next() {
 		var cert models.Certificate
 
 		err := rows.Scan(
 			&cert.ID, &cert.Name, &cert.Organization, &cert.Date, &cert.Description,
-			&cert.Icon, &cert.Link, &cert.SortOrder,
+			&cert.Icon, &cert.Link, &cert.ExpiryDate, &cert.SortOrder,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -39,10 +46,19 @@ func GetCertificates(c *gin.Context) {
 			})
 			return
 		}
+		if !scope.Allows(variantCertificate, cert.ID) {
+			continue
+		}
 
+		i18n.TranslateCertificate(&cert, translations[cert.ID])
 		certificates = append(certificates, cert)
 	}
 
+	// 按简历版本中的顺序排列
+	sort.SliceStable(certificates, func(i, j int) bool {
+		return scope.Less(variantCertificate, certificates[i].ID, certificates[j].ID)
+	})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取证书列表成功",
@@ -61,12 +77,15 @@ func GetCertificate(c *gin.Context) {
 		})
 		return
 	}
+	if rejectOutsideVariant(c, variantCertificate, idInt, "证书不存在") {
+		return
+	}
 
 	var cert models.Certificate
-	err = database.DB.QueryRow(`SELECT id, name, organization, date, description, icon, link, sort_order 
+	err = database.DB.QueryRow(`SELECT id, name, organization, date, description, icon, link, COALESCE(expiry_date, ''), sort_order 
 	                           FROM certificates WHERE id = ?`, idInt).Scan(
 		&cert.ID, &cert.Name, &cert.Organization, &cert.Date, &cert.Description,
-		&cert.Icon, &cert.Link, &cert.SortOrder,
+		&cert.Icon, &cert.Link, &cert.ExpiryDate, &cert.SortOrder,
 	)
 
 	if err != nil {
@@ -84,6 +103,8 @@ func GetCertificate(c *gin.Context) {
 		return
 	}
 
+	i18n.TranslateCertificate(&cert, contentTranslations(c, i18n.Certificate)[cert.ID])
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取证书详情成功",
@@ -102,12 +123,22 @@ func CreateCertificate(c *gin.Context) {
 		return
 	}
 
+	if certificate.ExpiryDate != "" {
+		if _, err := time.Parse("2006-01-02", certificate.ExpiryDate); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "过期日期格式应为YYYY-MM-DD",
+			})
+			return
+		}
+	}
+
 	result, err := database.DB.Exec(
 		`INSERT INTO certificates 
-		(name, organization, date, description, icon, link, sort_order) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		(name, organization, date, description, icon, link, expiry_date, sort_order) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 		certificate.Name, certificate.Organization, certificate.Date, certificate.Description,
-		certificate.Icon, certificate.Link, certificate.SortOrder)
+		certificate.Icon, certificate.Link, certificate.ExpiryDate, certificate.SortOrder)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -121,6 +152,8 @@ func CreateCertificate(c *gin.Context) {
 	id, _ := result.LastInsertId()
 	certificate.ID = int(id)
 
+	events.Publish(events.CertificateCreated, certificate)
+
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "创建证书成功",
@@ -149,6 +182,16 @@ func UpdateCertificate(c *gin.Context) {
 		return
 	}
 
+	if certificate.ExpiryDate != "" {
+		if _, err := time.Parse("2006-01-02", certificate.ExpiryDate); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "过期日期格式应为YYYY-MM-DD",
+			})
+			return
+		}
+	}
+
 	// 检查记录是否存在
 	var exists int
 	err = database.DB.QueryRow("SELECT 1 FROM certificates WHERE id = ?", idInt).Scan(&exists)
@@ -162,10 +205,10 @@ func UpdateCertificate(c *gin.Context) {
 
 	_, err = database.DB.Exec(
 		`UPDATE certificates SET 
-		name=?, organization=?, date=?, description=?, icon=?, link=?, sort_order=? 
+		name=?, organization=?, date=?, description=?, icon=?, link=?, expiry_date=?, sort_order=? 
 		WHERE id=?`,
 		certificate.Name, certificate.Organization, certificate.Date, certificate.Description,
-		certificate.Icon, certificate.Link, certificate.SortOrder, idInt)
+		certificate.Icon, certificate.Link, certificate.ExpiryDate, certificate.SortOrder, idInt)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -175,6 +218,9 @@ func UpdateCertificate(c *gin.Context) {
 		return
 	}
 
+	certificate.ID = idInt
+	events.Publish(events.CertificateUpdated, certificate)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "更新证书成功",
@@ -214,6 +260,16 @@ func DeleteCertificate(c *gin.Context) {
 		return
 	}
 
+	if err := removeVariantItem(variantCertificate, idInt); err != nil {
+		log.Printf("从简历版本中移除证书%d失败: %v", idInt, err)
+	}
+
+	if err := i18n.Remove(i18n.Certificate, idInt); err != nil {
+		log.Printf("删除证书%d的翻译失败: %v", idInt, err)
+	}
+
+	events.Publish(events.CertificateDeleted, gin.H{"id": idInt})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "删除证书成功",
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/events"
+	"backend/mailer"
+	"backend/models"
+)
+
+// 联系表单限流：同一IP每10分钟最多提交5次
+var contactLimiter = newRateLimiter(5, 10*time.Minute)
+
+// SubmitContact 访客提交联系留言
+func SubmitContact(c *gin.Context) {
+	var req models.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的留言数据: " + err.Error(),
+		})
+		return
+	}
+
+	// 蜜罐字段被填写，说明是机器人提交，假装成功但不保存
+	if req.Website != "" {
+		log.Printf("联系表单蜜罐字段被触发，来源IP: %s", c.ClientIP())
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "留言发送成功",
+		})
+		return
+	}
+
+	if !contactLimiter.Allow(c.ClientIP()) {
+		c.JSON(http.StatusTooManyRequests, models.APIResponse{
+			Success: false,
+			Message: "提交过于频繁，请稍后再试",
+		})
+		return
+	}
+
+	msg := models.Message{
+		Name:      strings.TrimSpace(req.Name),
+		Email:     strings.TrimSpace(req.Email),
+		Subject:   strings.TrimSpace(req.Subject),
+		Body:      strings.TrimSpace(req.Body),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		CreatedAt: time.Now(),
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO messages (name, email, subject, body, ip, user_agent, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		msg.Name, msg.Email, msg.Subject, msg.Body, msg.IP, msg.UserAgent, msg.CreatedAt)
+	if err != nil {
+		log.Printf("保存联系留言失败: %v", err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "留言发送失败",
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	msg.ID = int(id)
+
+	events.Publish(events.MessageReceived, gin.H{
+		"id":      msg.ID,
+		"name":    msg.Name,
+		"email":   msg.Email,
+		"subject": msg.Subject,
+	})
+
+	// 邮件通知进入发送队列，不影响访客的提交结果
+	mailer.NotifyOwnerWithReply(msg.Email, mailer.TemplateContactMessage, msg)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "留言发送成功",
+	})
+}
+
+// GetMessages 管理员获取留言列表，status可选unread/read/archived，默认返回未归档留言
+func GetMessages(c *gin.Context) {
+	query := `SELECT id, name, email, subject, body, ip, user_agent, is_read, archived, created_at FROM messages`
+
+	switch c.Query("status") {
+	case "unread":
+		query += " WHERE archived = 0 AND is_read = 0"
+	case "read":
+		query += " WHERE archived = 0 AND is_read = 1"
+	case "archived":
+		query += " WHERE archived = 1"
+	case "all":
+	default:
+		query += " WHERE archived = 0"
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取留言列表失败: " + err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "解析留言数据失败: " + err.Error(),
+			})
+			return
+		}
+		messages = append(messages, msg)
+	}
+
+	var unread int
+	database.DB.QueryRow("SELECT COUNT(*) FROM messages WHERE is_read = 0 AND archived = 0").Scan(&unread)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取留言列表成功",
+		Data: gin.H{
+			"messages":     messages,
+			"unread_count": unread,
+		},
+	})
+}
+
+// GetMessage 管理员查看单条留言，查看后自动标记为已读
+func GetMessage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的留言ID",
+		})
+		return
+	}
+
+	msg, err := scanMessage(database.DB.QueryRow(`
+		SELECT id, name, email, subject, body, ip, user_agent, is_read, archived, created_at
+		FROM messages WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "未找到指定留言",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取留言失败: " + err.Error(),
+		})
+		return
+	}
+
+	if !msg.IsRead {
+		if _, err := database.DB.Exec("UPDATE messages SET is_read = 1 WHERE id = ?", id); err == nil {
+			msg.IsRead = true
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取留言成功",
+		Data:    msg,
+	})
+}
+
+// MarkMessageRead 管理员标记留言已读/未读
+func MarkMessageRead(c *gin.Context) {
+	var req struct {
+		Read bool `json:"read"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	updateMessageFlag(c, "is_read", req.Read)
+}
+
+// ArchiveMessage 管理员归档/取消归档留言
+func ArchiveMessage(c *gin.Context) {
+	var req struct {
+		Archived bool `json:"archived"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	updateMessageFlag(c, "archived", req.Archived)
+}
+
+// 更新留言的布尔状态字段
+func updateMessageFlag(c *gin.Context, column string, value bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的留言ID",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec("UPDATE messages SET "+column+" = ? WHERE id = ?", value, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新留言状态失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定留言",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "留言状态更新成功",
+	})
+}
+
+// DeleteMessage 管理员删除留言
+func DeleteMessage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的留言ID",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM messages WHERE id = ?", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除留言失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要删除的留言",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "留言删除成功",
+	})
+}
+
+// 扫描留言行数据
+func scanMessage(scanner interface{ Scan(...interface{}) error }) (models.Message, error) {
+	var msg models.Message
+	var ip, userAgent sql.NullString
+	err := scanner.Scan(&msg.ID, &msg.Name, &msg.Email, &msg.Subject, &msg.Body,
+		&ip, &userAgent, &msg.IsRead, &msg.Archived, &msg.CreatedAt)
+	msg.IP = ip.String
+	msg.UserAgent = userAgent.String
+	return msg, err
+}
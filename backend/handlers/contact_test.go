@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/mailer"
+)
+
+// smtpStub 进程内的最小SMTP服务器，把收到的每封邮件原文发送到messages
+type smtpStub struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{listener: l, messages: make(chan string, 10)}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSubmitContactStoresMessageAndMailsOwner(t *testing.T) {
+	stub := newSMTPStub(t)
+	t.Setenv("DATA_DIR", t.TempDir())
+	t.Setenv("SMTP_HOST", "127.0.0.1")
+	t.Setenv("SMTP_PORT", strconv.Itoa(stub.port()))
+	t.Setenv("SMTP_FROM", "site@example.com")
+	t.Setenv("NOTIFY_EMAIL", "owner@example.com")
+	t.Setenv("MAIL_DEV_DIR", "")
+	if err := database.SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.DB.Close()
+	mailer.Start()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/contact", SubmitContact)
+
+	body := `{"name":"张三","email":"zhangsan@example.com","subject":"合作邀请","body":"你好，想聊聊项目。"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/contact", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("状态码 = %d, 响应: %s", w.Code, w.Body.String())
+	}
+
+	var name, email, subject, text string
+	err := database.DB.QueryRow("SELECT name, email, subject, body FROM messages").Scan(&name, &email, &subject, &text)
+	if err != nil {
+		t.Fatalf("留言未保存: %v", err)
+	}
+	if name != "张三" || email != "zhangsan@example.com" || subject != "合作邀请" || text != "你好，想聊聊项目。" {
+		t.Errorf("保存的留言不正确: %q %q %q %q", name, email, subject, text)
+	}
+
+	var raw string
+	select {
+	case raw = <-stub.messages:
+	case <-time.After(10 * time.Second):
+		t.Fatal("SMTP服务器未收到通知邮件")
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	if to := msg.Header.Get("To"); !strings.Contains(to, "owner@example.com") {
+		t.Errorf("收件人 = %q", to)
+	}
+	if replyTo := msg.Header.Get("Reply-To"); !strings.Contains(replyTo, "zhangsan@example.com") {
+		t.Errorf("回复地址 = %q", replyTo)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || !strings.Contains(decoded, "合作邀请") {
+		t.Errorf("邮件主题 = %q (%v)", decoded, err)
+	}
+
+	// 发送成功后队列中的记录标记为已发送
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var status string
+		if err := database.DB.QueryRow("SELECT status FROM mail_queue").Scan(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status == "sent" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("邮件队列状态 = %q", status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRateLimiterSweepsIdleKeys(t *testing.T) {
+	l := newRateLimiter(2, time.Minute)
+	l.Allow("198.51.100.1")
+	l.Allow("198.51.100.2")
+
+	// 窗口期过后，其他key的请求会清理掉不再出现的key
+	l.mu.Lock()
+	for key := range l.hits {
+		l.hits[key][0] = l.hits[key][0].Add(-2 * time.Minute)
+	}
+	l.lastSweep = l.lastSweep.Add(-2 * time.Minute)
+	l.mu.Unlock()
+
+	if !l.Allow("198.51.100.3") {
+		t.Fatal("新的key应被允许")
+	}
+	if len(l.hits) != 1 {
+		t.Errorf("清理后应只剩1个key，实际%d个", len(l.hits))
+	}
+}
+
+func TestContactLimitIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	limiter := newRateLimiter(1, time.Minute)
+	r.GET("/", func(c *gin.Context) {
+		if !limiter.Allow(c.ClientIP()) {
+			c.Status(http.StatusTooManyRequests)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Forwarded-For", "10.0.0."+strconv.Itoa(i+1))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("第%d次请求状态码 = %d, 期望 %d", i+1, w.Code, want)
+		}
+	}
+}
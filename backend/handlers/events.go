@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/events"
+	"backend/models"
+)
+
+// SSE心跳间隔，防止代理因连接空闲而断开
+const sseHeartbeatInterval = 25 * time.Second
+
+// AdminEvents 管理员实时事件流(Server-Sent Events)
+// 支持Last-Event-ID断线重连补发，可用types参数按事件类型过滤(如 types=visitor.verified,project.*)
+func AdminEvents(c *gin.Context) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	lastID, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "当前连接不支持事件流",
+		})
+		return
+	}
+
+	sub := events.Subscribe(lastID, 64, eventTypeFilter(c.Query("types")))
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// 告诉浏览器断线后3秒重连
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			flusher.Flush()
+		case event, ok := <-sub.C:
+			if !ok {
+				// 订阅被服务端断开，客户端会带Last-Event-ID自动重连
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// 根据types参数生成事件过滤函数，支持以".*"结尾的前缀匹配
+func eventTypeFilter(types string) func(events.Event) bool {
+	if types == "" {
+		return nil
+	}
+
+	var patterns []string
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			patterns = append(patterns, t)
+		}
+	}
+
+	return func(e events.Event) bool {
+		for _, p := range patterns {
+			if events.Match(p, e.Type) {
+				return true
+			}
+		}
+		return false
+	}
+}
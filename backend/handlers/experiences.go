@@ -3,13 +3,19 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"backend/database"
+	"backend/events"
+	"backend/i18n"
+	"backend/markdown"
 	"backend/models"
+	"backend/taxonomy"
 )
 
 // GetExperiences 获取所有工作经历
@@ -28,6 +34,8 @@ func GetExperiences(c *gin.Context) {
 	}
 	defer rows.Close()
 
+	scope := currentVariant(c)
+	translations := contentTranslations(c, i18n.Experience)
 	experiences := []models.Experience{}
 	for rows.Next() {
 		var exp models.Experience
@@ -43,6 +51,9 @@ func GetExperiences(c *gin.Context) {
 			})
 			return
 		}
+		if !scope.Allows(variantExperience, exp.ID) {
+			continue
+		}
 
 		// 解析JSON数据
 		if responsibilitiesJSON != "" {
@@ -75,9 +86,16 @@ func GetExperiences(c *gin.Context) {
 			}
 		}
 
+		i18n.TranslateExperience(&exp, translations[exp.ID])
+		markdown.RenderExperience(&exp)
 		experiences = append(experiences, exp)
 	}
 
+	// 按简历版本中的顺序排列
+	sort.SliceStable(experiences, func(i, j int) bool {
+		return scope.Less(variantExperience, experiences[i].ID, experiences[j].ID)
+	})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取工作经历成功",
@@ -96,6 +114,9 @@ func GetExperience(c *gin.Context) {
 		})
 		return
 	}
+	if rejectOutsideVariant(c, variantExperience, expID, "未找到指定工作经历") {
+		return
+	}
 
 	var exp models.Experience
 	var responsibilitiesJSON, achievementsJSON, technologiesJSON string
@@ -155,6 +176,9 @@ func GetExperience(c *gin.Context) {
 		}
 	}
 
+	i18n.TranslateExperience(&exp, contentTranslations(c, i18n.Experience)[exp.ID])
+	markdown.RenderExperience(&exp)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取工作经历成功",
@@ -192,6 +216,16 @@ func CreateExperience(c *gin.Context) {
 		return
 	}
 
+	// 将技术名称规范化，别名统一替换为规范名称
+	exp.Technologies, err = taxonomy.Normalize(exp.Technologies)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "规范化技术名称失败: " + err.Error(),
+		})
+		return
+	}
+
 	technologiesJSON, err := json.Marshal(exp.Technologies)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -228,6 +262,13 @@ func CreateExperience(c *gin.Context) {
 	}
 	exp.ID = int(id)
 
+	if err := taxonomy.Link(taxonomy.EntityExperience, exp.ID, exp.Technologies); err != nil {
+		log.Printf("更新工作经历%d的技术关联失败: %v", exp.ID, err)
+	}
+
+	markdown.RenderExperience(&exp)
+	events.Publish(events.ExperienceCreated, exp)
+
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "工作经历创建成功",
@@ -278,6 +319,16 @@ func UpdateExperience(c *gin.Context) {
 		return
 	}
 
+	// 将技术名称规范化，别名统一替换为规范名称
+	exp.Technologies, err = taxonomy.Normalize(exp.Technologies)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "规范化技术名称失败: " + err.Error(),
+		})
+		return
+	}
+
 	technologiesJSON, err := json.Marshal(exp.Technologies)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -321,6 +372,13 @@ func UpdateExperience(c *gin.Context) {
 		return
 	}
 
+	if err := taxonomy.Link(taxonomy.EntityExperience, exp.ID, exp.Technologies); err != nil {
+		log.Printf("更新工作经历%d的技术关联失败: %v", exp.ID, err)
+	}
+
+	markdown.RenderExperience(&exp)
+	events.Publish(events.ExperienceUpdated, exp)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "工作经历更新成功",
@@ -366,6 +424,24 @@ func DeleteExperience(c *gin.Context) {
 		return
 	}
 
+	if err := taxonomy.Unlink(taxonomy.EntityExperience, expID); err != nil {
+		log.Printf("删除工作经历%d的技术关联失败: %v", expID, err)
+	}
+
+	if err := unlinkTestimonials("experience_id", expID); err != nil {
+		log.Printf("清除推荐信与工作经历%d的关联失败: %v", expID, err)
+	}
+
+	if err := removeVariantItem(variantExperience, expID); err != nil {
+		log.Printf("从简历版本中移除工作经历%d失败: %v", expID, err)
+	}
+
+	if err := i18n.Remove(i18n.Experience, expID); err != nil {
+		log.Printf("删除工作经历%d的翻译失败: %v", expID, err)
+	}
+
+	events.Publish(events.ExperienceDeleted, gin.H{"id": expID})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "工作经历删除成功",
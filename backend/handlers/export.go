@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/export"
+	"backend/models"
+	"backend/portfolio"
+)
+
+// 从查询参数读取导出选项：?lang=&variant_id=&include_contact=true
+func exportOptions(c *gin.Context) (portfolio.Options, bool) {
+	opts := portfolio.Options{
+		Locale:         c.Query("lang"),
+		IncludeContact: c.Query("include_contact") == "true",
+	}
+	if raw := c.Query("variant_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "无效的简历版本ID",
+			})
+			return opts, false
+		}
+		opts.VariantID = id
+	}
+	return opts, true
+}
+
+// 输出导出失败的错误
+func respondExportError(c *gin.Context, err error, message string) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "简历版本不存在",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Message: message + ": " + err.Error(),
+	})
+}
+
+// DownloadStaticSite 管理员下载静态站点压缩包
+func DownloadStaticSite(c *gin.Context) {
+	opts, ok := exportOptions(c)
+	if !ok {
+		return
+	}
+
+	archive, err := export.StaticZip(opts)
+	if err != nil {
+		respondExportError(c, err, "生成静态站点失败")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="portfolio-static.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// GetStaticSiteStatus 管理员查看自动生成的静态站点状态
+func GetStaticSiteStatus(c *gin.Context) {
+	status := gin.H{"output": export.StaticDir(), "enabled": export.StaticDir() != ""}
+	if last, ok := export.LastStatic(); ok {
+		status["last"] = last
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取静态站点状态成功",
+		Data:    status,
+	})
+}
+
+// RegenerateStaticSite 管理员立即重新生成STATIC_EXPORT_DIR中的静态站点，只写入变化的文件
+func RegenerateStaticSite(c *gin.Context) {
+	if export.StaticDir() == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "未设置STATIC_EXPORT_DIR，请使用下载压缩包的方式导出",
+		})
+		return
+	}
+
+	result, err := export.RegenerateStatic()
+	if err != nil {
+		respondExportError(c, err, "生成静态站点失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "静态站点已生成",
+		Data:    result,
+	})
+}
+
+// 访客下载简历：使用访客绑定的简历版本和请求语言，验证过的访客可以看到联系方式
+func visitorResumeOptions(c *gin.Context) portfolio.Options {
+	opts := portfolio.Options{Locale: requestLocale(c), IncludeContact: true}
+	if scope := currentVariant(c); scope != nil {
+		opts.VariantID = scope.ID
+	}
+	return opts
+}
+
+// 输出简历，?download=true时作为附件下载，Word文档始终作为附件
+func serveResume(c *gin.Context, opts portfolio.Options, format string) {
+	sections, ok := exportSections(c)
+	if !ok {
+		return
+	}
+	content, err := export.LoadResume(opts, sections, format)
+	if err != nil {
+		respondExportError(c, err, "生成简历失败")
+		return
+	}
+
+	contentType := "text/markdown; charset=utf-8"
+	switch format {
+	case export.ResumeText:
+		contentType = "text/plain; charset=utf-8"
+	case export.ResumeDocx:
+		contentType = export.DocxContentType
+	}
+	if c.Query("download") == "true" || format == export.ResumeDocx {
+		c.Header("Content-Disposition", `attachment; filename="resume.`+format+`"`)
+	}
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// GetResumeMarkdown 访客获取Markdown格式的简历
+func GetResumeMarkdown(c *gin.Context) {
+	serveResume(c, visitorResumeOptions(c), export.ResumeMarkdown)
+}
+
+// GetResumeText 访客获取纯文本格式的简历
+func GetResumeText(c *gin.Context) {
+	serveResume(c, visitorResumeOptions(c), export.ResumeText)
+}
+
+// GetResumeDocx 访客获取Word格式的简历
+func GetResumeDocx(c *gin.Context) {
+	serveResume(c, visitorResumeOptions(c), export.ResumeDocx)
+}
+
+// ExportResumeMarkdown 管理员按指定语言和简历版本导出Markdown简历
+func ExportResumeMarkdown(c *gin.Context) {
+	if opts, ok := exportOptions(c); ok {
+		serveResume(c, opts, export.ResumeMarkdown)
+	}
+}
+
+// ExportResumeText 管理员按指定语言和简历版本导出纯文本简历
+func ExportResumeText(c *gin.Context) {
+	if opts, ok := exportOptions(c); ok {
+		serveResume(c, opts, export.ResumeText)
+	}
+}
+
+// ExportResumeDocx 管理员按指定语言和简历版本导出Word简历
+func ExportResumeDocx(c *gin.Context) {
+	if opts, ok := exportOptions(c); ok {
+		serveResume(c, opts, export.ResumeDocx)
+	}
+}
+
+// 从查询参数读取章节：?sections=about,skills,experiences,projects,certificates，为空表示全部
+func exportSections(c *gin.Context) (export.Sections, bool) {
+	sections, err := export.ParseSections(c.Query("sections"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	return sections, true
+}
+
+// DownloadLatex 管理员下载LaTeX简历工程压缩包：?template=moderncv|awesome-cv，&cjk_font=指定中文字体
+func DownloadLatex(c *gin.Context) {
+	opts, ok := exportOptions(c)
+	if !ok {
+		return
+	}
+	sections, ok := exportSections(c)
+	if !ok {
+		return
+	}
+
+	latexOpts := export.LatexOptions{
+		Template: c.DefaultQuery("template", export.LatexModernCV),
+		CJKFont:  c.Query("cjk_font"),
+		SiteURL:  siteBaseURL(c) + "/",
+	}
+	archive, err := export.LatexZip(opts, sections, latexOpts)
+	if err == export.ErrUnknownTemplate {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "LaTeX模板不存在，可选: " + strings.Join(export.LatexTemplates(), ", "),
+		})
+		return
+	}
+	if err != nil {
+		respondExportError(c, err, "生成LaTeX工程失败")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="resume-`+latexOpts.Template+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// GetLatexTemplates 管理员查看可选的LaTeX模板
+func GetLatexTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取LaTeX模板成功",
+		Data:    export.LatexTemplates(),
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/events"
+	"backend/feed"
+	"backend/i18n"
+	"backend/models"
+)
+
+// 生成订阅源，内容与搜索引擎看到的一致：不含联系方式，只包含公开的条目
+func buildFeed(c *gin.Context) (feed.Feed, bool) {
+	locale := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+	c.Header("Vary", "Accept-Language")
+	c.Header("Content-Language", locale)
+
+	site, err := seoSite(c, locale)
+	if err != nil {
+		log.Printf("生成订阅源失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return feed.Feed{}, false
+	}
+	entries, err := feed.List(true, feed.Limit)
+	if err != nil {
+		log.Printf("读取订阅记录失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return feed.Feed{}, false
+	}
+	id, err := feed.FeedID()
+	if err != nil {
+		log.Printf("读取订阅源ID失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return feed.Feed{}, false
+	}
+	f := feed.Build(site, id, entries)
+	c.Header("Last-Modified", f.Updated.UTC().Format(http.TimeFormat))
+	return f, true
+}
+
+func serveFeed(c *gin.Context, contentType string, render func(feed.Feed) ([]byte, error)) {
+	f, ok := buildFeed(c)
+	if !ok {
+		return
+	}
+	content, err := render(f)
+	if err != nil {
+		log.Printf("生成订阅源失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// FeedAtom 生成Atom订阅源
+func FeedAtom(c *gin.Context) {
+	serveFeed(c, "application/atom+xml; charset=utf-8", feed.Feed.Atom)
+}
+
+// FeedRSS 生成RSS订阅源
+func FeedRSS(c *gin.Context) {
+	serveFeed(c, "application/rss+xml; charset=utf-8", feed.Feed.RSS)
+}
+
+// FeedJSON 生成JSON Feed订阅源
+func FeedJSON(c *gin.Context) {
+	serveFeed(c, "application/feed+json; charset=utf-8", feed.Feed.JSON)
+}
+
+// GetFeedEntries 管理员查看全部订阅条目及其可见性
+func GetFeedEntries(c *gin.Context) {
+	entries, err := feed.List(false, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取订阅条目失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取订阅条目成功",
+		Data:    entries,
+	})
+}
+
+// UpdateFeedEntry 修改订阅条目的可见性，条目默认为private，设为public后才出现在订阅源、静态站点和搜索引擎元数据中
+func UpdateFeedEntry(c *gin.Context) {
+	entityType := c.Param("type")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || !feed.ValidType(entityType) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的订阅条目",
+		})
+		return
+	}
+
+	var req struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Visibility != feed.Public && req.Visibility != feed.Private) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "可见性只能是public或private",
+		})
+		return
+	}
+
+	entry, err := feed.SetVisibility(entityType, id, req.Visibility)
+	if err == feed.ErrNotFound {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "订阅条目不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新订阅条目失败: " + err.Error(),
+		})
+		return
+	}
+
+	events.Publish(events.FeedUpdated, entry)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "更新订阅条目成功",
+		Data:    entry,
+	})
+}
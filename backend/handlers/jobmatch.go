@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/models"
+	"backend/taxonomy"
+)
+
+// 职位描述的最大长度(字符)
+const maxJobDescriptionLength = 20000
+
+// MatchJobDescription 管理员粘贴职位描述，分析与作品集的匹配程度
+func MatchJobDescription(c *gin.Context) {
+	var req models.JobMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	if len([]rune(req.Text)) > maxJobDescriptionLength {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "职位描述过长",
+		})
+		return
+	}
+
+	result, err := taxonomy.MatchJobDescription(req.Text)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "分析职位描述失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "分析职位描述成功",
+		Data:    result,
+	})
+}
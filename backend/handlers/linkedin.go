@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/events"
+	"backend/linkedin"
+	"backend/models"
+)
+
+// 领英数据压缩包的大小上限
+const maxLinkedInArchive = 32 << 20
+
+// 输出领英导入操作的错误
+func respondLinkedInError(c *gin.Context, err error, message string) {
+	var actionErr *linkedin.ActionError
+	switch {
+	case err == linkedin.ErrNotFound:
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: err.Error()})
+	case err == linkedin.ErrClosed:
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: err.Error()})
+	case err == linkedin.ErrInvalidArchive, err == linkedin.ErrEmptyArchive,
+		err == linkedin.ErrCategoryNotFound, errors.As(err, &actionErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message + ": " + err.Error(),
+		})
+	}
+}
+
+func linkedInImportID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的导入ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// UploadLinkedInImport 上传领英导出的数据压缩包(表单字段file)，返回导入预览，确认前不修改任何内容
+func UploadLinkedInImport(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "请上传领英导出的zip文件(表单字段file)",
+		})
+		return
+	}
+	if file.Size > maxLinkedInArchive {
+		c.JSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+			Success: false,
+			Message: "压缩包不能超过32MB",
+		})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		respondLinkedInError(c, err, "读取上传文件失败")
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxLinkedInArchive))
+	if err != nil {
+		respondLinkedInError(c, err, "读取上传文件失败")
+		return
+	}
+
+	archive, err := linkedin.Read(data)
+	if err != nil {
+		respondLinkedInError(c, err, "解析压缩包失败")
+		return
+	}
+	imp, err := linkedin.Save(filepath.Base(file.Filename), archive)
+	if err != nil {
+		respondLinkedInError(c, err, "保存导入记录失败")
+		return
+	}
+	imp, err = linkedin.Get(imp.ID)
+	if err != nil {
+		respondLinkedInError(c, err, "生成导入预览失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "压缩包解析成功，请确认后导入",
+		Data:    imp,
+	})
+}
+
+// GetLinkedInImports 获取领英导入记录
+func GetLinkedInImports(c *gin.Context) {
+	imports, err := linkedin.List()
+	if err != nil {
+		respondLinkedInError(c, err, "获取导入记录失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取导入记录成功",
+		Data:    imports,
+	})
+}
+
+// GetLinkedInImport 获取单个导入记录，等待确认时附带按当前内容生成的预览
+func GetLinkedInImport(c *gin.Context) {
+	id, ok := linkedInImportID(c)
+	if !ok {
+		return
+	}
+	imp, err := linkedin.Get(id)
+	if err != nil {
+		respondLinkedInError(c, err, "获取导入记录失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取导入记录成功",
+		Data:    imp,
+	})
+}
+
+// CommitLinkedInImport 确认导入，请求体可为每个导入项指定处理方式
+func CommitLinkedInImport(c *gin.Context) {
+	id, ok := linkedInImportID(c)
+	if !ok {
+		return
+	}
+	var opts linkedin.Options
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "无效的请求数据: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	result, changes, err := linkedin.Commit(id, opts)
+	if err != nil {
+		respondLinkedInError(c, err, "导入失败")
+		return
+	}
+	for _, change := range changes {
+		events.Publish(change.Event, change.Data)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "导入完成",
+		Data:    result,
+	})
+}
+
+// DiscardLinkedInImport 放弃等待确认的导入
+func DiscardLinkedInImport(c *gin.Context) {
+	id, ok := linkedInImportID(c)
+	if !ok {
+		return
+	}
+	if err := linkedin.Discard(id); err != nil {
+		respondLinkedInError(c, err, "放弃导入失败")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "已放弃导入",
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/mailer"
+	"backend/models"
+)
+
+// GetMailQueue 管理员查看邮件发送队列
+func GetMailQueue(c *gin.Context) {
+	items, err := mailer.ListQueue(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取邮件队列失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取邮件队列成功",
+		Data:    items,
+	})
+}
+
+// RetryMail 管理员重新发送失败的邮件
+func RetryMail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的邮件ID",
+		})
+		return
+	}
+
+	ok, err := mailer.Retry(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "重新发送邮件失败: " + err.Error(),
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到待重发的邮件",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "邮件已重新加入发送队列",
+	})
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"backend/database"
+	"backend/events"
+	"backend/i18n"
+	"backend/markdown"
 	"backend/models"
 )
 
@@ -50,6 +53,23 @@ func GetProfile(c *gin.Context) {
 		profile.ResumeFileURL = ""
 	}
 
+	i18n.TranslateProfile(&profile, contentTranslations(c, i18n.Profile)[profile.ID])
+
+	// 访客绑定了简历版本时使用版本中的职位和简介
+	if scope := currentVariant(c); scope != nil {
+		title, introduction := scope.Title, scope.Introduction
+		values := contentTranslations(c, i18n.Variant)[scope.ID]
+		i18n.TranslateString(&title, values, "title")
+		i18n.TranslateString(&introduction, values, "introduction")
+		if title != "" {
+			profile.Title = title
+		}
+		if introduction != "" {
+			profile.Introduction = introduction
+		}
+	}
+	markdown.RenderProfile(&profile)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取个人信息成功",
@@ -122,6 +142,9 @@ func UpdateProfile(c *gin.Context) {
 		profile.ID = int(id)
 	}
 
+	markdown.RenderProfile(&profile)
+	events.Publish(events.ProfileUpdated, profile)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "个人信息更新成功",
@@ -3,13 +3,19 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"backend/database"
+	"backend/events"
+	"backend/i18n"
+	"backend/markdown"
 	"backend/models"
+	"backend/taxonomy"
 )
 
 // GetProjects 获取所有项目经验
@@ -26,6 +32,8 @@ func GetProjects(c *gin.Context) {
 	}
 	defer rows.Close()
 
+	scope := currentVariant(c)
+	translations := contentTranslations(c, i18n.Project)
 	var projects []models.Project
 	for rows.Next() {
 		var p models.Project
@@ -42,6 +50,9 @@ func GetProjects(c *gin.Context) {
 			})
 			return
 		}
+		if !scope.Allows(variantProject, p.ID) {
+			continue
+		}
 
 		// 解析JSON字段
 		var metrics []models.Metric
@@ -59,9 +70,16 @@ func GetProjects(c *gin.Context) {
 			p.TechStack = techStack
 		}
 
+		i18n.TranslateProject(&p, translations[p.ID])
+		markdown.RenderProject(&p)
 		projects = append(projects, p)
 	}
 
+	// 按简历版本中的顺序排列
+	sort.SliceStable(projects, func(i, j int) bool {
+		return scope.Less(variantProject, projects[i].ID, projects[j].ID)
+	})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取项目列表成功",
@@ -80,6 +98,9 @@ func GetProject(c *gin.Context) {
 		})
 		return
 	}
+	if rejectOutsideVariant(c, variantProject, idInt, "项目不存在") {
+		return
+	}
 
 	var p models.Project
 	var metricsJSON, keyPointsJSON, techStackJSON string
@@ -122,6 +143,9 @@ func GetProject(c *gin.Context) {
 		p.TechStack = techStack
 	}
 
+	i18n.TranslateProject(&p, contentTranslations(c, i18n.Project)[p.ID])
+	markdown.RenderProject(&p)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "获取项目详情成功",
@@ -140,6 +164,17 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	// 将技术名称规范化，别名统一替换为规范名称
+	var err error
+	project.TechStack, err = taxonomy.Normalize(project.TechStack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "规范化技术名称失败: " + err.Error(),
+		})
+		return
+	}
+
 	// 转换为JSON存储
 	metricsJSON, _ := json.Marshal(project.Metrics)
 	keyPointsJSON, _ := json.Marshal(project.KeyPoints)
@@ -165,6 +200,13 @@ func CreateProject(c *gin.Context) {
 	id, _ := result.LastInsertId()
 	project.ID = int(id)
 
+	if err := taxonomy.Link(taxonomy.EntityProject, project.ID, project.TechStack); err != nil {
+		log.Printf("更新项目%d的技术关联失败: %v", project.ID, err)
+	}
+
+	markdown.RenderProject(&project)
+	events.Publish(events.ProjectCreated, project)
+
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "创建项目成功",
@@ -204,6 +246,16 @@ func UpdateProject(c *gin.Context) {
 		return
 	}
 
+	// 将技术名称规范化，别名统一替换为规范名称
+	project.TechStack, err = taxonomy.Normalize(project.TechStack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "规范化技术名称失败: " + err.Error(),
+		})
+		return
+	}
+
 	// 转换为JSON存储
 	metricsJSON, _ := json.Marshal(project.Metrics)
 	keyPointsJSON, _ := json.Marshal(project.KeyPoints)
@@ -226,6 +278,14 @@ func UpdateProject(c *gin.Context) {
 		return
 	}
 
+	project.ID = idInt
+	if err := taxonomy.Link(taxonomy.EntityProject, project.ID, project.TechStack); err != nil {
+		log.Printf("更新项目%d的技术关联失败: %v", project.ID, err)
+	}
+
+	markdown.RenderProject(&project)
+	events.Publish(events.ProjectUpdated, project)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "更新项目成功",
@@ -265,6 +325,24 @@ func DeleteProject(c *gin.Context) {
 		return
 	}
 
+	if err := taxonomy.Unlink(taxonomy.EntityProject, idInt); err != nil {
+		log.Printf("删除项目%d的技术关联失败: %v", idInt, err)
+	}
+
+	if err := unlinkTestimonials("project_id", idInt); err != nil {
+		log.Printf("清除推荐信与项目%d的关联失败: %v", idInt, err)
+	}
+
+	if err := removeVariantItem(variantProject, idInt); err != nil {
+		log.Printf("从简历版本中移除项目%d失败: %v", idInt, err)
+	}
+
+	if err := i18n.Remove(i18n.Project, idInt); err != nil {
+		log.Printf("删除项目%d的翻译失败: %v", idInt, err)
+	}
+
+	events.Publish(events.ProjectDeleted, gin.H{"id": idInt})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "删除项目成功",
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// TrustedProxies 可信的反向代理地址(IP或CIDR)，从TRUSTED_PROXIES读取，逗号分隔。
+// 未设置时不信任任何代理，客户端IP取连接的来源地址，X-Forwarded-For等请求头被忽略
+func TrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// FromTrustedProxy 判断直接连接的来源地址是否为可信的反向代理，
+// 只有这时才采用X-Forwarded-Host、X-Forwarded-Proto等转发头
+func FromTrustedProxy(remoteIP string) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+	for _, p := range TrustedProxies() {
+		if strings.Contains(p, "/") {
+			if _, network, err := net.ParseCIDR(p); err == nil && network.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(p); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter 基于内存的滑动窗口限流器，按key(通常是客户端IP)计数
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+// 创建限流器：window时间内同一个key最多允许limit次请求
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[string][]time.Time),
+	}
+}
+
+// Allow 记录一次请求并返回是否允许通过
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	recent := l.prune(key, now)
+	if len(recent) >= l.limit {
+		l.hits[key] = recent
+		return false
+	}
+	l.hits[key] = append(recent, now)
+	return true
+}
+
+// Hit 记录一次事件并返回窗口内的累计次数(不做拦截，用于统计失败次数等场景)
+func (l *rateLimiter) Hit(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	recent := append(l.prune(key, now), now)
+	l.hits[key] = recent
+	return len(recent)
+}
+
+// 清理窗口外的记录，返回窗口内的请求时间
+func (l *rateLimiter) prune(key string, now time.Time) []time.Time {
+	cutoff := now.Add(-l.window)
+	recent := l.hits[key][:0]
+	for _, t := range l.hits[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(l.hits, key)
+	}
+	return recent
+}
+
+// 每个窗口期清理一次所有key，不再出现的key也会被删除，避免记录无限增长
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key := range l.hits {
+		if recent := l.prune(key, now); len(recent) > 0 {
+			l.hits[key] = recent
+		}
+	}
+}
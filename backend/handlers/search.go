@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/models"
+	"backend/search"
+)
+
+// Search 访客全文搜索项目、工作经历、技能和证书
+func Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "搜索关键词不能为空",
+		})
+		return
+	}
+	if len([]rune(q)) > 100 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "搜索关键词过长",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	// 访客绑定了简历版本时在查询中过滤，保证版本内有足够的匹配时仍返回limit条
+	var allow func(string, int) bool
+	if scope := currentVariant(c); scope != nil {
+		allow = scope.Allows
+	}
+	results, err := search.Search(q, limit, allow)
+	if err != nil {
+		log.Printf("全文搜索失败(%s): %v", q, err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "搜索失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "搜索成功",
+		Data:    results,
+	})
+}
+
+// RebuildSearchIndex 管理员手动重建全文索引
+func RebuildSearchIndex(c *gin.Context) {
+	if err := search.Rebuild(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "重建搜索索引失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "重建搜索索引成功",
+	})
+}
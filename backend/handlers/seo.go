@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/feed"
+	"backend/i18n"
+	"backend/models"
+	"backend/portfolio"
+	"backend/seo"
+)
+
+// 前端入口页面，由前端构建生成
+const indexFile = "./public/index.html"
+
+// 元数据使用的作品集内容缓存时间，内容修改后最多延迟这么久在链接预览中生效
+const seoCacheTTL = 30 * time.Second
+
+var seoConfig = seo.LoadConfig()
+
+// 不需要被搜索引擎收录的前端路由
+var seoPrivateRoutes = map[string]string{
+	"/verify": "访客验证",
+	"/login":  "管理员登录",
+	"/admin":  "管理后台",
+}
+
+var (
+	indexMu      sync.Mutex
+	indexContent string
+	indexModTime time.Time
+
+	seoCacheMu sync.Mutex
+	seoCache   = map[string]seoCacheEntry{}
+)
+
+type seoCacheEntry struct {
+	portfolio models.Portfolio
+	loadedAt  time.Time
+}
+
+// 读取入口页面，文件更新(重新构建前端)后自动重新读取
+func loadIndex() (string, error) {
+	info, err := os.Stat(indexFile)
+	if err != nil {
+		return "", err
+	}
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if indexContent == "" || !info.ModTime().Equal(indexModTime) {
+		raw, err := os.ReadFile(indexFile)
+		if err != nil {
+			return "", err
+		}
+		indexContent, indexModTime = string(raw), info.ModTime()
+	}
+	return indexContent, nil
+}
+
+// 读取公开的作品集内容，不含联系方式，不使用简历版本，只包含订阅源中公开的项目、工作经历和证书
+func seoPortfolio(locale string) (models.Portfolio, error) {
+	seoCacheMu.Lock()
+	entry, ok := seoCache[locale]
+	if !ok || time.Since(entry.loadedAt) >= seoCacheTTL {
+		p, err := portfolio.Load(portfolio.Options{Locale: locale})
+		if err != nil {
+			seoCacheMu.Unlock()
+			return p, err
+		}
+		entry = seoCacheEntry{portfolio: p, loadedAt: time.Now()}
+		seoCache[locale] = entry
+	}
+	seoCacheMu.Unlock()
+	// 可见性每次重新过滤，设为公开或不公开后立即生效
+	return feed.PublicOnly(entry.portfolio)
+}
+
+// 站点的公开地址：优先使用SITE_URL，否则根据请求推断。
+// 只有请求来自可信的反向代理(包括多租户网关)时才采用转发头，避免伪造的Host出现在页面和站点地图中
+func siteBaseURL(c *gin.Context) string {
+	if seoConfig.SiteURL != "" {
+		return seoConfig.SiteURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	host := c.Request.Host
+	prefix := ""
+	if FromTrustedProxy(c.RemoteIP()) {
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+		prefix = strings.TrimSuffix(c.GetHeader("X-Forwarded-Prefix"), "/")
+	}
+	return scheme + "://" + host + prefix
+}
+
+func seoSite(c *gin.Context, locale string) (seo.Site, error) {
+	settings := i18n.Settings()
+	p, err := seoPortfolio(locale)
+	return seo.Site{
+		BaseURL:      siteBaseURL(c),
+		Locale:       locale,
+		Locales:      settings.Supported,
+		Base:         settings.Base,
+		Portfolio:    p,
+		OmitProjects: !seoConfig.Projects,
+	}, err
+}
+
+// ServeIndex 返回前端入口页面，并按路由插入标题、描述、Open Graph、Twitter卡片和JSON-LD
+func ServeIndex(c *gin.Context) {
+	page, err := loadIndex()
+	if err != nil {
+		c.String(http.StatusNotFound, "前端页面不存在，请先构建前端")
+		return
+	}
+
+	locale := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+	c.Header("Vary", "Accept-Language")
+	c.Header("Content-Language", locale)
+
+	site, err := seoSite(c, locale)
+	if err != nil {
+		// 内容读取失败时仍然返回页面，只是没有元数据
+		log.Printf("读取页面元数据失败: %v", err)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+		return
+	}
+
+	path := strings.TrimSuffix(c.Request.URL.Path, "/")
+	meta := seo.HomePage(site.Portfolio)
+	if path != "" {
+		meta.Path = path
+		meta.NoIndex = true
+		if label, ok := seoPrivateRoutes[path]; ok {
+			meta.Title = label + " - " + meta.Title
+		}
+	}
+
+	head := seo.Head(site, meta, seoConfig.NoIndex)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(seo.Inject(page, head, locale)))
+}
+
+// Sitemap 生成sitemap.xml
+func Sitemap(c *gin.Context) {
+	if seoConfig.NoIndex {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	site, err := seoSite(c, i18n.Settings().Base)
+	if err != nil {
+		log.Printf("生成站点地图失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	content, err := seo.Sitemap(site, []seo.Page{seo.HomePage(site.Portfolio)}, site.Portfolio.Profile.LastUpdated)
+	if err != nil {
+		log.Printf("生成站点地图失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", content)
+}
+
+// Robots 生成robots.txt
+func Robots(c *gin.Context) {
+	content, err := seo.Robots(seoConfig, siteBaseURL(c))
+	if err != nil {
+		log.Printf("读取robots.txt失败: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.String(http.StatusOK, content)
+}
@@ -3,13 +3,21 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"backend/database"
+	"backend/events"
+	"backend/i18n"
+	"backend/markdown"
 	"backend/models"
+	"backend/portfolio"
+	"backend/taxonomy"
 )
 
 // GetSkills 获取所有技能分类及技能
@@ -27,6 +35,10 @@ func GetSkills(c *gin.Context) {
 	}
 	defer rows.Close()
 
+	scope := currentVariant(c)
+	categoryTranslations := contentTranslations(c, i18n.SkillCategory)
+	skillTranslations := contentTranslations(c, i18n.Skill)
+	evidenceTranslations := evidenceTranslationsFor(c)
 	categories := []models.SkillCategory{}
 	for rows.Next() {
 		var category models.SkillCategory
@@ -37,6 +49,7 @@ func GetSkills(c *gin.Context) {
 			})
 			return
 		}
+		i18n.TranslateSkillCategory(&category, categoryTranslations[category.ID])
 
 		// 获取该分类下的所有技能
 		skillRows, err := database.DB.Query(`
@@ -78,10 +91,22 @@ func GetSkills(c *gin.Context) {
 			}
 			skill.Tags = tags
 			skill.CategoryID = category.ID
+			if !scope.Allows(variantSkill, skill.ID) {
+				continue
+			}
+			attachSkillEvidence(&skill, scope, evidenceTranslations)
+			i18n.TranslateSkill(&skill, skillTranslations[skill.ID])
+			markdown.RenderSkill(&skill)
 			category.Skills = append(category.Skills, skill)
 		}
 
-		categories = append(categories, category)
+		// 简历版本中没有技能的分类不展示
+		if !scope.Selects(variantSkill) || len(category.Skills) > 0 {
+			sort.SliceStable(category.Skills, func(i, j int) bool {
+				return scope.Less(variantSkill, category.Skills[i].ID, category.Skills[j].ID)
+			})
+			categories = append(categories, category)
+		}
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
@@ -102,6 +127,9 @@ func GetSkill(c *gin.Context) {
 		})
 		return
 	}
+	if rejectOutsideVariant(c, variantSkill, skillID, "未找到指定技能") {
+		return
+	}
 
 	var skill models.Skill
 	var tagsJSON string
@@ -138,6 +166,10 @@ func GetSkill(c *gin.Context) {
 		}
 	}
 	skill.Tags = tags
+	attachSkillEvidence(&skill, currentVariant(c), evidenceTranslationsFor(c))
+
+	i18n.TranslateSkill(&skill, contentTranslations(c, i18n.Skill)[skill.ID])
+	markdown.RenderSkill(&skill)
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -146,6 +178,130 @@ func GetSkill(c *gin.Context) {
 	})
 }
 
+// 技能依据中工作经历和项目标题的翻译
+type evidenceTranslations struct {
+	experiences, projects map[int]map[string]string
+}
+
+func evidenceTranslationsFor(c *gin.Context) evidenceTranslations {
+	return evidenceTranslations{
+		experiences: contentTranslations(c, i18n.Experience),
+		projects:    contentTranslations(c, i18n.Project),
+	}
+}
+
+// 附加由工作经历和项目推算的技能依据，推算失败时只记录日志。
+// 访客绑定了简历版本时只保留版本内的工作经历和项目，年限和建议熟练度也只根据这些内容计算
+func attachSkillEvidence(skill *models.Skill, scope *portfolio.Variant, tr evidenceTranslations) {
+	evidence, err := taxonomy.SkillEvidence(skill.ID, skill.Name)
+	if err != nil {
+		log.Printf("推算技能%d的使用依据失败: %v", skill.ID, err)
+		return
+	}
+	if scope != nil {
+		experiences := []models.TechnologyExperienceRef{}
+		for _, ref := range evidence.Experiences {
+			if scope.Allows(variantExperience, ref.ID) {
+				experiences = append(experiences, ref)
+			}
+		}
+		projects := []models.TechnologyProjectRef{}
+		for _, ref := range evidence.Projects {
+			if scope.Allows(variantProject, ref.ID) {
+				projects = append(projects, ref)
+			}
+		}
+		evidence.Experiences, evidence.Projects = experiences, projects
+		taxonomy.Summarize(&evidence, time.Now())
+	}
+
+	// 计算完成后再翻译，时间段需要按原文解析
+	for i := range evidence.Experiences {
+		ref := &evidence.Experiences[i]
+		values := tr.experiences[ref.ID]
+		i18n.TranslateString(&ref.Title, values, "title")
+		i18n.TranslateString(&ref.Company, values, "company")
+		i18n.TranslateString(&ref.Period, values, "period")
+	}
+	for i := range evidence.Projects {
+		ref := &evidence.Projects[i]
+		values := tr.projects[ref.ID]
+		i18n.TranslateString(&ref.Title, values, "title")
+		i18n.TranslateString(&ref.Category, values, "category")
+	}
+	skill.Evidence = &evidence
+}
+
+// AcceptSuggestedSkillLevel 管理员采纳根据依据推算的建议熟练度
+func AcceptSuggestedSkillLevel(c *gin.Context) {
+	skillID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的技能ID",
+		})
+		return
+	}
+
+	var skill models.Skill
+	var tagsJSON string
+	err = database.DB.QueryRow(`
+		SELECT id, category_id, name, level, description, tags
+		FROM skills
+		WHERE id = ?`, skillID).Scan(
+		&skill.ID, &skill.CategoryID, &skill.Name, &skill.Level, &skill.Description, &tagsJSON)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "未找到指定技能",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "获取技能数据失败: " + err.Error(),
+			})
+		}
+		return
+	}
+	json.Unmarshal([]byte(tagsJSON), &skill.Tags)
+
+	evidence, err := taxonomy.SkillEvidence(skill.ID, skill.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "推算技能依据失败: " + err.Error(),
+		})
+		return
+	}
+	if evidence.SuggestedLevel == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "没有找到使用该技能的工作经历或项目，无法给出建议熟练度",
+		})
+		return
+	}
+
+	if _, err := database.DB.Exec("UPDATE skills SET level = ? WHERE id = ?", *evidence.SuggestedLevel, skill.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新技能熟练度失败: " + err.Error(),
+		})
+		return
+	}
+	skill.Level = *evidence.SuggestedLevel
+	skill.Evidence = &evidence
+
+	markdown.RenderSkill(&skill)
+	events.Publish(events.SkillUpdated, skill)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "已采纳建议熟练度",
+		Data:    skill,
+	})
+}
+
 // CreateSkill 创建技能
 func CreateSkill(c *gin.Context) {
 	var skill models.Skill
@@ -157,6 +313,17 @@ func CreateSkill(c *gin.Context) {
 		return
 	}
 
+	// 将技术名称规范化，别名统一替换为规范名称
+	var err error
+	skill.Tags, err = taxonomy.Normalize(skill.Tags)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "规范化技术名称失败: " + err.Error(),
+		})
+		return
+	}
+
 	// 将标签转换为JSON
 	tagsJSON, err := json.Marshal(skill.Tags)
 	if err != nil {
@@ -191,6 +358,13 @@ func CreateSkill(c *gin.Context) {
 	}
 	skill.ID = int(id)
 
+	if err := taxonomy.Link(taxonomy.EntitySkill, skill.ID, skill.Tags); err != nil {
+		log.Printf("更新技能%d的技术关联失败: %v", skill.ID, err)
+	}
+
+	markdown.RenderSkill(&skill)
+	events.Publish(events.SkillCreated, skill)
+
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "技能创建成功",
@@ -222,6 +396,16 @@ func UpdateSkill(c *gin.Context) {
 	// 确保路径ID与请求体ID一致
 	skill.ID = skillID
 
+	// 将技术名称规范化，别名统一替换为规范名称
+	skill.Tags, err = taxonomy.Normalize(skill.Tags)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "规范化技术名称失败: " + err.Error(),
+		})
+		return
+	}
+
 	// 将标签转换为JSON
 	tagsJSON, err := json.Marshal(skill.Tags)
 	if err != nil {
@@ -263,6 +447,13 @@ func UpdateSkill(c *gin.Context) {
 		return
 	}
 
+	if err := taxonomy.Link(taxonomy.EntitySkill, skill.ID, skill.Tags); err != nil {
+		log.Printf("更新技能%d的技术关联失败: %v", skill.ID, err)
+	}
+
+	markdown.RenderSkill(&skill)
+	events.Publish(events.SkillUpdated, skill)
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "技能更新成功",
@@ -308,6 +499,20 @@ func DeleteSkill(c *gin.Context) {
 		return
 	}
 
+	if err := taxonomy.Unlink(taxonomy.EntitySkill, skillID); err != nil {
+		log.Printf("删除技能%d的技术关联失败: %v", skillID, err)
+	}
+
+	if err := removeVariantItem(variantSkill, skillID); err != nil {
+		log.Printf("从简历版本中移除技能%d失败: %v", skillID, err)
+	}
+
+	if err := i18n.Remove(i18n.Skill, skillID); err != nil {
+		log.Printf("删除技能%d的翻译失败: %v", skillID, err)
+	}
+
+	events.Publish(events.SkillDeleted, gin.H{"id": skillID})
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "技能删除成功",
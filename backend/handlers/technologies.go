@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/models"
+	"backend/search"
+	"backend/taxonomy"
+)
+
+// GetTechnologies 获取技术列表，可按category筛选
+func GetTechnologies(c *gin.Context) {
+	technologies, err := taxonomy.List(c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取技术列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取技术列表成功",
+		Data:    technologies,
+	})
+}
+
+// GetTechnology 根据slug获取技术在技能、工作经历和项目中的使用情况，也支持按名称或别名查询
+func GetTechnology(c *gin.Context) {
+	usage, err := taxonomy.Usage(c.Param("slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "未找到指定技术",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取技术数据失败: " + err.Error(),
+		})
+		return
+	}
+	filterUsage(currentVariant(c), &usage)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取技术数据成功",
+		Data:    usage,
+	})
+}
+
+// CreateTechnology 管理员新建技术
+func CreateTechnology(c *gin.Context) {
+	var tech models.Technology
+	if err := c.ShouldBindJSON(&tech); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的技术数据: " + err.Error(),
+		})
+		return
+	}
+
+	id, err := taxonomy.Create(tech.Name, tech.Category, tech.Description, tech.Aliases)
+	if err != nil {
+		if err == taxonomy.ErrAliasConflict {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Message: "技术名称或别名已存在，重复的技术请使用合并功能",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建技术失败: " + err.Error(),
+		})
+		return
+	}
+
+	created, err := taxonomy.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取技术数据失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "创建技术成功",
+		Data:    created,
+	})
+}
+
+// UpdateTechnology 管理员修改技术名称、分类、说明和别名，改名后会同步更新所有引用
+func UpdateTechnology(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的技术ID",
+		})
+		return
+	}
+
+	var tech models.Technology
+	if err := c.ShouldBindJSON(&tech); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的技术数据: " + err.Error(),
+		})
+		return
+	}
+
+	if err := taxonomy.Update(id, tech.Name, tech.Category, tech.Description, tech.Aliases); err != nil {
+		respondTaxonomyError(c, err, "更新技术失败")
+		return
+	}
+	refreshSearchIndex()
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "更新技术成功",
+	})
+}
+
+// MergeTechnology 管理员将另一项技术合并到当前技术，用于合并自动创建的重复项
+func MergeTechnology(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的技术ID",
+		})
+		return
+	}
+
+	var req struct {
+		SourceID int `json:"source_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	if req.SourceID == id {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "不能将技术合并到自身",
+		})
+		return
+	}
+
+	if err := taxonomy.Merge(id, req.SourceID); err != nil {
+		respondTaxonomyError(c, err, "合并技术失败")
+		return
+	}
+	refreshSearchIndex()
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "合并技术成功",
+	})
+}
+
+// DeleteTechnology 管理员删除技术，同时从技能、工作经历和项目中移除
+func DeleteTechnology(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的技术ID",
+		})
+		return
+	}
+
+	if err := taxonomy.Delete(id); err != nil {
+		respondTaxonomyError(c, err, "删除技术失败")
+		return
+	}
+	refreshSearchIndex()
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "删除技术成功",
+	})
+}
+
+// 输出技术管理操作的错误
+func respondTaxonomyError(c *gin.Context, err error, message string) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定技术",
+		})
+	case taxonomy.ErrAliasConflict:
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "技术名称或别名已被其他技术使用",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message + ": " + err.Error(),
+		})
+	}
+}
+
+// 技术名称批量变化后重建搜索索引
+func refreshSearchIndex() {
+	if err := search.Rebuild(); err != nil {
+		log.Printf("重建搜索索引失败: %v", err)
+	}
+}
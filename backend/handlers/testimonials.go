@@ -0,0 +1,694 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 邀请链接默认有效期
+const defaultInviteTTL = 14 * 24 * time.Hour
+
+// 生成随机令牌(十六进制字符串)
+func generateRandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// 查询推荐信时使用的字段
+const testimonialColumns = `id, invite_id, author_name, author_role, relationship, content,
+	status, experience_id, project_id, created_at, reviewed_at`
+
+// 扫描推荐信行数据
+func scanTestimonial(scanner interface{ Scan(...interface{}) error }) (models.Testimonial, error) {
+	var t models.Testimonial
+	var authorRole, relationship sql.NullString
+	err := scanner.Scan(&t.ID, &t.InviteID, &t.AuthorName, &authorRole, &relationship, &t.Content,
+		&t.Status, &t.ExperienceID, &t.ProjectID, &t.CreatedAt, &t.ReviewedAt)
+	t.AuthorRole = authorRole.String
+	t.Relationship = relationship.String
+	return t, err
+}
+
+// 查询推荐信列表
+func queryTestimonials(query string, args ...interface{}) ([]models.Testimonial, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	testimonials := []models.Testimonial{}
+	for rows.Next() {
+		t, err := scanTestimonial(rows)
+		if err != nil {
+			return nil, err
+		}
+		testimonials = append(testimonials, t)
+	}
+	return testimonials, rows.Err()
+}
+
+// 检查关联的工作经历或项目是否存在
+func checkTestimonialLinks(experienceID, projectID *int) string {
+	var exists int
+	if experienceID != nil {
+		err := database.DB.QueryRow("SELECT 1 FROM experiences WHERE id = ?", *experienceID).Scan(&exists)
+		if err != nil {
+			return "关联的工作经历不存在"
+		}
+	}
+	if projectID != nil {
+		err := database.DB.QueryRow("SELECT 1 FROM projects WHERE id = ?", *projectID).Scan(&exists)
+		if err != nil {
+			return "关联的项目不存在"
+		}
+	}
+	return ""
+}
+
+// 删除工作经历或项目后清除推荐信和邀请中指向它的关联(数据库未开启外键约束)，
+// 已通过的推荐信因此失去全部关联时退回待审核，保证通过的推荐信始终有关联对象
+func unlinkTestimonials(column string, id int) error {
+	for _, table := range []string{"testimonials", "testimonial_invites"} {
+		if _, err := database.DB.Exec("UPDATE "+table+" SET "+column+" = NULL WHERE "+column+" = ?", id); err != nil {
+			return err
+		}
+	}
+	_, err := database.DB.Exec(`
+		UPDATE testimonials SET status = 'pending', reviewed_at = NULL
+		WHERE status = 'approved' AND experience_id IS NULL AND project_id IS NULL`)
+	return err
+}
+
+// 根据令牌查找仍然有效的邀请
+func findValidInvite(token string) (models.TestimonialInvite, string, int) {
+	var invite models.TestimonialInvite
+	var recipient, note sql.NullString
+	err := database.DB.QueryRow(`
+		SELECT id, token, recipient, note, experience_id, project_id, expires_at, used_at, created_at
+		FROM testimonial_invites WHERE token = ?`, token).Scan(
+		&invite.ID, &invite.Token, &recipient, &note, &invite.ExperienceID, &invite.ProjectID,
+		&invite.ExpiresAt, &invite.UsedAt, &invite.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return invite, "邀请链接无效", http.StatusNotFound
+		}
+		log.Printf("查询推荐信邀请失败: %v", err)
+		return invite, "查询邀请链接失败", http.StatusInternalServerError
+	}
+	invite.Recipient = recipient.String
+	invite.Note = note.String
+
+	if invite.UsedAt != nil {
+		return invite, "邀请链接已被使用", http.StatusGone
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		return invite, "邀请链接已过期", http.StatusGone
+	}
+	return invite, "", http.StatusOK
+}
+
+// GetTestimonialInvite 推荐人打开邀请链接时获取邀请信息
+func GetTestimonialInvite(c *gin.Context) {
+	invite, msg, status := findValidInvite(c.Param("token"))
+	if msg != "" {
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	// 仅返回推荐人需要看到的上下文信息
+	data := gin.H{
+		"recipient":  invite.Recipient,
+		"note":       invite.Note,
+		"expires_at": invite.ExpiresAt,
+	}
+	if invite.ExperienceID != nil {
+		var title, company string
+		if err := database.DB.QueryRow("SELECT title, company FROM experiences WHERE id = ?",
+			*invite.ExperienceID).Scan(&title, &company); err == nil {
+			data["experience"] = gin.H{"id": *invite.ExperienceID, "title": title, "company": company}
+		}
+	}
+	if invite.ProjectID != nil {
+		var title string
+		if err := database.DB.QueryRow("SELECT title FROM projects WHERE id = ?",
+			*invite.ProjectID).Scan(&title); err == nil {
+			data["project"] = gin.H{"id": *invite.ProjectID, "title": title}
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "邀请链接有效",
+		Data:    data,
+	})
+}
+
+// SubmitTestimonial 推荐人通过邀请链接提交推荐信
+func SubmitTestimonial(c *gin.Context) {
+	var req models.TestimonialSubmission
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	invite, msg, status := findValidInvite(c.Param("token"))
+	if msg != "" {
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "提交推荐信失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// 先占用邀请，防止同一链接被并发重复提交
+	result, err := tx.Exec("UPDATE testimonial_invites SET used_at = ? WHERE id = ? AND used_at IS NULL",
+		time.Now(), invite.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "提交推荐信失败: " + err.Error(),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusGone, models.APIResponse{
+			Success: false,
+			Message: "邀请链接已被使用",
+		})
+		return
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO testimonials
+		(invite_id, author_name, author_role, relationship, content, status, experience_id, project_id, created_at)
+		VALUES (?, ?, ?, ?, ?, 'pending', ?, ?, ?)`,
+		invite.ID, req.AuthorName, req.AuthorRole, req.Relationship, req.Content,
+		invite.ExperienceID, invite.ProjectID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "提交推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "提交推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	log.Printf("收到新的推荐信，ID: %d，邀请ID: %d", id, invite.ID)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "推荐信提交成功，等待审核",
+	})
+}
+
+// GetApprovedTestimonials 访客获取已审核通过的推荐信
+func GetApprovedTestimonials(c *gin.Context) {
+	query := "SELECT " + testimonialColumns + " FROM testimonials WHERE status = 'approved'"
+	var args []interface{}
+
+	if expID := c.Query("experience_id"); expID != "" {
+		query += " AND experience_id = ?"
+		args = append(args, expID)
+	}
+	if projectID := c.Query("project_id"); projectID != "" {
+		query += " AND project_id = ?"
+		args = append(args, projectID)
+	}
+	query += " ORDER BY reviewed_at DESC, id DESC"
+
+	testimonials, err := queryTestimonials(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 只返回关联的工作经历和项目都在当前简历版本中的推荐信，访客不需要看到邀请ID
+	scope := currentVariant(c)
+	visible := []models.Testimonial{}
+	for _, t := range testimonials {
+		if t.ExperienceID != nil && !scope.Allows(variantExperience, *t.ExperienceID) {
+			continue
+		}
+		if t.ProjectID != nil && !scope.Allows(variantProject, *t.ProjectID) {
+			continue
+		}
+		t.InviteID = nil
+		visible = append(visible, t)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取推荐信成功",
+		Data:    visible,
+	})
+}
+
+// CreateTestimonialInvite 管理员生成推荐信邀请链接
+func CreateTestimonialInvite(c *gin.Context) {
+	var req struct {
+		Recipient     string `json:"recipient"`
+		Note          string `json:"note"`
+		ExperienceID  *int   `json:"experience_id"`
+		ProjectID     *int   `json:"project_id"`
+		ExpiresInDays int    `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	if msg := checkTestimonialLinks(req.ExperienceID, req.ProjectID); msg != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	token, err := generateRandomToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成邀请令牌失败",
+		})
+		return
+	}
+
+	ttl := defaultInviteTTL
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	invite := models.TestimonialInvite{
+		Token:        token,
+		Recipient:    req.Recipient,
+		Note:         req.Note,
+		ExperienceID: req.ExperienceID,
+		ProjectID:    req.ProjectID,
+		ExpiresAt:    time.Now().Add(ttl),
+		CreatedAt:    time.Now(),
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO testimonial_invites (token, recipient, note, experience_id, project_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		invite.Token, invite.Recipient, invite.Note, invite.ExperienceID, invite.ProjectID,
+		invite.ExpiresAt, invite.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建邀请链接失败: " + err.Error(),
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	invite.ID = int(id)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "邀请链接创建成功",
+		Data:    invite,
+	})
+}
+
+// GetTestimonialInvites 管理员获取邀请链接列表
+func GetTestimonialInvites(c *gin.Context) {
+	rows, err := database.DB.Query(`
+		SELECT id, token, recipient, note, experience_id, project_id, expires_at, used_at, created_at
+		FROM testimonial_invites ORDER BY created_at DESC`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取邀请链接失败: " + err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	invites := []models.TestimonialInvite{}
+	for rows.Next() {
+		var invite models.TestimonialInvite
+		var recipient, note sql.NullString
+		if err := rows.Scan(&invite.ID, &invite.Token, &recipient, &note, &invite.ExperienceID,
+			&invite.ProjectID, &invite.ExpiresAt, &invite.UsedAt, &invite.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "解析邀请链接数据失败: " + err.Error(),
+			})
+			return
+		}
+		invite.Recipient = recipient.String
+		invite.Note = note.String
+		invites = append(invites, invite)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取邀请链接成功",
+		Data:    invites,
+	})
+}
+
+// DeleteTestimonialInvite 管理员撤销邀请链接
+func DeleteTestimonialInvite(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的邀请ID",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM testimonial_invites WHERE id = ?", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除邀请链接失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定的邀请链接",
+		})
+		return
+	}
+
+	// 通过该邀请提交的推荐信保留，只清除对邀请的引用
+	if _, err := database.DB.Exec("UPDATE testimonials SET invite_id = NULL WHERE invite_id = ?", id); err != nil {
+		log.Printf("清除推荐信对邀请%d的引用失败: %v", id, err)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "邀请链接删除成功",
+	})
+}
+
+// GetTestimonials 管理员获取推荐信列表，可按状态筛选
+func GetTestimonials(c *gin.Context) {
+	query := "SELECT " + testimonialColumns + " FROM testimonials"
+	var args []interface{}
+
+	if status := c.Query("status"); status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC"
+
+	testimonials, err := queryTestimonials(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取推荐信成功",
+		Data:    testimonials,
+	})
+}
+
+// UpdateTestimonial 管理员编辑推荐信内容及关联
+func UpdateTestimonial(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的推荐信ID",
+		})
+		return
+	}
+
+	var t models.Testimonial
+	if err := c.ShouldBindJSON(&t); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	// 与推荐人提交时的必填项一致
+	t.AuthorName = strings.TrimSpace(t.AuthorName)
+	t.Relationship = strings.TrimSpace(t.Relationship)
+	t.Content = strings.TrimSpace(t.Content)
+	if t.AuthorName == "" || t.Relationship == "" || t.Content == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "推荐人姓名、关系和推荐内容不能为空",
+		})
+		return
+	}
+
+	var status string
+	err = database.DB.QueryRow("SELECT status FROM testimonials WHERE id = ?", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要更新的推荐信",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	if msg := checkTestimonialLinks(t.ExperienceID, t.ProjectID); msg != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	// 与审核时的规则一致：通过的推荐信必须关联工作经历或项目
+	if status == "approved" && t.ExperienceID == nil && t.ProjectID == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "通过的推荐信必须关联工作经历或项目",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE testimonials SET
+		author_name = ?, author_role = ?, relationship = ?, content = ?, experience_id = ?, project_id = ?
+		WHERE id = ?`,
+		t.AuthorName, t.AuthorRole, t.Relationship, t.Content, t.ExperienceID, t.ProjectID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要更新的推荐信",
+		})
+		return
+	}
+
+	updated, err := scanTestimonial(database.DB.QueryRow(
+		"SELECT "+testimonialColumns+" FROM testimonials WHERE id = ?", id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "推荐信更新成功",
+		Data:    updated,
+	})
+}
+
+// ReviewTestimonial 管理员审核推荐信(通过或拒绝)
+func ReviewTestimonial(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的推荐信ID",
+		})
+		return
+	}
+
+	var req struct {
+		Status       string `json:"status" binding:"required"`
+		ExperienceID *int   `json:"experience_id"`
+		ProjectID    *int   `json:"project_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Status != "approved" && req.Status != "rejected" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "审核状态只能是approved或rejected",
+		})
+		return
+	}
+
+	current, err := scanTestimonial(database.DB.QueryRow(
+		"SELECT "+testimonialColumns+" FROM testimonials WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "未找到指定的推荐信",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 审核时可以重新指定关联对象，未指定则保留原有关联
+	if req.ExperienceID != nil || req.ProjectID != nil {
+		current.ExperienceID = req.ExperienceID
+		current.ProjectID = req.ProjectID
+	}
+	if msg := checkTestimonialLinks(current.ExperienceID, current.ProjectID); msg != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: msg,
+		})
+		return
+	}
+
+	// 通过的推荐信必须关联到工作经历或项目
+	if req.Status == "approved" && current.ExperienceID == nil && current.ProjectID == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "通过的推荐信必须关联工作经历或项目",
+		})
+		return
+	}
+
+	now := time.Now()
+	_, err = database.DB.Exec(`
+		UPDATE testimonials SET status = ?, experience_id = ?, project_id = ?, reviewed_at = ?
+		WHERE id = ?`,
+		req.Status, current.ExperienceID, current.ProjectID, now, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "审核推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	current.Status = req.Status
+	current.ReviewedAt = &now
+
+	log.Printf("推荐信 %d 审核结果: %s", id, req.Status)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "推荐信审核成功",
+		Data:    current,
+	})
+}
+
+// DeleteTestimonial 管理员删除推荐信
+func DeleteTestimonial(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的推荐信ID",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM testimonials WHERE id = ?", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除推荐信失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要删除的推荐信",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "推荐信删除成功",
+	})
+}
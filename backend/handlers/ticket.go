@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/models"
+)
+
+// 票据有效期。EventSource、图片和下载链接无法设置请求头，先用请求头中的令牌换取一次性票据，
+// 再通过?ticket=参数传递，长期有效的令牌不会出现在地址、浏览器历史和访问日志中
+const ticketTTL = time.Minute
+
+// 票据用途
+const (
+	ticketAdminEvents = "admin-events" // 管理员事件流
+	ticketVisitor     = "visitor"      // 访客的名片下载和二维码图片
+)
+
+type ticket struct {
+	purpose string
+	claims  interface{}
+	expires time.Time
+}
+
+var tickets = struct {
+	sync.Mutex
+	m map[string]ticket
+}{m: map[string]ticket{}}
+
+// 生成票据，同时清理过期的票据
+func issueTicket(purpose string, claims interface{}) (string, error) {
+	value, err := generateRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	tickets.Lock()
+	defer tickets.Unlock()
+	now := time.Now()
+	for k, t := range tickets.m {
+		if now.After(t.expires) {
+			delete(tickets.m, k)
+		}
+	}
+	tickets.m[value] = ticket{purpose: purpose, claims: claims, expires: now.Add(ticketTTL)}
+	return value, nil
+}
+
+// 兑换票据，每个票据只能使用一次，且只能用于签发时指定的用途
+func redeemTicket(purpose, value string) (interface{}, bool) {
+	tickets.Lock()
+	defer tickets.Unlock()
+	t, ok := tickets.m[value]
+	if !ok {
+		return nil, false
+	}
+	delete(tickets.m, value)
+	if t.purpose != purpose || time.Now().After(t.expires) {
+		return nil, false
+	}
+	return t.claims, true
+}
+
+func respondTicket(c *gin.Context, purpose string, claims interface{}) {
+	value, err := issueTicket(purpose, claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成票据失败",
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "票据已生成",
+		Data: gin.H{
+			"ticket":     value,
+			"expires_in": int(ticketTTL.Seconds()),
+		},
+	})
+}
+
+// CreateEventTicket 管理员换取订阅事件流的一次性票据
+func CreateEventTicket(c *gin.Context) {
+	respondTicket(c, ticketAdminEvents, &Claims{
+		UserID:   c.GetInt("userID"),
+		Username: c.GetString("username"),
+		Role:     c.GetString("role"),
+	})
+}
+
+// CreateVisitorTicket 访客换取下载名片或加载二维码图片的一次性票据，票据携带与令牌相同的访客信息
+func CreateVisitorTicket(c *gin.Context) {
+	claims, _ := c.Get("visitorClaims")
+	respondTicket(c, ticketVisitor, claims)
+}
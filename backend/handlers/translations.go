@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/i18n"
+	"backend/models"
+)
+
+// 获取请求使用的语言：访客请求根据?lang=参数和Accept-Language选择，
+// 管理员请求只在显式传入?lang=时翻译，避免把翻译内容当作原文编辑
+func requestLocale(c *gin.Context) string {
+	lang := c.Query("lang")
+	acceptLanguage := ""
+	if _, isVisitor := c.Get("visitorAccessKey"); isVisitor {
+		acceptLanguage = c.GetHeader("Accept-Language")
+		c.Header("Vary", "Accept-Language")
+	} else if lang == "" {
+		return i18n.Settings().Base
+	}
+
+	locale := i18n.Negotiate(lang, acceptLanguage)
+	c.Header("Content-Language", locale)
+	return locale
+}
+
+// 获取某类内容在当前请求语言下的翻译，原文语言或读取失败时返回nil
+func contentTranslations(c *gin.Context, entityType string) map[int]map[string]string {
+	locale := requestLocale(c)
+	if locale == i18n.Settings().Base {
+		return nil
+	}
+	translations, err := i18n.Translations(entityType, locale)
+	if err != nil {
+		log.Printf("读取%s翻译失败: %v", entityType, err)
+		return nil
+	}
+	return translations
+}
+
+// GetLocales 获取支持的语言，供前台切换语言
+func GetLocales(c *gin.Context) {
+	settings := i18n.Settings()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取语言设置成功",
+		Data: models.LocaleSettings{
+			Base:      settings.Base,
+			Fallback:  settings.Fallback,
+			Supported: settings.Supported,
+		},
+	})
+}
+
+// GetTranslationReport 管理员查看缺少的翻译，未指定locale时返回所有非原文语言的报告
+func GetTranslationReport(c *gin.Context) {
+	settings := i18n.Settings()
+	locales := []string{}
+	if locale := c.Query("locale"); locale != "" {
+		if !checkTranslationLocale(c, locale) {
+			return
+		}
+		locales = append(locales, locale)
+	} else {
+		for _, locale := range settings.Supported {
+			if locale != settings.Base {
+				locales = append(locales, locale)
+			}
+		}
+	}
+
+	reports := []models.TranslationReport{}
+	for _, locale := range locales {
+		report, err := i18n.Missing(locale)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "统计缺少的翻译失败: " + err.Error(),
+			})
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取翻译报告成功",
+		Data:    reports,
+	})
+}
+
+// GetEntityTranslations 管理员获取单项内容在某种语言下的原文和翻译
+func GetEntityTranslations(c *gin.Context) {
+	entityType, id, locale, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := loadEntityTranslations(entityType, id, locale)
+	if err != nil {
+		respondTranslationError(c, err, "获取翻译失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取翻译成功",
+		Data:    result,
+	})
+}
+
+// UpdateEntityTranslations 管理员保存单项内容在某种语言下的翻译，只修改请求中出现的字段，
+// 字段值为空字符串或空数组时删除该字段的翻译
+func UpdateEntityTranslations(c *gin.Context) {
+	entityType, id, locale, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	var req map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的翻译数据: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := i18n.Source(entityType, id); err != nil {
+		respondTranslationError(c, err, "获取原文失败")
+		return
+	}
+
+	fields, _ := i18n.Fields(entityType)
+	values := map[string]string{}
+	for _, field := range fields {
+		raw, exists := req[field.Name]
+		if !exists {
+			continue
+		}
+		delete(req, field.Name)
+
+		if field.List {
+			var list []string
+			if err := json.Unmarshal(raw, &list); err != nil {
+				c.JSON(http.StatusBadRequest, models.APIResponse{
+					Success: false,
+					Message: "字段" + field.Name + "应为字符串数组",
+				})
+				return
+			}
+			encoded, _ := json.Marshal(list)
+			values[field.Name] = string(encoded)
+		} else {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				c.JSON(http.StatusBadRequest, models.APIResponse{
+					Success: false,
+					Message: "字段" + field.Name + "应为字符串",
+				})
+				return
+			}
+			values[field.Name] = strings.TrimSpace(value)
+		}
+	}
+	for field := range req {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "字段" + field + "不支持翻译",
+		})
+		return
+	}
+
+	if err := i18n.Set(entityType, id, locale, values); err != nil {
+		respondTranslationError(c, err, "保存翻译失败")
+		return
+	}
+
+	result, err := loadEntityTranslations(entityType, id, locale)
+	if err != nil {
+		respondTranslationError(c, err, "获取翻译失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "保存翻译成功",
+		Data:    result,
+	})
+}
+
+// 读取单项内容各字段的原文和翻译
+func loadEntityTranslations(entityType string, id int, locale string) (models.EntityTranslations, error) {
+	result := models.EntityTranslations{EntityType: entityType, EntityID: id, Locale: locale}
+
+	source, err := i18n.Source(entityType, id)
+	if err != nil {
+		return result, err
+	}
+	values, updatedAt, err := i18n.Get(entityType, id, locale)
+	if err != nil {
+		return result, err
+	}
+	if !updatedAt.IsZero() {
+		result.UpdatedAt = &updatedAt
+	}
+
+	fields, _ := i18n.Fields(entityType)
+	for _, field := range fields {
+		item := models.TranslationField{Field: field.Name, List: field.List}
+		if field.List {
+			list := []string{}
+			json.Unmarshal([]byte(source[field.Name]), &list)
+			item.Source = list
+			if value, ok := values[field.Name]; ok {
+				var translated []string
+				if err := json.Unmarshal([]byte(value), &translated); err == nil {
+					item.Value = translated
+				}
+			}
+		} else {
+			item.Source = source[field.Name]
+			if value, ok := values[field.Name]; ok {
+				item.Value = value
+			}
+		}
+		result.Fields = append(result.Fields, item)
+	}
+	return result, nil
+}
+
+// 解析翻译接口的路径参数，无效时输出错误并返回false
+func translationParams(c *gin.Context) (string, int, string, bool) {
+	entityType := c.Param("type")
+	if _, err := i18n.Fields(entityType); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "不支持翻译的内容类型",
+		})
+		return "", 0, "", false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的内容ID",
+		})
+		return "", 0, "", false
+	}
+
+	locale := c.Param("locale")
+	if !checkTranslationLocale(c, locale) {
+		return "", 0, "", false
+	}
+	return entityType, id, locale, true
+}
+
+// 检查要翻译的语言，原文语言和不支持的语言输出错误并返回false
+func checkTranslationLocale(c *gin.Context, locale string) bool {
+	if !i18n.Supported(locale) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "不支持的语言: " + locale,
+		})
+		return false
+	}
+	if locale == i18n.Settings().Base {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "原文语言不需要翻译，请直接修改内容",
+		})
+		return false
+	}
+	return true
+}
+
+// 输出翻译操作的错误
+func respondTranslationError(c *gin.Context, err error, message string) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要翻译的内容",
+		})
+	case i18n.ErrUnknownEntity, i18n.ErrUnknownField:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message + ": " + err.Error(),
+		})
+	}
+}
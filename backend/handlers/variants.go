@@ -0,0 +1,484 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/i18n"
+	"backend/models"
+	"backend/portfolio"
+)
+
+// 简历版本中的内容类型
+const (
+	variantProject     = portfolio.ItemProject
+	variantExperience  = portfolio.ItemExperience
+	variantSkill       = portfolio.ItemSkill
+	variantCertificate = portfolio.ItemCertificate
+)
+
+// 获取当前请求的简历版本，访客未绑定版本或为管理员请求时返回nil。
+// 版本由访客验证中间件通过loadVariantScope读取
+func currentVariant(c *gin.Context) *portfolio.Variant {
+	scope, _ := c.Value("variantScope").(*portfolio.Variant)
+	return scope
+}
+
+// 读取访客绑定的简历版本并保存到请求上下文。绑定的版本已被删除时展示全部内容(删除版本时会解除绑定)；
+// 其他读取错误时返回500并中止请求，不能把绑定了版本的访客当作不受限制
+func loadVariantScope(c *gin.Context) bool {
+	id := c.GetInt("visitorVariantID")
+	if id <= 0 {
+		return true
+	}
+	scope, err := portfolio.LoadVariant(id)
+	if err == sql.ErrNoRows {
+		return true
+	}
+	if err != nil {
+		log.Printf("读取简历版本失败: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "读取简历版本失败",
+		})
+		return false
+	}
+	c.Set("variantScope", scope)
+	return true
+}
+
+// 过滤技术使用情况中不在简历版本内的技能、工作经历和项目
+func filterUsage(s *portfolio.Variant, usage *models.TechnologyUsage) {
+	if s == nil {
+		return
+	}
+	skills := []models.TechnologySkillRef{}
+	for _, ref := range usage.Skills {
+		if s.Allows(variantSkill, ref.ID) {
+			skills = append(skills, ref)
+		}
+	}
+	experiences := []models.TechnologyExperienceRef{}
+	for _, ref := range usage.Experiences {
+		if s.Allows(variantExperience, ref.ID) {
+			experiences = append(experiences, ref)
+		}
+	}
+	projects := []models.TechnologyProjectRef{}
+	for _, ref := range usage.Projects {
+		if s.Allows(variantProject, ref.ID) {
+			projects = append(projects, ref)
+		}
+	}
+	usage.Skills, usage.Experiences, usage.Projects = skills, experiences, projects
+}
+
+// 访客无权查看的内容返回404，与内容不存在时一致
+func rejectOutsideVariant(c *gin.Context, itemType string, id int, message string) bool {
+	if currentVariant(c).Allows(itemType, id) {
+		return false
+	}
+	c.JSON(http.StatusNotFound, models.APIResponse{
+		Success: false,
+		Message: message,
+	})
+	return true
+}
+
+// GetVariants 获取所有简历版本
+func GetVariants(c *gin.Context) {
+	rows, err := database.DB.Query("SELECT id FROM variants ORDER BY id")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取简历版本列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "读取简历版本数据失败: " + err.Error(),
+			})
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	variants := []models.Variant{}
+	for _, id := range ids {
+		variant, err := loadVariant(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "读取简历版本数据失败: " + err.Error(),
+			})
+			return
+		}
+		variants = append(variants, variant)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取简历版本列表成功",
+		Data:    variants,
+	})
+}
+
+// GetVariant 获取单个简历版本
+func GetVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的简历版本ID",
+		})
+		return
+	}
+
+	variant, err := loadVariant(id)
+	if err != nil {
+		respondVariantError(c, err, "获取简历版本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取简历版本成功",
+		Data:    variant,
+	})
+}
+
+// CreateVariant 创建简历版本
+func CreateVariant(c *gin.Context) {
+	var variant models.Variant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的简历版本数据: " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"INSERT INTO variants (name, description, title, introduction, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		variant.Name, variant.Description, variant.Title, variant.Introduction, now, now,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := saveVariantItems(tx, int(id), variant); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "保存简历版本内容失败: " + err.Error(),
+		})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建简历版本失败: " + err.Error(),
+		})
+		return
+	}
+
+	created, err := loadVariant(int(id))
+	if err != nil {
+		respondVariantError(c, err, "获取简历版本失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "创建简历版本成功",
+		Data:    created,
+	})
+}
+
+// UpdateVariant 更新简历版本，内容列表整体替换
+func UpdateVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的简历版本ID",
+		})
+		return
+	}
+
+	var variant models.Variant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的简历版本数据: " + err.Error(),
+		})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE variants SET name = ?, description = ?, title = ?, introduction = ?, updated_at = ? WHERE id = ?",
+		variant.Name, variant.Description, variant.Title, variant.Introduction, time.Now(), id,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		respondVariantError(c, sql.ErrNoRows, "")
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM variant_items WHERE variant_id = ?", id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新简历版本内容失败: " + err.Error(),
+		})
+		return
+	}
+	if err := saveVariantItems(tx, id, variant); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "保存简历版本内容失败: " + err.Error(),
+		})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新简历版本失败: " + err.Error(),
+		})
+		return
+	}
+
+	updated, err := loadVariant(id)
+	if err != nil {
+		respondVariantError(c, err, "获取简历版本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "更新简历版本成功",
+		Data:    updated,
+	})
+}
+
+// DeleteVariant 删除简历版本，已绑定该版本的访客密码和分享链接恢复为展示全部内容
+func DeleteVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的简历版本ID",
+		})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM variants WHERE id = ?", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		respondVariantError(c, sql.ErrNoRows, "")
+		return
+	}
+
+	// 未开启外键约束，需要手动清理关联数据
+	if _, err := tx.Exec("DELETE FROM variant_items WHERE variant_id = ?", id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除简历版本内容失败: " + err.Error(),
+		})
+		return
+	}
+	if _, err := tx.Exec("UPDATE visitor_access SET variant_id = NULL WHERE variant_id = ?", id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "解除访客绑定失败: " + err.Error(),
+		})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除简历版本失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := i18n.Remove(i18n.Variant, id); err != nil {
+		log.Printf("删除简历版本%d的翻译失败: %v", id, err)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "删除简历版本成功",
+	})
+}
+
+// 读取简历版本及其内容列表
+func loadVariant(id int) (models.Variant, error) {
+	var variant models.Variant
+	var description, title, introduction sql.NullString
+	err := database.DB.QueryRow(
+		"SELECT id, name, description, title, introduction, created_at, updated_at FROM variants WHERE id = ?", id,
+	).Scan(&variant.ID, &variant.Name, &description, &title, &introduction, &variant.CreatedAt, &variant.UpdatedAt)
+	if err != nil {
+		return variant, err
+	}
+	variant.Description = description.String
+	variant.Title = title.String
+	variant.Introduction = introduction.String
+
+	variant.ProjectIDs = []int{}
+	variant.ExperienceIDs = []int{}
+	variant.SkillIDs = []int{}
+	variant.CertificateIDs = []int{}
+
+	rows, err := database.DB.Query(
+		"SELECT item_type, item_id FROM variant_items WHERE variant_id = ? ORDER BY item_type, position", id,
+	)
+	if err != nil {
+		return variant, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemType string
+		var itemID int
+		if err := rows.Scan(&itemType, &itemID); err != nil {
+			return variant, err
+		}
+		switch itemType {
+		case variantProject:
+			variant.ProjectIDs = append(variant.ProjectIDs, itemID)
+		case variantExperience:
+			variant.ExperienceIDs = append(variant.ExperienceIDs, itemID)
+		case variantSkill:
+			variant.SkillIDs = append(variant.SkillIDs, itemID)
+		case variantCertificate:
+			variant.CertificateIDs = append(variant.CertificateIDs, itemID)
+		}
+	}
+	return variant, rows.Err()
+}
+
+// 保存简历版本的内容列表，列表顺序即展示顺序
+func saveVariantItems(tx *sql.Tx, variantID int, variant models.Variant) error {
+	lists := []struct {
+		itemType string
+		ids      []int
+	}{
+		{variantProject, variant.ProjectIDs},
+		{variantExperience, variant.ExperienceIDs},
+		{variantSkill, variant.SkillIDs},
+		{variantCertificate, variant.CertificateIDs},
+	}
+	for _, list := range lists {
+		for position, itemID := range list.ids {
+			_, err := tx.Exec(
+				"INSERT OR IGNORE INTO variant_items (variant_id, item_type, item_id, position) VALUES (?, ?, ?, ?)",
+				variantID, list.itemType, itemID, position,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 内容删除后从简历版本中移除。版本中某类内容只剩这一项时保留记录，
+// 否则该版本会变成"未选择该类内容"而向访客展示全部内容
+func removeVariantItem(itemType string, itemID int) error {
+	_, err := database.DB.Exec(`
+		DELETE FROM variant_items
+		WHERE item_type = ? AND item_id = ? AND variant_id IN (
+			SELECT variant_id FROM variant_items WHERE item_type = ? GROUP BY variant_id HAVING COUNT(*) > 1
+		)`, itemType, itemID, itemType)
+	return err
+}
+
+// 检查简历版本是否存在，variantID为0表示不绑定
+func variantExists(variantID int) (bool, error) {
+	if variantID == 0 {
+		return true, nil
+	}
+	var exists int
+	err := database.DB.QueryRow("SELECT 1 FROM variants WHERE id = ?", variantID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// 输出简历版本操作的错误
+func respondVariantError(c *gin.Context, err error, message string) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定简历版本",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Message: message + ": " + err.Error(),
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+)
+
+func TestVisitorVariantScope(t *testing.T) {
+	t.Setenv("DATA_DIR", t.TempDir())
+	if err := database.SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.DB.Close()
+	if _, err := database.DB.Exec("INSERT INTO variants (id, name) VALUES (1, '运维岗位')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec("INSERT INTO variant_items (variant_id, item_type, item_id) VALUES (1, ?, 2)", variantProject); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/projects", VisitorAuthMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"project1": currentVariant(c).Allows(variantProject, 1)})
+	})
+	get := func(variantID int) *httptest.ResponseRecorder {
+		token, err := generateVisitorToken("visitor_key", variantID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(1); w.Code != http.StatusOK || w.Body.String() != `{"project1":false}` {
+		t.Errorf("绑定版本的访客: %d %s", w.Code, w.Body.String())
+	}
+	// 绑定的版本已被删除时展示全部内容
+	if w := get(99); w.Code != http.StatusOK || w.Body.String() != `{"project1":true}` {
+		t.Errorf("版本已删除的访客: %d %s", w.Code, w.Body.String())
+	}
+
+	// 读取版本出错时不能展示全部内容
+	if _, err := database.DB.Exec("DROP TABLE variant_items"); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(1); w.Code != http.StatusInternalServerError {
+		t.Errorf("读取版本出错时状态码 = %d, 期望 500, 响应: %s", w.Code, w.Body.String())
+	}
+	if w := get(0); w.Code != http.StatusOK {
+		t.Errorf("未绑定版本的访客状态码 = %d, 响应: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/models"
+	"backend/portfolio"
+	"backend/qrcode"
+	"backend/vcard"
+)
+
+// 读取名片使用的个人信息：只有验证过的访客才包含邮箱和电话，并使用访客绑定的简历版本
+func cardProfile(c *gin.Context) (models.Profile, bool) {
+	_, verified := c.Get("visitorAccessKey")
+	opts := portfolio.Options{Locale: requestLocale(c), IncludeContact: verified}
+	if scope := currentVariant(c); scope != nil {
+		opts.VariantID = scope.ID
+	}
+
+	profile, err := portfolio.LoadProfile(opts)
+	if err != nil {
+		log.Printf("读取名片信息失败: %v", err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "读取个人信息失败",
+		})
+		return profile, false
+	}
+	// 名片内容随访客身份变化，不能被共享缓存
+	c.Header("Cache-Control", "private, no-cache")
+	return profile, true
+}
+
+// GetProfileVCard 下载vCard 4.0电子名片
+func GetProfileVCard(c *gin.Context) {
+	profile, ok := cardProfile(c)
+	if !ok {
+		return
+	}
+
+	card := vcard.Build(profile, vcard.Options{SiteURL: siteBaseURL(c) + "/", EmbedPhoto: true})
+	c.Header("Content-Disposition", `attachment; filename="contact.vcf"; filename*=UTF-8''`+url.PathEscape(vcard.FileName(profile)))
+	c.Data(http.StatusOK, "text/vcard; charset=utf-8", []byte(card))
+}
+
+// GetProfileQRCode 生成二维码图片：默认编码站点地址，?link=为分享链接令牌时编码该分享链接，
+// ?content=vcard时编码电子名片。?size=为每个模块的像素数(1-20)
+func GetProfileQRCode(c *gin.Context) {
+	var content string
+	switch c.DefaultQuery("content", "url") {
+	case "url":
+		if token := c.Query("link"); token != "" {
+			// 只编码已存在的分享链接，持有令牌即可访问，不泄露其他信息
+			exists, err := shareLinkExists(token)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Message: "查询分享链接失败: " + err.Error(),
+				})
+				return
+			}
+			if !exists {
+				c.JSON(http.StatusNotFound, models.APIResponse{
+					Success: false,
+					Message: "分享链接不存在",
+				})
+				return
+			}
+			content = shareLinkURL(c, token)
+			break
+		}
+		content = siteBaseURL(c) + "/"
+		if lang := c.Query("lang"); lang != "" {
+			content += "?lang=" + url.QueryEscape(lang)
+		}
+	case "vcard":
+		profile, ok := cardProfile(c)
+		if !ok {
+			return
+		}
+		// 二维码容量有限，头像只引用地址
+		content = vcard.Build(profile, vcard.Options{SiteURL: siteBaseURL(c) + "/"})
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "content只能是url或vcard",
+		})
+		return
+	}
+	writeQRCode(c, content)
+}
+
+// GetShareLinkQRCode 管理员按ID生成分享链接的二维码，?size=同GetProfileQRCode
+func GetShareLinkQRCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的分享链接ID",
+		})
+		return
+	}
+
+	var token string
+	err = database.DB.QueryRow("SELECT value FROM visitor_access WHERE id = ? AND access_type = 'link'", id).Scan(&token)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "分享链接不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "查询分享链接失败: " + err.Error(),
+		})
+		return
+	}
+	writeQRCode(c, shareLinkURL(c, token))
+}
+
+// 输出内容的二维码PNG图片
+func writeQRCode(c *gin.Context, content string) {
+	scale, err := strconv.Atoi(c.DefaultQuery("size", "8"))
+	if err != nil || scale < 1 || scale > 20 {
+		scale = 8
+	}
+
+	code, err := qrcode.Encode([]byte(content))
+	if err == qrcode.ErrTooLong {
+		c.JSON(http.StatusUnprocessableEntity, models.APIResponse{
+			Success: false,
+			Message: "内容过长，无法生成二维码",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成二维码失败: " + err.Error(),
+		})
+		return
+	}
+	image, err := code.PNG(scale)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成二维码失败: " + err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", image)
+}
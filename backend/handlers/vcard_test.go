@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/qrcode"
+)
+
+func TestShareLinkQRCode(t *testing.T) {
+	t.Setenv("DATA_DIR", t.TempDir())
+	if err := database.SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.DB.Close()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/admin/visitor/share-links", CreateShareLink)
+	r.GET("/api/admin/visitor/share-links/:id/qr.png", GetShareLinkQRCode)
+	r.GET("/api/profile/qr.png", GetProfileQRCode)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/admin/visitor/share-links", `{"access_key":"acme"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("生成分享链接状态码 = %d, 响应: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID    int    `json:"id"`
+			Token string `json:"token"`
+			URL   string `json:"url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	want := "http://example.com/verify?link=" + created.Data.Token
+	if created.Data.URL != want {
+		t.Errorf("分享链接地址 = %s, 期望 %s", created.Data.URL, want)
+	}
+
+	code, err := qrcode.Encode([]byte(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPNG, err := code.PNG(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{
+		"/api/profile/qr.png?size=4&link=" + created.Data.Token,
+		"/api/admin/visitor/share-links/" + strconv.Itoa(created.Data.ID) + "/qr.png?size=4",
+	} {
+		w := serve(http.MethodGet, target, "")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Errorf("%s 状态码 = %d, 响应: %s", target, w.Code, w.Body.String())
+			continue
+		}
+		if !bytes.Equal(w.Body.Bytes(), wantPNG) {
+			t.Errorf("%s 的二维码没有编码分享链接", target)
+		}
+	}
+
+	// 不存在的令牌、ID和非分享链接的访客密码都返回404
+	var passwordID int
+	if err := database.DB.QueryRow("SELECT id FROM visitor_access WHERE access_type = 'password' LIMIT 1").Scan(&passwordID); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{
+		"/api/profile/qr.png?link=unknown",
+		"/api/admin/visitor/share-links/9999/qr.png",
+		"/api/admin/visitor/share-links/" + strconv.Itoa(passwordID) + "/qr.png",
+	} {
+		if w := serve(http.MethodGet, target, ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s 状态码 = %d, 期望 404", target, w.Code)
+		}
+	}
+
+	// 不带link时仍编码站点首页
+	if w := serve(http.MethodGet, "/api/profile/qr.png", ""); w.Code != http.StatusOK {
+		t.Errorf("首页二维码状态码 = %d", w.Code)
+	}
+}
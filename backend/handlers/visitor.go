@@ -4,17 +4,22 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 
 	"backend/database"
+	"backend/events"
+	"backend/mailer"
 	"backend/models"
 )
 
-// 生成访客令牌的密钥
-var visitorSecretKey = []byte("visitor_secret_key")
+// 生成访客令牌的密钥，多租户部署时每个租户使用不同的密钥
+var visitorSecretKey = []byte(getEnvOrDefault("VISITOR_SECRET", "visitor_secret_key"))
 
 // VerifyVisitor 验证访客身份
 func VerifyVisitor(c *gin.Context) {
@@ -29,7 +34,8 @@ func VerifyVisitor(c *gin.Context) {
 
 	// 验证类型有效性
 	if req.VerificationType != "name" && req.VerificationType != "email" &&
-		req.VerificationType != "phone" && req.VerificationType != "password" {
+		req.VerificationType != "phone" && req.VerificationType != "password" &&
+		req.VerificationType != "link" {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "无效的验证类型",
@@ -48,19 +54,24 @@ func VerifyVisitor(c *gin.Context) {
 
 	// 根据验证类型进行验证
 	switch req.VerificationType {
-	case "password":
-		// 验证访客密码
+	case "password", "link":
+		// 验证访客密码或分享链接
 		var accessKey string
+		var variantID sql.NullInt64
 		err := database.DB.QueryRow(
-			"SELECT access_key FROM visitor_access WHERE access_type = ? AND value = ?",
+			"SELECT access_key, variant_id FROM visitor_access WHERE access_type = ? AND value = ?",
 			req.VerificationType, req.Value,
-		).Scan(&accessKey)
+		).Scan(&accessKey, &variantID)
 
 		if err != nil {
 			if err == sql.ErrNoRows {
+				message := "密码验证失败"
+				if req.VerificationType == "link" {
+					message = "分享链接无效或已被删除"
+				}
 				c.JSON(http.StatusUnauthorized, models.APIResponse{
 					Success: false,
-					Message: "密码验证失败",
+					Message: message,
 				})
 				return
 			}
@@ -71,8 +82,11 @@ func VerifyVisitor(c *gin.Context) {
 			return
 		}
 
-		// 生成访客令牌
-		token, err := generateVisitorToken(accessKey)
+		// 访客密码首次被使用时通知站长
+		markVisitorFirstUse(c, req.VerificationType, req.Value, accessKey)
+
+		// 生成访客令牌，绑定了简历版本时访客只能看到该版本的内容
+		token, err := generateVisitorToken(accessKey, int(variantID.Int64))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
@@ -81,9 +95,15 @@ func VerifyVisitor(c *gin.Context) {
 			return
 		}
 
+		publishVisitorVerified(c, accessKey, req.VerificationType)
+
+		message := "密码验证成功"
+		if req.VerificationType == "link" {
+			message = "分享链接验证成功"
+		}
 		c.JSON(http.StatusOK, models.APIResponse{
 			Success: true,
-			Message: "密码验证成功",
+			Message: message,
 			Data: models.VerificationResponse{
 				Success: true,
 				Token:   token,
@@ -124,7 +144,7 @@ func VerifyVisitor(c *gin.Context) {
 		}
 
 		// 生成访客令牌
-		token, err := generateVisitorToken(req.VerificationType + "_" + value)
+		token, err := generateVisitorToken(req.VerificationType+"_"+value, 0)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
@@ -133,6 +153,8 @@ func VerifyVisitor(c *gin.Context) {
 			return
 		}
 
+		publishVisitorVerified(c, req.VerificationType+"_"+value, req.VerificationType)
+
 		c.JSON(http.StatusOK, models.APIResponse{
 			Success: true,
 			Message: "验证成功",
@@ -150,15 +172,59 @@ func VerifyVisitor(c *gin.Context) {
 	}
 }
 
-// 生成访客JWT令牌
-func generateVisitorToken(accessKey string) (string, error) {
+// 记录访客密码或分享链接首次使用时间，仅在第一次使用时发送通知
+func markVisitorFirstUse(c *gin.Context, accessType, value, accessKey string) {
+	now := time.Now()
+	result, err := database.DB.Exec(
+		"UPDATE visitor_access SET first_used_at = ? WHERE access_type = ? AND value = ? AND first_used_at IS NULL",
+		now, accessType, value,
+	)
+	if err != nil {
+		log.Printf("记录访客密码首次使用时间失败: %v", err)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		log.Printf("访客密码首次被使用，访客标识: %s", accessKey)
+		mailer.NotifyOwner(mailer.TemplateVisitorFirstUse, map[string]interface{}{
+			"AccessKey": accessKey,
+			"UsedAt":    now,
+			"IP":        c.ClientIP(),
+			"UserAgent": c.Request.UserAgent(),
+		})
+	}
+}
+
+// 发布访客验证成功事件
+func publishVisitorVerified(c *gin.Context, accessKey, verificationType string) {
+	events.Publish(events.VisitorVerified, gin.H{
+		"access_key":        accessKey,
+		"verification_type": verificationType,
+		"ip":                coarseIP(c.ClientIP()),
+		"user_agent":        c.Request.UserAgent(),
+	})
+}
+
+// 生成访客JWT令牌，variantID大于0时写入绑定的简历版本
+func generateVisitorToken(accessKey string, variantID int) (string, error) {
 	// 创建令牌
 	token := jwt.New(jwt.SigningMethodHS256)
 
 	// 设置声明
+	// 每次验证生成独立的会话ID，用于访问统计
+	sessionID, err := generateRandomToken(8)
+	if err != nil {
+		return "", err
+	}
+
 	claims := token.Claims.(jwt.MapClaims)
 	claims["access_key"] = accessKey
 	claims["type"] = "visitor"
+	claims["sid"] = sessionID
+	if variantID > 0 {
+		claims["variant"] = variantID
+	}
+	claims["iat"] = time.Now().Unix()
 	claims["exp"] = time.Now().Add(time.Hour * 24).Unix() // 24小时有效期
 
 	// 签名令牌
@@ -170,7 +236,7 @@ func generateVisitorToken(accessKey string) (string, error) {
 	return tokenString, nil
 }
 
-// VisitorAuthMiddleware 访客身份验证中间件
+// VisitorAuthMiddleware 访客验证中间件
 func VisitorAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取令牌
@@ -184,44 +250,75 @@ func VisitorAuthMiddleware() gin.HandlerFunc {
 		}
 
 		// 移除"Bearer "前缀
-		tokenStr := authHeader[7:]
-
-		// 解析令牌
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			// 验证签名方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return visitorSecretKey, nil
-		})
-
-		if err != nil {
+		claims, message := parseVisitorToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if claims == nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
 				Success: false,
-				Message: "无效的访客令牌",
+				Message: message,
 			})
 			return
 		}
+		setVisitorClaims(c, claims)
+		if !loadVariantScope(c) {
+			return
+		}
+		c.Next()
+	}
+}
 
-		// 验证令牌有效性
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			if claims["type"] != "visitor" {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
-					Success: false,
-					Message: "无效的访客令牌类型",
-				})
-				return
+// OptionalVisitorAuthMiddleware 可选的访客验证，用于未验证也能访问、但验证后内容更完整的接口。
+// 令牌通过Authorization头传递；下载链接和图片无法设置请求头，改用POST /api/tickets换取的一次性票据(?ticket=)。
+// 无效时按未验证处理
+func OptionalVisitorAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); tokenStr != "" {
+			if claims, _ := parseVisitorToken(tokenStr); claims != nil {
+				setVisitorClaims(c, claims)
 			}
-			// 设置访客标识
-			c.Set("visitorAccessKey", claims["access_key"])
-			c.Next()
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
-				Success: false,
-				Message: "无效的访客令牌",
-			})
+		} else if value := c.Query("ticket"); value != "" {
+			if claims, ok := redeemTicket(ticketVisitor, value); ok {
+				setVisitorClaims(c, claims.(jwt.MapClaims))
+			}
+		}
+		if !loadVariantScope(c) {
 			return
 		}
+		c.Next()
+	}
+}
+
+// 解析访客令牌，失败时返回nil和错误提示
+func parseVisitorToken(tokenStr string) (jwt.MapClaims, string) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		// 验证签名方法
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return visitorSecretKey, nil
+	})
+	if err != nil {
+		return nil, "无效的访客令牌"
+	}
+
+	// 验证令牌有效性
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, "无效的访客令牌"
+	}
+	if claims["type"] != "visitor" {
+		return nil, "无效的访客令牌类型"
+	}
+	return claims, ""
+}
+
+// 设置访客标识
+func setVisitorClaims(c *gin.Context, claims jwt.MapClaims) {
+	c.Set("visitorClaims", claims)
+	c.Set("visitorAccessKey", claims["access_key"])
+	c.Set("visitorSessionID", claims["sid"])
+	c.Set("visitorVerifiedAt", claims["iat"])
+	if variant, ok := claims["variant"].(float64); ok {
+		c.Set("visitorVariantID", int(variant))
 	}
 }
 
@@ -241,7 +338,7 @@ func ManageVisitorAccess(c *gin.Context) {
 	log.Printf("管理员准备获取访客密码列表，用户角色: %v", role)
 
 	// 获取所有访问记录
-	rows, err := database.DB.Query("SELECT id, access_type, value, access_key, created_at FROM visitor_access")
+	rows, err := database.DB.Query("SELECT id, access_type, value, access_key, created_at, variant_id FROM visitor_access")
 	if err != nil {
 		log.Printf("查询访客密码数据库错误: %v", err)
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -257,8 +354,9 @@ func ManageVisitorAccess(c *gin.Context) {
 		var id int
 		var accessType, value, accessKey string
 		var createdAt time.Time
+		var variantID sql.NullInt64
 
-		err := rows.Scan(&id, &accessType, &value, &accessKey, &createdAt)
+		err := rows.Scan(&id, &accessType, &value, &accessKey, &createdAt, &variantID)
 		if err != nil {
 			log.Printf("扫描访客密码行数据错误: %v", err)
 			continue
@@ -270,6 +368,7 @@ func ManageVisitorAccess(c *gin.Context) {
 			"value":       value,
 			"access_key":  accessKey,
 			"created_at":  createdAt,
+			"variant_id":  variantID.Int64,
 		})
 	}
 
@@ -300,6 +399,7 @@ func AddVisitorAccess(c *gin.Context) {
 		AccessType string `json:"access_type" binding:"required"`
 		Value      string `json:"value" binding:"required"`
 		AccessKey  string `json:"access_key" binding:"required"`
+		VariantID  int    `json:"variant_id"`
 	}
 
 	if err := c.ShouldBindJSON(&accessData); err != nil {
@@ -320,10 +420,14 @@ func AddVisitorAccess(c *gin.Context) {
 		return
 	}
 
+	if !checkVariantBinding(c, accessData.VariantID) {
+		return
+	}
+
 	// 插入数据
-	_, err := database.DB.Exec(
-		"INSERT INTO visitor_access (access_type, value, access_key) VALUES (?, ?, ?)",
-		accessData.AccessType, accessData.Value, accessData.AccessKey,
+	result, err := database.DB.Exec(
+		"INSERT INTO visitor_access (access_type, value, access_key, variant_id) VALUES (?, ?, ?, ?)",
+		accessData.AccessType, accessData.Value, accessData.AccessKey, nullableVariant(accessData.VariantID),
 	)
 
 	if err != nil {
@@ -335,10 +439,13 @@ func AddVisitorAccess(c *gin.Context) {
 		return
 	}
 
+	id, _ := result.LastInsertId()
+
 	log.Printf("成功添加访客密码: %s", accessData.Value)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "访客密码添加成功",
+		Data:    gin.H{"id": id},
 	})
 }
 
@@ -380,9 +487,155 @@ func DeleteVisitorAccess(c *gin.Context) {
 		return
 	}
 
+	// 解除求职申请的关联
+	if _, err := database.DB.Exec("UPDATE applications SET visitor_access_id = NULL WHERE visitor_access_id = ?", idStr); err != nil {
+		log.Printf("解除求职申请与访客密码的关联失败: %v", err)
+	}
+
 	log.Printf("成功删除ID为 %s 的访客密码", idStr)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "访客密码删除成功",
 	})
 }
+
+// SetVisitorAccessVariant 为访客密码或分享链接绑定简历版本，variant_id为0时解除绑定
+func SetVisitorAccessVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的访客密码ID",
+		})
+		return
+	}
+
+	var req struct {
+		VariantID int `json:"variant_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	if !checkVariantBinding(c, req.VariantID) {
+		return
+	}
+
+	result, err := database.DB.Exec("UPDATE visitor_access SET variant_id = ? WHERE id = ?", nullableVariant(req.VariantID), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "绑定简历版本失败: " + err.Error(),
+		})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定的访客密码记录",
+		})
+		return
+	}
+
+	// 已签发的访客令牌在过期前仍使用原来的版本
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "绑定简历版本成功，新验证的访客生效",
+	})
+}
+
+// CreateShareLink 生成分享链接，访客通过链接中的随机令牌验证身份，可绑定简历版本
+func CreateShareLink(c *gin.Context) {
+	var req struct {
+		AccessKey string `json:"access_key" binding:"required"`
+		VariantID int    `json:"variant_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的请求数据: " + err.Error(),
+		})
+		return
+	}
+	if !checkVariantBinding(c, req.VariantID) {
+		return
+	}
+
+	token, err := generateRandomToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成分享链接失败",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec(
+		"INSERT INTO visitor_access (access_type, value, access_key, variant_id) VALUES ('link', ?, ?, ?)",
+		token, req.AccessKey, nullableVariant(req.VariantID),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成分享链接失败: " + err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	log.Printf("成功生成分享链接，访客标识: %s", req.AccessKey)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "生成分享链接成功",
+		Data: gin.H{
+			"id":         id,
+			"token":      token,
+			"url":        shareLinkURL(c, token),
+			"access_key": req.AccessKey,
+			"variant_id": req.VariantID,
+		},
+	})
+}
+
+// 分享链接的地址，打开后由验证页面使用链接中的令牌自动验证
+func shareLinkURL(c *gin.Context, token string) string {
+	return siteBaseURL(c) + "/verify?link=" + url.QueryEscape(token)
+}
+
+// 分享链接令牌是否存在
+func shareLinkExists(token string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM visitor_access WHERE access_type = 'link' AND value = ?)", token).Scan(&exists)
+	return exists, err
+}
+
+// 检查要绑定的简历版本是否存在，不存在时输出错误并返回false
+func checkVariantBinding(c *gin.Context, variantID int) bool {
+	exists, err := variantExists(variantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "查询简历版本失败: " + err.Error(),
+		})
+		return false
+	}
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "指定的简历版本不存在",
+		})
+		return false
+	}
+	return true
+}
+
+// 未绑定简历版本时写入NULL
+func nullableVariant(variantID int) interface{} {
+	if variantID <= 0 {
+		return nil
+	}
+	return variantID
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/database"
+	"backend/models"
+	"backend/webhooks"
+)
+
+// 扫描回调地址行数据
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (models.Webhook, error) {
+	var w models.Webhook
+	var eventsJSON string
+	var description sql.NullString
+	err := scanner.Scan(&w.ID, &w.URL, &w.Secret, &eventsJSON, &description, &w.Active, &w.CreatedAt)
+	if err != nil {
+		return w, err
+	}
+	w.Description = description.String
+	if err := json.Unmarshal([]byte(eventsJSON), &w.Events); err != nil {
+		w.Events = []string{}
+	}
+	return w, nil
+}
+
+// GetWebhooks 管理员获取回调地址列表
+func GetWebhooks(c *gin.Context) {
+	rows, err := database.DB.Query(`
+		SELECT id, url, secret, events, description, active, created_at
+		FROM webhooks ORDER BY id`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取Webhook列表失败: " + err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "解析Webhook数据失败: " + err.Error(),
+			})
+			return
+		}
+		hooks = append(hooks, w)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取Webhook列表成功",
+		Data:    hooks,
+	})
+}
+
+// CreateWebhook 管理员注册回调地址，未提供密钥时自动生成
+func CreateWebhook(c *gin.Context) {
+	var w models.Webhook
+	w.Active = true
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的Webhook数据: " + err.Error(),
+		})
+		return
+	}
+
+	if w.Secret == "" {
+		secret, err := generateRandomToken(32)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "生成签名密钥失败",
+			})
+			return
+		}
+		w.Secret = secret
+	}
+
+	eventsJSON, _ := json.Marshal(w.Events)
+	w.CreatedAt = time.Now()
+
+	result, err := database.DB.Exec(`
+		INSERT INTO webhooks (url, secret, events, description, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		w.URL, w.Secret, string(eventsJSON), w.Description, w.Active, w.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "创建Webhook失败: " + err.Error(),
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	w.ID = int(id)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "创建Webhook成功",
+		Data:    w,
+	})
+}
+
+// UpdateWebhook 管理员修改回调地址，密钥留空则保持不变
+func UpdateWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的Webhook ID",
+		})
+		return
+	}
+
+	var w models.Webhook
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的Webhook数据: " + err.Error(),
+		})
+		return
+	}
+
+	eventsJSON, _ := json.Marshal(w.Events)
+	result, err := database.DB.Exec(`
+		UPDATE webhooks SET url = ?, secret = CASE WHEN ? = '' THEN secret ELSE ? END,
+		events = ?, description = ?, active = ?
+		WHERE id = ?`,
+		w.URL, w.Secret, w.Secret, string(eventsJSON), w.Description, w.Active, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "更新Webhook失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要更新的Webhook",
+		})
+		return
+	}
+
+	updated, err := scanWebhook(database.DB.QueryRow(`
+		SELECT id, url, secret, events, description, active, created_at
+		FROM webhooks WHERE id = ?`, id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取Webhook失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "更新Webhook成功",
+		Data:    updated,
+	})
+}
+
+// DeleteWebhook 管理员删除回调地址及其投递记录
+func DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的Webhook ID",
+		})
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "删除Webhook失败: " + err.Error(),
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到要删除的Webhook",
+		})
+		return
+	}
+
+	database.DB.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "删除Webhook成功",
+	})
+}
+
+// GetWebhookDeliveries 管理员查看回调地址的投递记录
+func GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的Webhook ID",
+		})
+		return
+	}
+
+	query := `SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+		COALESCE(response_status, 0), COALESCE(response_body, ''), COALESCE(last_error, ''),
+		next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries WHERE webhook_id = ?`
+	args := []interface{}{id}
+	if status := c.Query("status"); status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT 200"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "获取投递记录失败: " + err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.ResponseBody, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "解析投递记录失败: " + err.Error(),
+			})
+			return
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取投递记录成功",
+		Data:    deliveries,
+	})
+}
+
+// RedeliverWebhook 管理员重新投递某条记录
+func RedeliverWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的投递记录ID",
+		})
+		return
+	}
+
+	newID, err := webhooks.Redeliver(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "未找到指定的投递记录",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "重新投递失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "已加入重新投递队列",
+		Data:    gin.H{"delivery_id": newID},
+	})
+}
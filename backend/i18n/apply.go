@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"encoding/json"
+
+	"backend/models"
+)
+
+// TranslateString 用翻译替换字符串字段，values中没有该字段时保留原文
+func TranslateString(dst *string, values map[string]string, field string) {
+	if value, ok := values[field]; ok {
+		*dst = value
+	}
+}
+
+// TranslateList 用翻译替换字符串数组字段
+func TranslateList(dst *[]string, values map[string]string, field string) {
+	value, ok := values[field]
+	if !ok {
+		return
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(value), &list); err == nil {
+		*dst = list
+	}
+}
+
+// TranslateProfile 用翻译替换个人信息的可翻译字段
+func TranslateProfile(profile *models.Profile, values map[string]string) {
+	TranslateString(&profile.Name, values, "name")
+	TranslateString(&profile.Title, values, "title")
+	TranslateString(&profile.Location, values, "location")
+	TranslateString(&profile.Introduction, values, "introduction")
+	TranslateString(&profile.Education, values, "education")
+	TranslateString(&profile.JobStatus, values, "job_status")
+	TranslateString(&profile.Philosophy, values, "philosophy")
+}
+
+// TranslateSkillCategory 用翻译替换技能分类的可翻译字段
+func TranslateSkillCategory(category *models.SkillCategory, values map[string]string) {
+	TranslateString(&category.Name, values, "name")
+	TranslateString(&category.Description, values, "description")
+}
+
+// TranslateSkill 用翻译替换技能的可翻译字段
+func TranslateSkill(skill *models.Skill, values map[string]string) {
+	TranslateString(&skill.Name, values, "name")
+	TranslateString(&skill.Description, values, "description")
+}
+
+// TranslateExperience 用翻译替换工作经历的可翻译字段
+func TranslateExperience(exp *models.Experience, values map[string]string) {
+	TranslateString(&exp.Period, values, "period")
+	TranslateString(&exp.Title, values, "title")
+	TranslateString(&exp.Company, values, "company")
+	TranslateString(&exp.Location, values, "location")
+	TranslateList(&exp.Responsibilities, values, "responsibilities")
+	TranslateList(&exp.Achievements, values, "achievements")
+}
+
+// TranslateProject 用翻译替换项目的可翻译字段
+func TranslateProject(p *models.Project, values map[string]string) {
+	TranslateString(&p.Title, values, "title")
+	TranslateString(&p.Category, values, "category")
+	TranslateString(&p.Description, values, "description")
+	TranslateList(&p.KeyPoints, values, "key_points")
+}
+
+// TranslateCertificate 用翻译替换证书的可翻译字段
+func TranslateCertificate(cert *models.Certificate, values map[string]string) {
+	TranslateString(&cert.Name, values, "name")
+	TranslateString(&cert.Organization, values, "organization")
+	TranslateString(&cert.Description, values, "description")
+}
@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config 多语言配置，全部从环境变量读取
+type Config struct {
+	Base      string   // 主表中内容的语言，该语言不需要翻译
+	Fallback  string   // 请求的语言缺少翻译时使用的语言，与Base相同时直接使用原文
+	Supported []string // 支持的语言，包含Base
+}
+
+var config = LoadConfig()
+
+// LoadConfig 从环境变量加载多语言配置：CONTENT_LOCALE(默认zh)、FALLBACK_LOCALE(默认同CONTENT_LOCALE)、
+// LOCALES(逗号分隔，默认zh,en)
+func LoadConfig() Config {
+	base := normalizeTag(os.Getenv("CONTENT_LOCALE"))
+	if base == "" {
+		base = "zh"
+	}
+
+	supported := []string{base}
+	locales := os.Getenv("LOCALES")
+	if locales == "" {
+		locales = "zh,en"
+	}
+	for _, locale := range strings.Split(locales, ",") {
+		locale = normalizeTag(locale)
+		if locale != "" && !contains(supported, locale) {
+			supported = append(supported, locale)
+		}
+	}
+
+	fallback := normalizeTag(os.Getenv("FALLBACK_LOCALE"))
+	if fallback == "" || !contains(supported, fallback) {
+		fallback = base
+	}
+
+	return Config{Base: base, Fallback: fallback, Supported: supported}
+}
+
+// Settings 当前的多语言配置
+func Settings() Config {
+	return config
+}
+
+// Supported 判断是否为支持的语言
+func Supported(locale string) bool {
+	return contains(config.Supported, locale)
+}
+
+// Negotiate 根据?lang=参数和Accept-Language请求头选择语言，都不匹配时返回Base
+func Negotiate(lang, acceptLanguage string) string {
+	if locale := Match(lang); locale != "" {
+		return locale
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale := Match(tag); locale != "" {
+			return locale
+		}
+	}
+	return config.Base
+}
+
+// Match 将语言标签匹配到支持的语言，如 en-US 匹配 en、zh-Hans-CN 匹配 zh，不支持时返回空字符串
+func Match(tag string) string {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return ""
+	}
+	if contains(config.Supported, tag) {
+		return tag
+	}
+	if i := strings.Index(tag, "-"); i > 0 && contains(config.Supported, tag[:i]) {
+		return tag[:i]
+	}
+	return ""
+}
+
+// 按权重从高到低解析Accept-Language，忽略q=0和通配符
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// 统一语言标签格式：小写，下划线替换为连字符
+func normalizeTag(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+package i18n
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 可翻译的内容类型
+const (
+	Profile       = "profile"
+	SkillCategory = "skill_category"
+	Skill         = "skill"
+	Experience    = "experience"
+	Project       = "project"
+	Certificate   = "certificate"
+	Variant       = "variant"
+)
+
+var (
+	// ErrUnknownEntity 不支持翻译的内容类型
+	ErrUnknownEntity = errors.New("不支持翻译的内容类型")
+	// ErrUnknownField 不支持翻译的字段
+	ErrUnknownField = errors.New("不支持翻译的字段")
+)
+
+// Field 可翻译的字段，List为true时对应主表中JSON格式的字符串数组，翻译同样以JSON数组保存
+type Field struct {
+	Name string
+	List bool
+}
+
+// 内容类型对应的主表、用于展示的字段和可翻译字段，字段名与主表列名一致
+type entity struct {
+	table  string
+	label  string
+	fields []Field
+}
+
+// 缺失翻译报告中内容类型的顺序
+var entityOrder = []string{Profile, SkillCategory, Skill, Experience, Project, Certificate, Variant}
+
+var entities = map[string]entity{
+	Profile: {"profile", "name", []Field{
+		{Name: "name"}, {Name: "title"}, {Name: "location"}, {Name: "introduction"},
+		{Name: "education"}, {Name: "job_status"}, {Name: "philosophy"},
+	}},
+	SkillCategory: {"skill_categories", "name", []Field{
+		{Name: "name"}, {Name: "description"},
+	}},
+	Skill: {"skills", "name", []Field{
+		{Name: "name"}, {Name: "description"},
+	}},
+	Experience: {"experiences", "title", []Field{
+		{Name: "period"}, {Name: "title"}, {Name: "company"}, {Name: "location"},
+		{Name: "responsibilities", List: true}, {Name: "achievements", List: true},
+	}},
+	Project: {"projects", "title", []Field{
+		{Name: "title"}, {Name: "category"}, {Name: "description"},
+		{Name: "key_points", List: true},
+	}},
+	Certificate: {"certificates", "name", []Field{
+		{Name: "name"}, {Name: "organization"}, {Name: "description"},
+	}},
+	// 简历版本覆盖的职位和简介
+	Variant: {"variants", "name", []Field{
+		{Name: "title"}, {Name: "introduction"},
+	}},
+}
+
+// Fields 获取内容类型的可翻译字段
+func Fields(entityType string) ([]Field, error) {
+	def, ok := entities[entityType]
+	if !ok {
+		return nil, ErrUnknownEntity
+	}
+	return def.fields, nil
+}
+
+// Translations 获取某类内容在指定语言下的翻译，按内容ID分组。
+// 缺少的字段依次使用回退语言的翻译，仍没有时不返回该字段，由调用方保留原文
+func Translations(entityType, locale string) (map[int]map[string]string, error) {
+	if _, ok := entities[entityType]; !ok {
+		return nil, ErrUnknownEntity
+	}
+	result := map[int]map[string]string{}
+	if locale == config.Base {
+		return result, nil
+	}
+
+	// 先加载回退语言，再用请求的语言覆盖
+	var chain []string
+	if config.Fallback != config.Base && config.Fallback != locale {
+		chain = append(chain, config.Fallback)
+	}
+	chain = append(chain, locale)
+
+	for _, loc := range chain {
+		rows, err := database.DB.Query(
+			"SELECT entity_id, field, value FROM translations WHERE entity_type = ? AND locale = ?",
+			entityType, loc,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			var field, value string
+			if err := rows.Scan(&id, &field, &value); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if result[id] == nil {
+				result[id] = map[string]string{}
+			}
+			result[id][field] = value
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Get 获取单项内容在指定语言下已有的翻译，不做回退
+func Get(entityType string, id int, locale string) (map[string]string, time.Time, error) {
+	values := map[string]string{}
+	var updatedAt time.Time
+	if _, ok := entities[entityType]; !ok {
+		return values, updatedAt, ErrUnknownEntity
+	}
+
+	rows, err := database.DB.Query(
+		"SELECT field, value, updated_at FROM translations WHERE entity_type = ? AND entity_id = ? AND locale = ?",
+		entityType, id, locale,
+	)
+	if err != nil {
+		return values, updatedAt, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var field, value string
+		var t time.Time
+		if err := rows.Scan(&field, &value, &t); err != nil {
+			return values, updatedAt, err
+		}
+		values[field] = value
+		if t.After(updatedAt) {
+			updatedAt = t
+		}
+	}
+	return values, updatedAt, rows.Err()
+}
+
+// Source 获取单项内容的原文，sql.ErrNoRows表示内容不存在
+func Source(entityType string, id int) (map[string]string, error) {
+	def, ok := entities[entityType]
+	if !ok {
+		return nil, ErrUnknownEntity
+	}
+
+	columns := make([]string, len(def.fields))
+	values := make([]sql.NullString, len(def.fields))
+	dest := make([]interface{}, len(def.fields))
+	for i, field := range def.fields {
+		columns[i] = field.Name
+		dest[i] = &values[i]
+	}
+
+	err := database.DB.QueryRow(
+		"SELECT "+strings.Join(columns, ", ")+" FROM "+def.table+" WHERE id = ?", id,
+	).Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	source := map[string]string{}
+	for i, field := range def.fields {
+		source[field.Name] = values[i].String
+	}
+	return source, nil
+}
+
+// Set 保存单项内容在指定语言下的翻译，值为空的字段删除已有翻译。列表字段的值为JSON字符串数组
+func Set(entityType string, id int, locale string, values map[string]string) error {
+	def, ok := entities[entityType]
+	if !ok {
+		return ErrUnknownEntity
+	}
+	known := map[string]bool{}
+	for _, field := range def.fields {
+		known[field.Name] = true
+	}
+	for field := range values {
+		if !known[field] {
+			return ErrUnknownField
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for field, value := range values {
+		if isEmpty(value) {
+			_, err = tx.Exec(
+				"DELETE FROM translations WHERE entity_type = ? AND entity_id = ? AND locale = ? AND field = ?",
+				entityType, id, locale, field,
+			)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO translations (entity_type, entity_id, locale, field, value, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(entity_type, entity_id, locale, field) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+				entityType, id, locale, field, value, now,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Remove 删除单项内容的所有翻译，在内容被删除时调用
+func Remove(entityType string, id int) error {
+	_, err := database.DB.Exec("DELETE FROM translations WHERE entity_type = ? AND entity_id = ?", entityType, id)
+	return err
+}
+
+// Missing 统计指定语言缺少的翻译，原文为空的字段不计入
+func Missing(locale string) (models.TranslationReport, error) {
+	report := models.TranslationReport{Locale: locale, Missing: []models.MissingTranslation{}}
+
+	translated := map[string]map[int]map[string]bool{}
+	rows, err := database.DB.Query("SELECT entity_type, entity_id, field FROM translations WHERE locale = ?", locale)
+	if err != nil {
+		return report, err
+	}
+	for rows.Next() {
+		var entityType, field string
+		var id int
+		if err := rows.Scan(&entityType, &id, &field); err != nil {
+			rows.Close()
+			return report, err
+		}
+		if translated[entityType] == nil {
+			translated[entityType] = map[int]map[string]bool{}
+		}
+		if translated[entityType][id] == nil {
+			translated[entityType][id] = map[string]bool{}
+		}
+		translated[entityType][id][field] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	for _, entityType := range entityOrder {
+		def := entities[entityType]
+		columns := []string{"id", def.label}
+		for _, field := range def.fields {
+			columns = append(columns, field.Name)
+		}
+
+		rows, err := database.DB.Query("SELECT " + strings.Join(columns, ", ") + " FROM " + def.table + " ORDER BY id")
+		if err != nil {
+			return report, err
+		}
+		for rows.Next() {
+			var id int
+			var label sql.NullString
+			values := make([]sql.NullString, len(def.fields))
+			dest := []interface{}{&id, &label}
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return report, err
+			}
+
+			var missing []string
+			for i, field := range def.fields {
+				if isEmpty(values[i].String) {
+					continue
+				}
+				report.Total++
+				if translated[entityType][id][field.Name] {
+					report.Translated++
+				} else {
+					missing = append(missing, field.Name)
+				}
+			}
+			if len(missing) > 0 {
+				report.Missing = append(report.Missing, models.MissingTranslation{
+					EntityType: entityType,
+					EntityID:   id,
+					Label:      label.String,
+					Fields:     missing,
+				})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// 判断原文或翻译是否为空，空的JSON数组也视为空
+func isEmpty(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "null" {
+		return true
+	}
+	if strings.HasPrefix(value, "[") {
+		var list []string
+		if err := json.Unmarshal([]byte(value), &list); err == nil {
+			for _, item := range list {
+				if strings.TrimSpace(item) != "" {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+// Package linkedin 解析领英(LinkedIn)导出的数据压缩包，并导入为个人信息、工作经历、项目、证书和技能
+package linkedin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/models"
+)
+
+// 单个CSV文件的大小上限，领英导出的文件通常只有几KB
+const maxCSVSize = 4 << 20
+
+// ErrInvalidArchive 不是有效的zip压缩包
+var ErrInvalidArchive = errors.New("无法读取压缩包，请上传领英导出的zip文件")
+
+// ErrEmptyArchive 压缩包中没有可导入的数据
+var ErrEmptyArchive = errors.New("压缩包中没有找到Profile、Positions、Education、Skills、Certifications或Projects文件")
+
+// Archive 从压缩包中解析出的数据，尚未与现有内容比较
+type Archive struct {
+	Files        []string             `json:"files"` // 识别到的CSV文件
+	Profile      *models.Profile      `json:"profile,omitempty"`
+	Experiences  []models.Experience  `json:"experiences"`
+	Projects     []models.Project     `json:"projects"`
+	Certificates []models.Certificate `json:"certificates"`
+	Skills       []string             `json:"skills"`
+}
+
+// 一个CSV文件，按列名读取
+type table struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+func (t *table) each(fn func(get func(column string) string)) {
+	for _, row := range t.rows {
+		fn(func(column string) string {
+			if i, ok := t.columns[strings.ToLower(column)]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		})
+	}
+}
+
+// Read 解析领英数据压缩包。文件可能位于子目录中，按文件名识别，缺少的文件忽略
+func Read(data []byte) (*Archive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	// 每个文件的表头中必须包含的列，用于跳过文件开头的说明文字
+	required := map[string]string{
+		"profile.csv":         "First Name",
+		"positions.csv":       "Company Name",
+		"education.csv":       "School Name",
+		"skills.csv":          "Name",
+		"certifications.csv":  "Name",
+		"projects.csv":        "Title",
+		"email addresses.csv": "Email Address",
+		"phonenumbers.csv":    "Number",
+	}
+	tables := map[string]*table{}
+	archive := &Archive{}
+	for _, f := range zr.File {
+		name := strings.ToLower(path.Base(f.Name))
+		column, ok := required[name]
+		if !ok || f.FileInfo().IsDir() || f.UncompressedSize64 > maxCSVSize {
+			continue
+		}
+		t, err := readTable(f, column)
+		if err != nil {
+			return nil, err
+		}
+		tables[name] = t
+		archive.Files = append(archive.Files, path.Base(f.Name))
+	}
+
+	if t := tables["profile.csv"]; t != nil {
+		t.each(func(get func(string) string) {
+			if archive.Profile != nil {
+				return
+			}
+			archive.Profile = &models.Profile{
+				Name:         fullName(get("First Name"), get("Last Name")),
+				Title:        get("Headline"),
+				Introduction: get("Summary"),
+				Location:     get("Geo Location"),
+			}
+		})
+	}
+	if archive.Profile != nil {
+		if t := tables["education.csv"]; t != nil {
+			// 领英按时间倒序导出，第一条为最高学历
+			t.each(func(get func(string) string) {
+				if archive.Profile.Education == "" {
+					archive.Profile.Education = joinNonEmpty(" · ", get("Degree Name"), get("School Name"))
+				}
+			})
+		}
+		if t := tables["email addresses.csv"]; t != nil {
+			t.each(func(get func(string) string) {
+				if archive.Profile.Email == "" || strings.EqualFold(get("Primary"), "yes") {
+					archive.Profile.Email = get("Email Address")
+				}
+			})
+		}
+		if t := tables["phonenumbers.csv"]; t != nil {
+			t.each(func(get func(string) string) {
+				if archive.Profile.Phone == "" {
+					archive.Profile.Phone = get("Number")
+				}
+			})
+		}
+	}
+
+	if t := tables["positions.csv"]; t != nil {
+		t.each(func(get func(string) string) {
+			if get("Company Name") == "" && get("Title") == "" {
+				return
+			}
+			archive.Experiences = append(archive.Experiences, models.Experience{
+				Period:           period(get("Started On"), get("Finished On")),
+				Title:            get("Title"),
+				Company:          get("Company Name"),
+				Location:         get("Location"),
+				Responsibilities: bulletLines(get("Description")),
+				Achievements:     []string{},
+				Technologies:     []string{},
+			})
+		})
+	}
+
+	if t := tables["projects.csv"]; t != nil {
+		t.each(func(get func(string) string) {
+			if get("Title") == "" {
+				return
+			}
+			archive.Projects = append(archive.Projects, models.Project{
+				Title:       get("Title"),
+				Description: get("Description"),
+				DemoLink:    get("Url"),
+				Metrics:     []models.Metric{},
+				KeyPoints:   []string{},
+				TechStack:   []string{},
+			})
+		})
+	}
+
+	if t := tables["certifications.csv"]; t != nil {
+		t.each(func(get func(string) string) {
+			if get("Name") == "" {
+				return
+			}
+			archive.Certificates = append(archive.Certificates, models.Certificate{
+				Name:         get("Name"),
+				Organization: get("Authority"),
+				Date:         monthText(get("Started On")),
+				Link:         get("Url"),
+				ExpiryDate:   expiryDate(get("Finished On")),
+			})
+		})
+	}
+
+	if t := tables["skills.csv"]; t != nil {
+		t.each(func(get func(string) string) {
+			if name := get("Name"); name != "" {
+				archive.Skills = append(archive.Skills, name)
+			}
+		})
+	}
+
+	if archive.Profile == nil && len(archive.Experiences) == 0 && len(archive.Projects) == 0 &&
+		len(archive.Certificates) == 0 && len(archive.Skills) == 0 {
+		return nil, ErrEmptyArchive
+	}
+	return archive, nil
+}
+
+// 读取CSV文件，以包含requiredColumn的第一行作为表头
+func readTable(f *zip.File, requiredColumn string) (*table, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	raw, err := io.ReadAll(io.LimitReader(rc, maxCSVSize))
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.New("解析" + path.Base(f.Name) + "失败: " + err.Error())
+	}
+
+	t := &table{}
+	for i, record := range records {
+		columns := map[string]int{}
+		for j, column := range record {
+			columns[strings.ToLower(strings.TrimSpace(column))] = j
+		}
+		if _, ok := columns[strings.ToLower(requiredColumn)]; ok {
+			t.columns = columns
+			t.rows = records[i+1:]
+			break
+		}
+	}
+	return t, nil
+}
+
+// 姓名：中日韩姓名按姓在前且不加空格拼接
+func fullName(first, last string) string {
+	if isCJK(first) && isCJK(last) {
+		return last + first
+	}
+	return joinNonEmpty(" ", first, last)
+}
+
+func isCJK(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// 描述按行拆分为列表项，去掉行首的项目符号
+func bulletLines(description string) []string {
+	items := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•·-*–—▪●◦"))
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+var (
+	yearPattern     = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})(?:\D|$)`)
+	yearMonth       = regexp.MustCompile(`((?:19|20)\d{2})[年./-](\d{1,2})`)
+	monthYear       = regexp.MustCompile(`(\d{1,2})[./-]((?:19|20)\d{2})`)
+	englishMonths   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	englishMonthKey = regexp.MustCompile(`[A-Za-z]{3,}`)
+)
+
+// 解析领英的日期，如 "Mar 2019"、"2019"、"2019-03"、"03/2019"、"2019年3月"，月份未知时为0
+func parseDate(s string) (year, month int, ok bool) {
+	s = strings.TrimSpace(s)
+	if m := yearMonth.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+	} else if m := monthYear.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[2])
+		month, _ = strconv.Atoi(m[1])
+	} else if m := yearPattern.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		if word := englishMonthKey.FindString(s); word != "" {
+			for i, name := range englishMonths {
+				if strings.HasPrefix(strings.ToLower(word), name) {
+					month = i + 1
+					break
+				}
+			}
+		}
+	} else {
+		return 0, 0, false
+	}
+	if month < 0 || month > 12 {
+		month = 0
+	}
+	return year, month, true
+}
+
+// 工作经历时间段，与现有数据的写法一致，如 "2019.03 - 2021.06"、"2021.03 - 至今"
+func period(started, finished string) string {
+	start := dotted(started)
+	if start == "" {
+		return dotted(finished)
+	}
+	end := dotted(finished)
+	if end == "" {
+		end = "至今"
+	}
+	return start + " - " + end
+}
+
+func dotted(s string) string {
+	year, month, ok := parseDate(s)
+	if !ok {
+		return ""
+	}
+	if month == 0 {
+		return strconv.Itoa(year)
+	}
+	return strconv.Itoa(year) + "." + twoDigits(month)
+}
+
+// 证书日期，与现有数据的写法一致，如 "2022年3月"
+func monthText(s string) string {
+	year, month, ok := parseDate(s)
+	if !ok {
+		return s
+	}
+	if month == 0 {
+		return strconv.Itoa(year) + "年"
+	}
+	return strconv.Itoa(year) + "年" + strconv.Itoa(month) + "月"
+}
+
+// 证书过期日期(YYYY-MM-DD)，取所在月份(或年份)的最后一天
+func expiryDate(s string) string {
+	year, month, ok := parseDate(s)
+	if !ok {
+		return ""
+	}
+	if month == 0 {
+		month = 12
+	}
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var kept []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return strings.Join(kept, sep)
+}
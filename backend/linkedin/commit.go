@@ -0,0 +1,391 @@
+package linkedin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"backend/database"
+	"backend/events"
+	"backend/markdown"
+	"backend/models"
+)
+
+// 未指定技能分类时，导入的技能放入这个分类(不存在时创建)
+const defaultSkillCategory = "LinkedIn"
+
+// ErrCategoryNotFound 指定的技能分类不存在
+var ErrCategoryNotFound = errors.New("指定的技能分类不存在")
+
+// ActionError 提交的处理方式无效
+type ActionError struct {
+	Key, Action string
+}
+
+func (e *ActionError) Error() string {
+	if e.Action == "" {
+		return "导入预览中没有" + e.Key
+	}
+	return e.Key + "不能使用处理方式" + e.Action
+}
+
+// Options 确认导入时的选择
+type Options struct {
+	Actions         map[string]string `json:"actions"`           // 导入项的处理方式，未指定的使用预览中的默认处理方式
+	SkillCategoryID int               `json:"skill_category_id"` // 新技能所属分类，为0时使用LinkedIn分类
+}
+
+// Result 导入结果
+type Result struct {
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Items   map[string]string `json:"items"` // 每个导入项实际执行的处理方式，合并后没有变化时为skip
+}
+
+// Change 导入修改的内容，由调用方在提交后发布事件
+type Change struct {
+	Event string
+	Data  interface{}
+}
+
+// Commit 按当前内容重新比较并执行导入，所有修改在同一事务中完成
+func Commit(id int, opts Options) (*Result, []Change, error) {
+	imp, err := get(database.DB, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imp.Status != StatePending {
+		return nil, nil, ErrClosed
+	}
+	plan, err := Build(imp.archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validate(plan, opts.Actions); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// 在事务中先把导入标记为已提交，同时提交同一导入时只有一个请求能执行，其余的回滚并返回ErrClosed
+	claimed, err := tx.Exec("UPDATE linkedin_imports SET status = ? WHERE id = ? AND status = ?",
+		StateCommitted, id, StatePending)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n, _ := claimed.RowsAffected(); n == 0 {
+		return nil, nil, ErrClosed
+	}
+
+	c := &committer{tx: tx, skillCategoryID: opts.SkillCategoryID}
+	result := &Result{Items: map[string]string{}}
+	for _, item := range plan.Items {
+		action := item.Action
+		if chosen, ok := opts.Actions[item.Key]; ok {
+			action = chosen
+		}
+		if action != ActionSkip {
+			changed, err := c.apply(item, action)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !changed {
+				action = ActionSkip
+			}
+		}
+		result.Items[item.Key] = action
+		switch action {
+		case ActionCreate:
+			result.Created++
+		case ActionSkip:
+			result.Skipped++
+		default:
+			result.Updated++
+		}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec("UPDATE linkedin_imports SET result = ?, finished_at = ? WHERE id = ?",
+		string(data), time.Now(), id); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return result, c.changes, nil
+}
+
+// 检查提交的处理方式是否在预览允许的范围内
+func validate(plan Plan, actions map[string]string) error {
+	items := map[string]Item{}
+	for _, item := range plan.Items {
+		items[item.Key] = item
+	}
+	for key, action := range actions {
+		item, ok := items[key]
+		if !ok {
+			return &ActionError{Key: key}
+		}
+		allowed := false
+		for _, a := range item.Actions {
+			if a == action {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return &ActionError{Key: key, Action: action}
+		}
+	}
+	return nil
+}
+
+type committer struct {
+	tx              *sql.Tx
+	skillCategoryID int
+	changes         []Change
+}
+
+func (c *committer) apply(item Item, action string) (bool, error) {
+	switch item.Type {
+	case TypeProfile:
+		return c.profile(item, action)
+	case TypeExperience:
+		return c.experience(item, action)
+	case TypeProject:
+		return c.project(item, action)
+	case TypeCertificate:
+		return c.certificate(item, action)
+	case TypeSkill:
+		return c.skill(item)
+	}
+	return false, nil
+}
+
+func (c *committer) profile(item Item, action string) (bool, error) {
+	now := time.Now()
+	if action == ActionCreate {
+		p := *item.Profile
+		p.LastUpdated = now
+		result, err := c.tx.Exec(`
+			INSERT INTO profile (name, title, email, phone, location, introduction, education, last_updated)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.Name, p.Title, p.Email, p.Phone, p.Location, p.Introduction, p.Education, p.LastUpdated)
+		if err != nil {
+			return false, err
+		}
+		id, _ := result.LastInsertId()
+		p.ID = int(id)
+		markdown.RenderProfile(&p)
+		c.changes = append(c.changes, Change{events.ProfileUpdated, p})
+		return true, nil
+	}
+
+	p, err := loadProfile(c.tx)
+	if err != nil || p == nil {
+		return false, err
+	}
+	if !apply(profileFields(p), profileFields(item.Profile), action) {
+		return false, nil
+	}
+	p.LastUpdated = now
+	_, err = c.tx.Exec(`
+		UPDATE profile SET name = ?, title = ?, email = ?, phone = ?, location = ?,
+		introduction = ?, education = ?, last_updated = ? WHERE id = ?`,
+		p.Name, p.Title, p.Email, p.Phone, p.Location, p.Introduction, p.Education, p.LastUpdated, p.ID)
+	if err != nil {
+		return false, err
+	}
+	markdown.RenderProfile(p)
+	c.changes = append(c.changes, Change{events.ProfileUpdated, *p})
+	return true, nil
+}
+
+func (c *committer) experience(item Item, action string) (bool, error) {
+	if action == ActionCreate {
+		exp := *item.Experience
+		exp.Color, exp.Icon = "#1E3A8A", "fas fa-briefcase"
+		if err := c.nextSortOrder("experiences", &exp.SortOrder); err != nil {
+			return false, err
+		}
+		result, err := c.tx.Exec(`
+			INSERT INTO experiences (period, title, company, location, color, icon,
+			responsibilities, achievements, technologies, sort_order)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			exp.Period, exp.Title, exp.Company, exp.Location, exp.Color, exp.Icon,
+			jsonList(exp.Responsibilities), jsonList(exp.Achievements), jsonList(exp.Technologies), exp.SortOrder)
+		if err != nil {
+			return false, err
+		}
+		id, _ := result.LastInsertId()
+		exp.ID = int(id)
+		markdown.RenderExperience(&exp)
+		c.changes = append(c.changes, Change{events.ExperienceCreated, exp})
+		return true, nil
+	}
+
+	found, err := loadExperiences(c.tx, item.ExistingID)
+	if err != nil || len(found) == 0 {
+		return false, err
+	}
+	exp := found[0]
+	if !apply(experienceFields(&exp), experienceFields(item.Experience), action) {
+		return false, nil
+	}
+	_, err = c.tx.Exec("UPDATE experiences SET period = ?, location = ?, responsibilities = ? WHERE id = ?",
+		exp.Period, exp.Location, jsonList(exp.Responsibilities), exp.ID)
+	if err != nil {
+		return false, err
+	}
+	markdown.RenderExperience(&exp)
+	c.changes = append(c.changes, Change{events.ExperienceUpdated, exp})
+	return true, nil
+}
+
+func (c *committer) project(item Item, action string) (bool, error) {
+	if action == ActionCreate {
+		project := *item.Project
+		if err := c.nextSortOrder("projects", &project.SortOrder); err != nil {
+			return false, err
+		}
+		metrics, _ := json.Marshal(project.Metrics)
+		result, err := c.tx.Exec(`
+			INSERT INTO projects (title, category, description, image, demo_link, repo_link,
+			show_architecture, metrics, key_points, tech_stack, sort_order)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			project.Title, project.Category, project.Description, project.Image, project.DemoLink, project.RepoLink,
+			project.ShowArchitecture, string(metrics), jsonList(project.KeyPoints), jsonList(project.TechStack), project.SortOrder)
+		if err != nil {
+			return false, err
+		}
+		id, _ := result.LastInsertId()
+		project.ID = int(id)
+		markdown.RenderProject(&project)
+		c.changes = append(c.changes, Change{events.ProjectCreated, project})
+		return true, nil
+	}
+
+	found, err := loadProjects(c.tx, item.ExistingID)
+	if err != nil || len(found) == 0 {
+		return false, err
+	}
+	project := found[0]
+	if !apply(projectFields(&project), projectFields(item.Project), action) {
+		return false, nil
+	}
+	_, err = c.tx.Exec("UPDATE projects SET description = ?, demo_link = ? WHERE id = ?",
+		project.Description, project.DemoLink, project.ID)
+	if err != nil {
+		return false, err
+	}
+	markdown.RenderProject(&project)
+	c.changes = append(c.changes, Change{events.ProjectUpdated, project})
+	return true, nil
+}
+
+func (c *committer) certificate(item Item, action string) (bool, error) {
+	if action == ActionCreate {
+		cert := *item.Certificate
+		cert.Icon = "fas fa-certificate"
+		if err := c.nextSortOrder("certificates", &cert.SortOrder); err != nil {
+			return false, err
+		}
+		result, err := c.tx.Exec(`
+			INSERT INTO certificates (name, organization, date, description, icon, link, expiry_date, sort_order)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			cert.Name, cert.Organization, cert.Date, cert.Description, cert.Icon, cert.Link, cert.ExpiryDate, cert.SortOrder)
+		if err != nil {
+			return false, err
+		}
+		id, _ := result.LastInsertId()
+		cert.ID = int(id)
+		c.changes = append(c.changes, Change{events.CertificateCreated, cert})
+		return true, nil
+	}
+
+	found, err := loadCertificates(c.tx, item.ExistingID)
+	if err != nil || len(found) == 0 {
+		return false, err
+	}
+	cert := found[0]
+	if !apply(certificateFields(&cert), certificateFields(item.Certificate), action) {
+		return false, nil
+	}
+	_, err = c.tx.Exec("UPDATE certificates SET organization = ?, date = ?, link = ?, expiry_date = ? WHERE id = ?",
+		cert.Organization, cert.Date, cert.Link, cert.ExpiryDate, cert.ID)
+	if err != nil {
+		return false, err
+	}
+	c.changes = append(c.changes, Change{events.CertificateUpdated, cert})
+	return true, nil
+}
+
+// 技能只有名称，只能新建
+func (c *committer) skill(item Item) (bool, error) {
+	categoryID, err := c.categoryID()
+	if err != nil {
+		return false, err
+	}
+	skill := models.Skill{CategoryID: categoryID, Name: item.Skill, Tags: []string{}}
+	result, err := c.tx.Exec("INSERT INTO skills (category_id, name, level, description, tags) VALUES (?, ?, ?, ?, ?)",
+		skill.CategoryID, skill.Name, skill.Level, skill.Description, jsonList(skill.Tags))
+	if err != nil {
+		return false, err
+	}
+	id, _ := result.LastInsertId()
+	skill.ID = int(id)
+	markdown.RenderSkill(&skill)
+	c.changes = append(c.changes, Change{events.SkillCreated, skill})
+	return true, nil
+}
+
+// 新技能所属分类，第一次使用时确认或创建
+func (c *committer) categoryID() (int, error) {
+	if c.skillCategoryID > 0 {
+		var count int
+		if err := c.tx.QueryRow("SELECT COUNT(*) FROM skill_categories WHERE id = ?", c.skillCategoryID).Scan(&count); err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			return 0, ErrCategoryNotFound
+		}
+		return c.skillCategoryID, nil
+	}
+
+	var id int
+	err := c.tx.QueryRow("SELECT id FROM skill_categories WHERE name = ? ORDER BY id LIMIT 1", defaultSkillCategory).Scan(&id)
+	if err == sql.ErrNoRows {
+		result, err := c.tx.Exec("INSERT INTO skill_categories (name, description, icon) VALUES (?, ?, ?)",
+			defaultSkillCategory, "从领英导入的技能", "fab fa-linkedin")
+		if err != nil {
+			return 0, err
+		}
+		last, _ := result.LastInsertId()
+		id = int(last)
+	} else if err != nil {
+		return 0, err
+	}
+	c.skillCategoryID = id
+	return id, nil
+}
+
+// 新记录排在现有记录之后
+func (c *committer) nextSortOrder(table string, order *int) error {
+	return c.tx.QueryRow("SELECT COALESCE(MAX(sort_order), 0) + 1 FROM " + table).Scan(order)
+}
+
+func jsonList(items []string) string {
+	if items == nil {
+		items = []string{}
+	}
+	data, _ := json.Marshal(items)
+	return string(data)
+}
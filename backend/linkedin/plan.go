@@ -0,0 +1,456 @@
+package linkedin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 导入项类型
+const (
+	TypeProfile     = "profile"
+	TypeExperience  = "experience"
+	TypeProject     = "project"
+	TypeCertificate = "certificate"
+	TypeSkill       = "skill"
+)
+
+// 导入项状态
+const (
+	StatusNew       = "new"       // 现有内容中没有对应记录
+	StatusDuplicate = "duplicate" // 与现有记录或压缩包中的前一条记录相同
+	StatusConflict  = "conflict"  // 对应的现有记录有不同的内容
+)
+
+// 处理方式
+const (
+	ActionCreate = "create" // 新建记录
+	ActionMerge  = "merge"  // 只填写现有记录中为空的字段
+	ActionUpdate = "update" // 用导入的内容覆盖现有记录中的不同字段
+	ActionSkip   = "skip"   // 不导入
+)
+
+// 每种状态允许的处理方式，第一个为默认
+var allowedActions = map[string][]string{
+	StatusNew:       {ActionCreate, ActionSkip},
+	StatusDuplicate: {ActionSkip, ActionCreate},
+	StatusConflict:  {ActionMerge, ActionUpdate, ActionSkip, ActionCreate},
+}
+
+// Plan 导入预览：压缩包中的每条记录与现有内容的比较结果
+type Plan struct {
+	Files   []string       `json:"files"`
+	Items   []Item         `json:"items"`
+	Summary map[string]int `json:"summary"` // 各状态的数量
+}
+
+// Item 一条待导入的记录
+type Item struct {
+	Key         string              `json:"key"` // 如experience-0，提交时用于指定处理方式
+	Type        string              `json:"type"`
+	Label       string              `json:"label"`
+	Status      string              `json:"status"`
+	Action      string              `json:"action"`  // 默认处理方式
+	Actions     []string            `json:"actions"` // 可选的处理方式
+	ExistingID  int                 `json:"existing_id,omitempty"`
+	DuplicateOf string              `json:"duplicate_of,omitempty"` // 与压缩包中的哪一条重复
+	Conflicts   []Conflict          `json:"conflicts,omitempty"`
+	Profile     *models.Profile     `json:"profile,omitempty"`
+	Experience  *models.Experience  `json:"experience,omitempty"`
+	Project     *models.Project     `json:"project,omitempty"`
+	Certificate *models.Certificate `json:"certificate,omitempty"`
+	Skill       string              `json:"skill,omitempty"`
+}
+
+// Conflict 一个字段的现有内容和导入内容，Current为空时合并会直接填入
+type Conflict struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Imported string `json:"imported"`
+}
+
+// 参与比较和合并的字段
+type field struct {
+	name string
+	get  func() string
+	set  func(string)
+}
+
+func profileFields(p *models.Profile) []field {
+	return []field{
+		{"name", func() string { return p.Name }, func(v string) { p.Name = v }},
+		{"title", func() string { return p.Title }, func(v string) { p.Title = v }},
+		{"email", func() string { return p.Email }, func(v string) { p.Email = v }},
+		{"phone", func() string { return p.Phone }, func(v string) { p.Phone = v }},
+		{"location", func() string { return p.Location }, func(v string) { p.Location = v }},
+		{"introduction", func() string { return p.Introduction }, func(v string) { p.Introduction = v }},
+		{"education", func() string { return p.Education }, func(v string) { p.Education = v }},
+	}
+}
+
+func experienceFields(e *models.Experience) []field {
+	return []field{
+		{"period", func() string { return e.Period }, func(v string) { e.Period = v }},
+		{"location", func() string { return e.Location }, func(v string) { e.Location = v }},
+		{"responsibilities", func() string { return strings.Join(e.Responsibilities, "\n") },
+			func(v string) { e.Responsibilities = strings.Split(v, "\n") }},
+	}
+}
+
+func projectFields(p *models.Project) []field {
+	return []field{
+		{"description", func() string { return p.Description }, func(v string) { p.Description = v }},
+		{"demo_link", func() string { return p.DemoLink }, func(v string) { p.DemoLink = v }},
+	}
+}
+
+func certificateFields(c *models.Certificate) []field {
+	return []field{
+		{"organization", func() string { return c.Organization }, func(v string) { c.Organization = v }},
+		{"date", func() string { return c.Date }, func(v string) { c.Date = v }},
+		{"link", func() string { return c.Link }, func(v string) { c.Link = v }},
+		{"expiry_date", func() string { return c.ExpiryDate }, func(v string) { c.ExpiryDate = v }},
+	}
+}
+
+// 比较两组字段，只列出导入内容不为空且与现有内容不同的字段
+func compare(current, imported []field) []Conflict {
+	var conflicts []Conflict
+	for i, f := range imported {
+		value := f.get()
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		if existing := current[i].get(); matchKey(existing) != matchKey(value) {
+			conflicts = append(conflicts, Conflict{Field: f.name, Current: existing, Imported: value})
+		}
+	}
+	return conflicts
+}
+
+// 应用处理方式：merge只填写空字段，update覆盖所有不同的字段
+func apply(current, imported []field, action string) bool {
+	changed := false
+	for _, c := range compare(current, imported) {
+		if action == ActionUpdate || strings.TrimSpace(c.Current) == "" {
+			for _, f := range current {
+				if f.name == c.Field {
+					f.set(c.Imported)
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// 用于判断重复的键：忽略大小写、空白和标点
+func matchKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// 现有内容，按匹配键索引
+type existing struct {
+	profile      *models.Profile
+	experiences  map[string]*models.Experience
+	projects     map[string]*models.Project
+	certificates map[string]*models.Certificate
+	skills       map[string]int
+}
+
+func experienceKey(e models.Experience) string   { return matchKey(e.Company) + "|" + matchKey(e.Title) }
+func certificateKey(c models.Certificate) string { return matchKey(c.Name) }
+
+// Build 将压缩包中的数据与现有内容比较，生成导入预览
+func Build(a *Archive) (Plan, error) {
+	current, err := loadExisting(database.DB)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	plan := Plan{Files: a.Files, Items: []Item{}, Summary: map[string]int{}}
+	add := func(item Item, conflicts []Conflict, existingID int, seen map[string]string, key string) {
+		switch {
+		case seen[key] != "":
+			item.Status, item.DuplicateOf = StatusDuplicate, seen[key]
+		case existingID == 0:
+			item.Status = StatusNew
+		case len(conflicts) == 0:
+			item.Status, item.ExistingID = StatusDuplicate, existingID
+		default:
+			item.Status, item.ExistingID, item.Conflicts = StatusConflict, existingID, conflicts
+		}
+		if seen[key] == "" {
+			seen[key] = item.Key
+		}
+		item.Actions = allowedActions[item.Status]
+		item.Action = item.Actions[0]
+		plan.Items = append(plan.Items, item)
+		plan.Summary[item.Status]++
+	}
+
+	if a.Profile != nil {
+		item := Item{Key: TypeProfile, Type: TypeProfile, Label: a.Profile.Name, Profile: a.Profile}
+		var conflicts []Conflict
+		existingID := 0
+		if current.profile != nil {
+			existingID = current.profile.ID
+			conflicts = compare(profileFields(current.profile), profileFields(a.Profile))
+		}
+		add(item, conflicts, existingID, map[string]string{}, "")
+		// 个人信息只有一条，不能重复创建
+		if last := &plan.Items[len(plan.Items)-1]; last.Status != StatusNew {
+			last.Actions = withoutCreate(last.Actions)
+		}
+	}
+
+	seen := map[string]string{}
+	for i := range a.Experiences {
+		exp := &a.Experiences[i]
+		item := Item{Key: TypeExperience + "-" + strconv.Itoa(i), Type: TypeExperience,
+			Label: joinNonEmpty(" · ", exp.Title, exp.Company), Experience: exp}
+		key := experienceKey(*exp)
+		var conflicts []Conflict
+		existingID := 0
+		if match := current.experiences[key]; match != nil {
+			existingID = match.ID
+			conflicts = compare(experienceFields(match), experienceFields(exp))
+		}
+		add(item, conflicts, existingID, seen, key)
+	}
+
+	seen = map[string]string{}
+	for i := range a.Projects {
+		project := &a.Projects[i]
+		item := Item{Key: TypeProject + "-" + strconv.Itoa(i), Type: TypeProject, Label: project.Title, Project: project}
+		key := matchKey(project.Title)
+		var conflicts []Conflict
+		existingID := 0
+		if match := current.projects[key]; match != nil {
+			existingID = match.ID
+			conflicts = compare(projectFields(match), projectFields(project))
+		}
+		add(item, conflicts, existingID, seen, key)
+	}
+
+	seen = map[string]string{}
+	for i := range a.Certificates {
+		cert := &a.Certificates[i]
+		item := Item{Key: TypeCertificate + "-" + strconv.Itoa(i), Type: TypeCertificate,
+			Label: joinNonEmpty(" · ", cert.Name, cert.Organization), Certificate: cert}
+		key := certificateKey(*cert)
+		var conflicts []Conflict
+		existingID := 0
+		if match := current.certificates[key]; match != nil {
+			existingID = match.ID
+			conflicts = compare(certificateFields(match), certificateFields(cert))
+		}
+		add(item, conflicts, existingID, seen, key)
+	}
+
+	seen = map[string]string{}
+	for i, name := range a.Skills {
+		item := Item{Key: TypeSkill + "-" + strconv.Itoa(i), Type: TypeSkill, Label: name, Skill: name}
+		key := matchKey(name)
+		add(item, nil, current.skills[key], seen, key)
+	}
+	return plan, nil
+}
+
+func withoutCreate(actions []string) []string {
+	var result []string
+	for _, action := range actions {
+		if action != ActionCreate {
+			result = append(result, action)
+		}
+	}
+	return result
+}
+
+// 可以是*sql.DB或*sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func loadExisting(q queryer) (*existing, error) {
+	current := &existing{
+		experiences:  map[string]*models.Experience{},
+		projects:     map[string]*models.Project{},
+		certificates: map[string]*models.Certificate{},
+		skills:       map[string]int{},
+	}
+
+	profile, err := loadProfile(q)
+	if err != nil {
+		return nil, err
+	}
+	current.profile = profile
+
+	experiences, err := loadExperiences(q, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range experiences {
+		if key := experienceKey(experiences[i]); current.experiences[key] == nil {
+			current.experiences[key] = &experiences[i]
+		}
+	}
+
+	projects, err := loadProjects(q, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		if key := matchKey(projects[i].Title); current.projects[key] == nil {
+			current.projects[key] = &projects[i]
+		}
+	}
+
+	certificates, err := loadCertificates(q, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range certificates {
+		if key := certificateKey(certificates[i]); current.certificates[key] == nil {
+			current.certificates[key] = &certificates[i]
+		}
+	}
+
+	rows, err := q.Query("SELECT id, name FROM skills ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		if key := matchKey(name); current.skills[key] == 0 {
+			current.skills[key] = id
+		}
+	}
+	return current, rows.Err()
+}
+
+func loadProfile(q queryer) (*models.Profile, error) {
+	var p models.Profile
+	var avatar, email, phone, location, introduction, education, jobStatus, philosophy, resumeURL sql.NullString
+	var years sql.NullInt64
+	var lastUpdated sql.NullTime
+	err := q.QueryRow(`
+		SELECT id, name, title, avatar, email, phone, location, introduction,
+		years_of_exp, education, job_status, philosophy, last_updated, resume_file_url
+		FROM profile ORDER BY id LIMIT 1`).Scan(
+		&p.ID, &p.Name, &p.Title, &avatar, &email, &phone, &location, &introduction,
+		&years, &education, &jobStatus, &philosophy, &lastUpdated, &resumeURL)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Avatar, p.Email, p.Phone, p.Location = avatar.String, email.String, phone.String, location.String
+	p.Introduction, p.Education, p.JobStatus = introduction.String, education.String, jobStatus.String
+	p.Philosophy, p.ResumeFileURL = philosophy.String, resumeURL.String
+	p.YearsOfExp, p.LastUpdated = int(years.Int64), lastUpdated.Time
+	return &p, nil
+}
+
+// id为0时读取全部
+func loadExperiences(q queryer, id int) ([]models.Experience, error) {
+	rows, err := q.Query(`
+		SELECT id, period, title, company, COALESCE(location, ''), COALESCE(color, ''), COALESCE(icon, ''),
+		COALESCE(responsibilities, ''), COALESCE(achievements, ''), COALESCE(technologies, ''), COALESCE(sort_order, 0)
+		FROM experiences WHERE ? = 0 OR id = ? ORDER BY sort_order, id`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Experience
+	for rows.Next() {
+		var e models.Experience
+		var responsibilities, achievements, technologies string
+		if err := rows.Scan(&e.ID, &e.Period, &e.Title, &e.Company, &e.Location, &e.Color, &e.Icon,
+			&responsibilities, &achievements, &technologies, &e.SortOrder); err != nil {
+			return nil, err
+		}
+		unmarshalList(responsibilities, &e.Responsibilities)
+		unmarshalList(achievements, &e.Achievements)
+		unmarshalList(technologies, &e.Technologies)
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+func loadProjects(q queryer, id int) ([]models.Project, error) {
+	rows, err := q.Query(`
+		SELECT id, title, COALESCE(category, ''), COALESCE(description, ''), COALESCE(image, ''),
+		COALESCE(demo_link, ''), COALESCE(repo_link, ''), COALESCE(show_architecture, 0),
+		COALESCE(metrics, ''), COALESCE(key_points, ''), COALESCE(tech_stack, ''), COALESCE(sort_order, 0)
+		FROM projects WHERE ? = 0 OR id = ? ORDER BY sort_order, id`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Project
+	for rows.Next() {
+		var p models.Project
+		var metrics, keyPoints, techStack string
+		if err := rows.Scan(&p.ID, &p.Title, &p.Category, &p.Description, &p.Image, &p.DemoLink, &p.RepoLink,
+			&p.ShowArchitecture, &metrics, &keyPoints, &techStack, &p.SortOrder); err != nil {
+			return nil, err
+		}
+		if metrics != "" {
+			json.Unmarshal([]byte(metrics), &p.Metrics)
+		}
+		unmarshalList(keyPoints, &p.KeyPoints)
+		unmarshalList(techStack, &p.TechStack)
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+func loadCertificates(q queryer, id int) ([]models.Certificate, error) {
+	rows, err := q.Query(`
+		SELECT id, name, COALESCE(organization, ''), COALESCE(date, ''), COALESCE(description, ''),
+		COALESCE(icon, ''), COALESCE(link, ''), COALESCE(expiry_date, ''), COALESCE(sort_order, 0)
+		FROM certificates WHERE ? = 0 OR id = ? ORDER BY sort_order, id`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Certificate
+	for rows.Next() {
+		var c models.Certificate
+		if err := rows.Scan(&c.ID, &c.Name, &c.Organization, &c.Date, &c.Description,
+			&c.Icon, &c.Link, &c.ExpiryDate, &c.SortOrder); err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+// 列表字段以JSON数组保存，格式错误时视为空列表
+func unmarshalList(raw string, target *[]string) {
+	*target = []string{}
+	if raw != "" {
+		json.Unmarshal([]byte(raw), target)
+	}
+}
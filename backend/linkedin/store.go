@@ -0,0 +1,161 @@
+package linkedin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"backend/database"
+)
+
+// 导入状态
+const (
+	StatePending   = "pending"   // 等待确认
+	StateCommitted = "committed" // 已导入
+	StateDiscarded = "discarded" // 已放弃
+)
+
+// ErrNotFound 导入记录不存在
+var ErrNotFound = errors.New("导入记录不存在")
+
+// ErrClosed 导入已提交或已放弃
+var ErrClosed = errors.New("该导入已提交或已放弃")
+
+// Import 一次上传的领英数据，确认前只保存解析结果，不修改现有内容
+type Import struct {
+	ID         int        `json:"id"`
+	FileName   string     `json:"file_name"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Result     *Result    `json:"result,omitempty"`
+	Plan       *Plan      `json:"plan,omitempty"`
+
+	archive *Archive
+}
+
+// Setup 创建导入记录表
+func Setup() error {
+	_, err := database.DB.Exec(`
+	CREATE TABLE IF NOT EXISTS linkedin_imports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_name TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		data TEXT NOT NULL,
+		result TEXT,
+		created_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP
+	)`)
+	return err
+}
+
+// Save 保存解析结果，等待确认
+func Save(fileName string, a *Archive) (*Import, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	imp := &Import{FileName: fileName, Status: StatePending, CreatedAt: time.Now(), archive: a}
+	result, err := database.DB.Exec(
+		"INSERT INTO linkedin_imports (file_name, status, data, created_at) VALUES (?, ?, ?, ?)",
+		imp.FileName, imp.Status, string(data), imp.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+	imp.ID = int(id)
+	return imp, nil
+}
+
+// Get 读取导入记录，等待确认的记录附带按当前内容重新生成的预览
+func Get(id int) (*Import, error) {
+	imp, err := get(database.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status == StatePending {
+		plan, err := Build(imp.archive)
+		if err != nil {
+			return nil, err
+		}
+		imp.Plan = &plan
+	}
+	return imp, nil
+}
+
+func get(q queryer, id int) (*Import, error) {
+	var imp Import
+	var data string
+	var result sql.NullString
+	var finished sql.NullTime
+	err := q.QueryRow(
+		"SELECT id, COALESCE(file_name, ''), status, data, result, created_at, finished_at FROM linkedin_imports WHERE id = ?", id,
+	).Scan(&imp.ID, &imp.FileName, &imp.Status, &data, &result, &imp.CreatedAt, &finished)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	imp.archive = &Archive{}
+	if err := json.Unmarshal([]byte(data), imp.archive); err != nil {
+		return nil, err
+	}
+	if result.Valid {
+		imp.Result = &Result{}
+		if err := json.Unmarshal([]byte(result.String), imp.Result); err != nil {
+			return nil, err
+		}
+	}
+	if finished.Valid {
+		imp.FinishedAt = &finished.Time
+	}
+	return &imp, nil
+}
+
+// List 按时间倒序列出导入记录，不含预览
+func List() ([]Import, error) {
+	rows, err := database.DB.Query(
+		"SELECT id, COALESCE(file_name, ''), status, result, created_at, finished_at FROM linkedin_imports ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := []Import{}
+	for rows.Next() {
+		var imp Import
+		var result sql.NullString
+		var finished sql.NullTime
+		if err := rows.Scan(&imp.ID, &imp.FileName, &imp.Status, &result, &imp.CreatedAt, &finished); err != nil {
+			return nil, err
+		}
+		if result.Valid {
+			imp.Result = &Result{}
+			json.Unmarshal([]byte(result.String), imp.Result)
+		}
+		if finished.Valid {
+			imp.FinishedAt = &finished.Time
+		}
+		imports = append(imports, imp)
+	}
+	return imports, rows.Err()
+}
+
+// Discard 放弃等待确认的导入
+func Discard(id int) error {
+	result, err := database.DB.Exec(
+		"UPDATE linkedin_imports SET status = ?, finished_at = ? WHERE id = ? AND status = ?",
+		StateDiscarded, time.Now(), id, StatePending)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err := get(database.DB, id); err != nil {
+			return err
+		}
+		return ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"os"
+	"strconv"
+)
+
+// Config 邮件发送配置，全部从环境变量读取
+type Config struct {
+	Host       string // SMTP服务器地址
+	Port       int    // SMTP端口
+	Username   string // SMTP认证用户名，留空则不认证
+	Password   string // SMTP认证密码
+	From       string // 发件人地址
+	OwnerEmail string // 接收通知的站长邮箱
+	DevDir     string // 开发模式：设置后邮件写入该目录下的.eml文件而不是真正发送
+}
+
+// LoadConfig 从环境变量加载邮件配置
+func LoadConfig() Config {
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = 25
+	}
+
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "noreply@localhost"
+	}
+
+	return Config{
+		Host:       os.Getenv("SMTP_HOST"),
+		Port:       port,
+		Username:   os.Getenv("SMTP_USERNAME"),
+		Password:   os.Getenv("SMTP_PASSWORD"),
+		From:       from,
+		OwnerEmail: os.Getenv("NOTIFY_EMAIL"),
+		DevDir:     os.Getenv("MAIL_DEV_DIR"),
+	}
+}
+
+// Enabled 是否配置了可用的发送方式
+func (c Config) Enabled() bool {
+	return c.Host != "" || c.DevDir != ""
+}
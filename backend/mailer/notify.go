@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"backend/database"
+)
+
+// NotifyOwner 向站长邮箱发送通知
+func NotifyOwner(template string, data interface{}) {
+	if cfg.OwnerEmail == "" {
+		return
+	}
+	Enqueue(cfg.OwnerEmail, "", template, data)
+}
+
+// NotifyOwnerWithReply 向站长发送通知，并把回复地址设为指定邮箱(如留言者)
+func NotifyOwnerWithReply(replyTo, template string, data interface{}) {
+	if cfg.OwnerEmail == "" {
+		return
+	}
+	Enqueue(cfg.OwnerEmail, replyTo, template, data)
+}
+
+// CertificateExpiry 证书过期提醒模板数据
+type CertificateExpiry struct {
+	ID           int
+	Name         string
+	Organization string
+	ExpiryDate   string
+	DaysLeft     int
+}
+
+// CheckCertificateExpiry 检查在within时间内过期的证书并发送提醒，同一过期日期只提醒一次
+func CheckCertificateExpiry(within time.Duration) {
+	if !cfg.Enabled() || cfg.OwnerEmail == "" {
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, name, COALESCE(organization, ''), expiry_date
+		FROM certificates
+		WHERE expiry_date IS NOT NULL AND expiry_date != ''
+		AND (expiry_notified_for IS NULL OR expiry_notified_for != expiry_date)`)
+	if err != nil {
+		log.Printf("查询证书过期信息失败: %v", err)
+		return
+	}
+
+	// 按本地日期比较，Truncate只能截断到UTC的零点
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	var due []CertificateExpiry
+	for rows.Next() {
+		var cert CertificateExpiry
+		if err := rows.Scan(&cert.ID, &cert.Name, &cert.Organization, &cert.ExpiryDate); err != nil {
+			log.Printf("读取证书数据失败: %v", err)
+			continue
+		}
+		expiry, err := time.ParseInLocation("2006-01-02", cert.ExpiryDate, time.Local)
+		if err != nil {
+			continue
+		}
+		if expiry.Before(today) || expiry.Sub(today) > within {
+			continue
+		}
+		// 跨夏令时切换的一天不是24小时，四舍五入到整天
+		cert.DaysLeft = int(math.Round(expiry.Sub(today).Hours() / 24))
+		due = append(due, cert)
+	}
+	rows.Close()
+
+	for _, cert := range due {
+		// 加入发送队列成功后才标记，失败时下次检查会重试
+		if err := Enqueue(cfg.OwnerEmail, "", TemplateCertificateExpiry, cert); err != nil {
+			continue
+		}
+		if _, err := database.DB.Exec("UPDATE certificates SET expiry_notified_for = ? WHERE id = ?", cert.ExpiryDate, cert.ID); err != nil {
+			log.Printf("记录证书%d的过期提醒失败: %v", cert.ID, err)
+			continue
+		}
+		log.Printf("已将证书过期提醒加入发送队列: %s (%s)", cert.Name, cert.ExpiryDate)
+	}
+}
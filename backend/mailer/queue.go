@@ -0,0 +1,198 @@
+package mailer
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/retry"
+)
+
+const (
+	maxAttempts   = 6                // 最多尝试发送次数
+	pollInterval  = 30 * time.Second // 队列轮询间隔
+	batchSize     = 10               // 每轮最多处理的邮件数
+	expiryWarning = 30 * 24 * time.Hour
+)
+
+// 首次重试等待1分钟，之后每次翻倍，最长6小时
+var backoff = retry.Backoff{Base: time.Minute, Max: 6 * time.Hour}
+
+var (
+	cfg    Config
+	sender Sender
+	wake   = make(chan struct{}, 1)
+)
+
+// Start 加载配置并启动后台发送协程，未配置SMTP或开发目录时邮件功能保持关闭
+func Start() {
+	cfg = LoadConfig()
+	if !cfg.Enabled() {
+		log.Printf("未配置SMTP_HOST或MAIL_DEV_DIR，邮件通知功能未启用")
+		return
+	}
+	sender = newSender(cfg)
+
+	if cfg.DevDir != "" {
+		log.Printf("邮件开发模式已启用，邮件将写入目录: %s", cfg.DevDir)
+	} else {
+		log.Printf("邮件发送已启用，SMTP服务器: %s:%d", cfg.Host, cfg.Port)
+	}
+
+	go worker()
+}
+
+// Enqueue 渲染模板并写入发送队列
+func Enqueue(to, replyTo, template string, data interface{}) error {
+	if !cfg.Enabled() || to == "" {
+		return nil
+	}
+
+	subject, text, html, err := Render(template, data)
+	if err != nil {
+		log.Printf("渲染邮件模板%s失败: %v", template, err)
+		return err
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO mail_queue (template, to_addr, reply_to, subject, text_body, html_body, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		template, to, replyTo, subject, text, html, time.Now(), time.Now())
+	if err != nil {
+		log.Printf("写入邮件队列失败: %v", err)
+		return err
+	}
+
+	trigger()
+	return nil
+}
+
+// Retry 将失败的邮件重新放回队列立即发送
+func Retry(id int) (bool, error) {
+	result, err := database.DB.Exec(`
+		UPDATE mail_queue SET status = 'pending', attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND status != 'sent'`, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected > 0 {
+		trigger()
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListQueue 查询发送队列，status为空时返回全部
+func ListQueue(status string) ([]models.MailQueueItem, error) {
+	query := `SELECT id, template, to_addr, subject, status, attempts, last_error, next_attempt_at, created_at, sent_at
+		FROM mail_queue`
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC LIMIT 200"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.MailQueueItem{}
+	for rows.Next() {
+		var item models.MailQueueItem
+		var lastError sql.NullString
+		if err := rows.Scan(&item.ID, &item.Template, &item.ToAddr, &item.Subject, &item.Status,
+			&item.Attempts, &lastError, &item.NextAttemptAt, &item.CreatedAt, &item.SentAt); err != nil {
+			return nil, err
+		}
+		item.LastError = lastError.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// 唤醒发送协程
+func trigger() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// 后台发送协程：定时或被唤醒时处理到期的邮件，并定期检查证书过期
+func worker() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lastExpiryCheck := time.Time{}
+	for {
+		processQueue()
+
+		if time.Since(lastExpiryCheck) > 12*time.Hour {
+			CheckCertificateExpiry(expiryWarning)
+			lastExpiryCheck = time.Now()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// 发送一批到期的邮件
+func processQueue() {
+	rows, err := database.DB.Query(`
+		SELECT id, to_addr, reply_to, subject, text_body, html_body, attempts
+		FROM mail_queue
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at LIMIT ?`, time.Now(), batchSize)
+	if err != nil {
+		log.Printf("查询邮件队列失败: %v", err)
+		return
+	}
+
+	type queued struct {
+		id       int
+		attempts int
+		msg      Message
+	}
+	var batch []queued
+	for rows.Next() {
+		var q queued
+		var replyTo, text, html sql.NullString
+		if err := rows.Scan(&q.id, &q.msg.To, &replyTo, &q.msg.Subject, &text, &html, &q.attempts); err != nil {
+			log.Printf("读取邮件队列数据失败: %v", err)
+			continue
+		}
+		q.msg.From = cfg.From
+		q.msg.ReplyTo = replyTo.String
+		q.msg.TextBody = text.String
+		q.msg.HTMLBody = html.String
+		batch = append(batch, q)
+	}
+	rows.Close()
+
+	for _, q := range batch {
+		err := sender.Send(q.msg)
+		attempts := q.attempts + 1
+		if err == nil {
+			database.DB.Exec(`UPDATE mail_queue SET status = 'sent', attempts = ?, last_error = NULL, sent_at = ?
+				WHERE id = ?`, attempts, time.Now(), q.id)
+			log.Printf("邮件发送成功，队列ID: %d，收件人: %s", q.id, q.msg.To)
+			continue
+		}
+
+		status := "pending"
+		if attempts >= maxAttempts {
+			status = "failed"
+		}
+		database.DB.Exec(`UPDATE mail_queue SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?
+			WHERE id = ?`, status, attempts, err.Error(), time.Now().Add(backoff.Delay(attempts)), q.id)
+		log.Printf("邮件发送失败，队列ID: %d，第%d次尝试: %v", q.id, attempts, err)
+	}
+}
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Sender 邮件发送方式
+type Sender interface {
+	Send(msg Message) error
+}
+
+// smtpSender 通过SMTP服务器发送
+type smtpSender struct {
+	cfg Config
+}
+
+func (s smtpSender) Send(msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	addr := s.cfg.Host + ":" + strconv.Itoa(s.cfg.Port)
+	return smtp.SendMail(addr, auth, msg.From, []string{msg.To}, data)
+}
+
+// fileSender 开发模式：把邮件写成.eml文件，方便本地查看
+type fileSender struct {
+	dir string
+}
+
+func (s fileSender) Send(msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405"), time.Now().UnixNano()%1000000)
+	return os.WriteFile(filepath.Join(s.dir, name), data, 0644)
+}
+
+// 根据配置选择发送方式，开发模式优先
+func newSender(cfg Config) Sender {
+	if cfg.DevDir != "" {
+		return fileSender{dir: cfg.DevDir}
+	}
+	return smtpSender{cfg: cfg}
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// 内置通知模板名称
+const (
+	TemplateContactMessage    = "contact_message"    // 收到新的联系留言
+	TemplateVisitorFirstUse   = "visitor_first_use"  // 访客密码首次被使用
+	TemplateCertificateExpiry = "certificate_expiry" // 证书即将过期
+	TemplateLoginFailures     = "login_failures"     // 短时间内多次登录失败
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Render 渲染指定模板，返回邮件主题、纯文本正文和HTML正文
+// 纯文本模板 <name>.txt 需要定义 "subject" 块，HTML模板 <name>.html 定义 "content" 块并套用 layout.html
+func Render(name string, data interface{}) (subject, text, html string, err error) {
+	textTmpl, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var buf bytes.Buffer
+	if err = textTmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err = textTmpl.Execute(&buf, data); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+
+	htmlTmpl, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	buf.Reset()
+	if err = htmlTmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
@@ -10,23 +10,107 @@ import (
 	"github.com/gin-gonic/gin"
 	_ "github.com/mattn/go-sqlite3"
 
+	"backend/backup"
 	"backend/database"
+	"backend/export"
+	"backend/feed"
 	"backend/handlers"
+	"backend/linkedin"
+	"backend/mailer"
+	"backend/search"
+	"backend/taxonomy"
+	"backend/tenants"
+	"backend/webhooks"
 )
 
 func main() {
+	// 命令行子命令，执行完直接退出
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export-static":
+			os.Exit(runExportStatic(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
+
+	// 多租户网关模式：本进程只负责转发请求和管理租户，每个租户由独立的子进程提供服务
+	if tenants.Enabled() {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		tenants.SetProxyTrust(handlers.FromTrustedProxy)
+		if err := tenants.Run(port); err != nil {
+			log.Fatalf("多租户网关启动失败: %v", err)
+		}
+		return
+	}
+
 	// 初始化数据库
 	err := initDatabase()
 	if err != nil {
 		log.Printf("数据库初始化失败: %v", err)
 	}
 
+	// 规范化技术标签并建立关联
+	if err := taxonomy.Setup(); err != nil {
+		log.Printf("技术标签迁移失败: %v", err)
+	}
+
+	// 建立全文搜索索引，并随内容变更自动更新
+	if err := search.Setup(); err != nil {
+		log.Printf("全文搜索索引初始化失败: %v", err)
+	} else {
+		search.Start()
+	}
+
+	// 领英数据导入记录
+	if err := linkedin.Setup(); err != nil {
+		log.Printf("领英导入记录表初始化失败: %v", err)
+	}
+
+	// 记录内容的发布和更新时间，用于订阅源
+	if err := feed.Setup(); err != nil {
+		log.Printf("订阅记录初始化失败: %v", err)
+	} else {
+		feed.Start()
+	}
+
+	// 备份任务记录
+	if err := backup.Setup(); err != nil {
+		log.Printf("备份任务记录表初始化失败: %v", err)
+	}
+
+	// 恢复备份后重新执行上面的建表和索引步骤
+	backup.SetMigrator(migrateRestored)
+
+	// 设置了BACKUP_SCHEDULE时定时备份
+	backup.StartScheduler()
+
+	// 启动邮件发送队列
+	mailer.Start()
+
+	// 启动Webhook投递
+	webhooks.Start()
+
+	// 设置了STATIC_EXPORT_DIR时自动生成静态站点
+	export.StartStaticWatcher()
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
 	// 创建Gin路由
 	r := gin.Default()
 
+	// 只信任配置的反向代理转发的客户端IP，否则任何人都能伪造X-Forwarded-For绕过限流
+	if err := r.SetTrustedProxies(handlers.TrustedProxies()); err != nil {
+		log.Printf("TRUSTED_PROXIES配置无效: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+
 	// 配置CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000", "http://localhost:5174"},
@@ -42,15 +126,18 @@ func main() {
 	// 提供前端静态文件
 	// 使用Static而不是StaticFS，并且指定具体文件
 	r.Static("/assets", "./public/assets")
-	r.StaticFile("/", "./public/index.html")
 	r.StaticFile("/favicon.ico", "./public/favicon.ico")
-	// 添加其他可能需要的静态文件
-	r.StaticFile("/robots.txt", "./public/robots.txt")
+	// 入口页面按路由插入SEO元数据，robots.txt和站点地图动态生成
+	r.GET("/", handlers.ServeIndex)
+	r.GET("/robots.txt", handlers.Robots)
+	r.GET("/sitemap.xml", handlers.Sitemap)
+	// Atom、RSS和JSON Feed订阅源，只包含公开的项目、证书和工作经历
+	r.GET("/feed.atom", handlers.FeedAtom)
+	r.GET("/feed.rss", handlers.FeedRSS)
+	r.GET("/feed.json", handlers.FeedJSON)
 
 	// 处理前端路由 (SPA应用需要)
-	r.NoRoute(func(c *gin.Context) {
-		c.File("./public/index.html")
-	})
+	r.NoRoute(handlers.ServeIndex)
 
 	// API路由组
 	api := r.Group("/api")
@@ -61,6 +148,22 @@ func main() {
 		// 登录接口 - 无需任何验证
 		api.POST("/login", handlers.Login)
 
+		// 支持的语言 - 无需验证，供前台切换语言
+		api.GET("/locales", handlers.GetLocales)
+
+		// 推荐信邀请链接 - 凭一次性令牌访问
+		api.GET("/testimonials/invite/:token", handlers.GetTestimonialInvite)
+		api.POST("/testimonials/invite/:token", handlers.SubmitTestimonial)
+
+		// 电子名片和二维码 - 无需验证，验证过的访客可获得邮箱和电话
+		api.GET("/profile.vcf", handlers.OptionalVisitorAuthMiddleware(), handlers.GetProfileVCard)
+		api.GET("/profile/qr.png", handlers.OptionalVisitorAuthMiddleware(), handlers.GetProfileQRCode)
+		// 访客换取一次性票据，用于名片下载链接和二维码图片
+		api.POST("/tickets", handlers.VisitorAuthMiddleware(), handlers.CreateVisitorTicket)
+
+		// 联系表单 - 无需验证，带蜜罐和限流防护
+		api.POST("/contact", handlers.SubmitContact)
+
 		// 临时诊断接口 - 仅用于开发调试
 		api.GET("/debug/visitor-access", func(c *gin.Context) {
 			log.Printf("正在执行访客密码表诊断")
@@ -151,6 +254,8 @@ func main() {
 				access_type VARCHAR(50) NOT NULL,
 				value VARCHAR(255) NOT NULL,
 				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				first_used_at TIMESTAMP,
+				variant_id INTEGER,
 				UNIQUE(access_type, value)
 			);`)
 			if err != nil {
@@ -199,6 +304,7 @@ func main() {
 			admin.POST("/skills", handlers.CreateSkill)
 			admin.PUT("/skills/:id", handlers.UpdateSkill)
 			admin.DELETE("/skills/:id", handlers.DeleteSkill)
+			admin.POST("/skills/:id/accept-level", handlers.AcceptSuggestedSkillLevel)
 
 			// 工作经历接口
 			admin.GET("/experiences", handlers.GetExperiences)
@@ -228,11 +334,122 @@ func main() {
 			admin.GET("/visitor/access", handlers.ManageVisitorAccess)
 			admin.POST("/visitor/access", handlers.AddVisitorAccess)
 			admin.DELETE("/visitor/access/:id", handlers.DeleteVisitorAccess)
+			admin.PUT("/visitor/access/:id/variant", handlers.SetVisitorAccessVariant)
+			admin.POST("/visitor/share-links", handlers.CreateShareLink)
+			admin.GET("/visitor/share-links/:id/qr.png", handlers.GetShareLinkQRCode)
+
+			// 简历版本管理
+			admin.GET("/variants", handlers.GetVariants)
+			admin.GET("/variants/:id", handlers.GetVariant)
+			admin.POST("/variants", handlers.CreateVariant)
+			admin.PUT("/variants/:id", handlers.UpdateVariant)
+			admin.DELETE("/variants/:id", handlers.DeleteVariant)
+
+			// 求职申请管理
+			admin.GET("/applications", handlers.GetApplications)
+			admin.GET("/applications/:id", handlers.GetApplication)
+			admin.POST("/applications", handlers.CreateApplication)
+			admin.PUT("/applications/:id", handlers.UpdateApplication)
+			admin.DELETE("/applications/:id", handlers.DeleteApplication)
+			admin.POST("/applications/:id/stage", handlers.ChangeApplicationStage)
+			admin.GET("/applications/:id/analytics", handlers.GetApplicationAnalytics)
+
+			// 多语言翻译
+			admin.GET("/translations/locales", handlers.GetLocales)
+			admin.GET("/translations/report", handlers.GetTranslationReport)
+			admin.GET("/translations/:type/:id/:locale", handlers.GetEntityTranslations)
+			admin.PUT("/translations/:type/:id/:locale", handlers.UpdateEntityTranslations)
+
+			// 静态站点导出
+			admin.GET("/export/static", handlers.GetStaticSiteStatus)
+			admin.POST("/export/static", handlers.RegenerateStaticSite)
+			admin.GET("/export/static.zip", handlers.DownloadStaticSite)
+
+			// Markdown和纯文本简历导出
+			admin.GET("/export/resume.md", handlers.ExportResumeMarkdown)
+			admin.GET("/export/resume.txt", handlers.ExportResumeText)
+			admin.GET("/export/resume.docx", handlers.ExportResumeDocx)
+
+			// LaTeX简历工程导出
+			admin.GET("/export/latex/templates", handlers.GetLatexTemplates)
+			admin.GET("/export/latex.zip", handlers.DownloadLatex)
+
+			// 领英数据导入：上传后预览，确认后写入
+			admin.GET("/import/linkedin", handlers.GetLinkedInImports)
+			admin.POST("/import/linkedin", handlers.UploadLinkedInImport)
+			admin.GET("/import/linkedin/:id", handlers.GetLinkedInImport)
+			admin.POST("/import/linkedin/:id/commit", handlers.CommitLinkedInImport)
+			admin.DELETE("/import/linkedin/:id", handlers.DiscardLinkedInImport)
+
+			// 备份与恢复
+			admin.GET("/backups", handlers.GetBackups)
+			admin.POST("/backups", handlers.CreateBackup)
+			admin.GET("/backups/status", handlers.GetBackupStatus)
+			admin.POST("/backups/run", handlers.RunBackupJob)
+			admin.GET("/backups/:name", handlers.DownloadBackup)
+			admin.DELETE("/backups/:name", handlers.DeleteBackup)
+			admin.POST("/backups/:name/restore", handlers.RestoreBackup)
+			admin.POST("/restore", handlers.UploadRestore)
+
+			// 订阅源条目可见性
+			admin.GET("/feed/entries", handlers.GetFeedEntries)
+			admin.PUT("/feed/entries/:type/:id", handlers.UpdateFeedEntry)
+
+			// 推荐信管理
+			admin.GET("/testimonials/invites", handlers.GetTestimonialInvites)
+			admin.POST("/testimonials/invites", handlers.CreateTestimonialInvite)
+			admin.DELETE("/testimonials/invites/:id", handlers.DeleteTestimonialInvite)
+			admin.GET("/testimonials", handlers.GetTestimonials)
+			admin.PUT("/testimonials/:id", handlers.UpdateTestimonial)
+			admin.POST("/testimonials/:id/review", handlers.ReviewTestimonial)
+			admin.DELETE("/testimonials/:id", handlers.DeleteTestimonial)
+
+			// 留言收件箱
+			admin.GET("/messages", handlers.GetMessages)
+			admin.GET("/messages/:id", handlers.GetMessage)
+			admin.PUT("/messages/:id/read", handlers.MarkMessageRead)
+			admin.PUT("/messages/:id/archive", handlers.ArchiveMessage)
+			admin.DELETE("/messages/:id", handlers.DeleteMessage)
+
+			// 实时事件流(SSE)
+			admin.GET("/events", handlers.AdminEvents)
+			admin.POST("/events/ticket", handlers.CreateEventTicket)
+
+			// Webhook管理
+			admin.GET("/webhooks", handlers.GetWebhooks)
+			admin.POST("/webhooks", handlers.CreateWebhook)
+			admin.PUT("/webhooks/:id", handlers.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", handlers.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+			admin.POST("/webhooks/deliveries/:id/redeliver", handlers.RedeliverWebhook)
+
+			// 访客统计
+			admin.GET("/analytics/visits", handlers.GetVisitAnalytics)
+			admin.GET("/analytics/sessions", handlers.GetVisitSessions)
+
+			// 邮件发送队列
+			admin.GET("/mail/queue", handlers.GetMailQueue)
+			admin.POST("/mail/queue/:id/retry", handlers.RetryMail)
+
+			// 全文搜索索引
+			admin.POST("/search/reindex", handlers.RebuildSearchIndex)
+
+			// 技术标签管理
+			admin.GET("/technologies", handlers.GetTechnologies)
+			admin.POST("/technologies", handlers.CreateTechnology)
+			admin.PUT("/technologies/:id", handlers.UpdateTechnology)
+			admin.DELETE("/technologies/:id", handlers.DeleteTechnology)
+			admin.POST("/technologies/:id/merge", handlers.MergeTechnology)
+
+			// 职位描述匹配
+			admin.POST("/job-match", handlers.MatchJobDescription)
 		}
 
 		// 需要访客验证的接口 - 只提供GET请求访问
 		visitor := api.Group("/")
 		visitor.Use(handlers.VisitorAuthMiddleware())
+		// 记录访客访问，用于访客统计
+		visitor.Use(handlers.VisitTrackingMiddleware())
 		{
 			// 个人信息接口 - 仅GET需要访客验证
 			visitor.GET("/profile", handlers.GetProfile)
@@ -252,6 +469,21 @@ func main() {
 			// 证书接口 - 仅GET需要访客验证
 			visitor.GET("/certificates", handlers.GetCertificates)
 			visitor.GET("/certificates/:id", handlers.GetCertificate)
+
+			// 推荐信接口 - 仅返回已审核通过的推荐信
+			visitor.GET("/testimonials", handlers.GetApprovedTestimonials)
+
+			// 简历文本接口 - 使用访客绑定的简历版本，可直接粘贴到招聘网站
+			visitor.GET("/resume.md", handlers.GetResumeMarkdown)
+			visitor.GET("/resume.txt", handlers.GetResumeText)
+			visitor.GET("/resume.docx", handlers.GetResumeDocx)
+
+			// 全文搜索接口
+			visitor.GET("/search", handlers.Search)
+
+			// 技术标签接口 - 查看技术在各处的使用情况
+			visitor.GET("/technologies", handlers.GetTechnologies)
+			visitor.GET("/technologies/:slug", handlers.GetTechnology)
 		}
 	}
 
@@ -261,9 +493,9 @@ func main() {
 		port = "8080"
 	}
 
-	// 启动服务器
+	// 启动服务器，HOST为空时监听所有网卡
 	log.Printf("后端API服务已启动，运行在 http://localhost:%s", port)
-	r.Run(":" + port)
+	r.Run(os.Getenv("HOST") + ":" + port)
 }
 
 // 初始化数据库
@@ -271,3 +503,14 @@ func initDatabase() error {
 	// 调用数据库初始化函数
 	return database.SetupDatabase()
 }
+
+// 恢复备份后执行启动时的建表和索引步骤，使较旧的备份与当前结构一致；
+// 备份中的搜索索引可能落后于数据，恢复后总是重建
+func migrateRestored() error {
+	for _, setup := range []func() error{database.Migrate, taxonomy.Setup, search.Setup, search.Rebuild, linkedin.Setup, feed.Setup, backup.Setup} {
+		if err := setup(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
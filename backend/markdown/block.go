@@ -0,0 +1,311 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// 支持的语法：段落、标题、无序/有序列表(可嵌套)、引用、代码块、分隔线，
+// 以及行内的粗体、斜体、删除线、行内代码、链接、图片和自动链接。
+// 原文中的HTML标签一律按文本转义，链接只允许安全的协议，因此输出无需再次过滤即可直接嵌入页面
+
+var (
+	headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	hrPattern      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern   = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	bulletPattern  = regexp.MustCompile(`^( {0,3})([-*+])([ \t]+|$)`)
+	orderedPattern = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])([ \t]+|$)`)
+	quotePattern   = regexp.MustCompile(`^ {0,3}> ?`)
+)
+
+// HTML 将Markdown渲染为安全的HTML
+func HTML(src string) string {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+	var b strings.Builder
+	renderBlocks(&b, splitLines(src), false)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Item 渲染工作职责等本身作为列表项展示的文本，段落不包<p>，其中的子列表等块级语法照常渲染
+func Item(src string) string {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+	var b strings.Builder
+	renderBlocks(&b, splitLines(src), true)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	return strings.Split(src, "\n")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// 列表项的标记
+type listMarker struct {
+	ordered bool
+	char    string // 无序列表的符号或有序列表的分隔符
+	start   string // 有序列表的起始序号
+	width   int    // 标记及其后空格的宽度，续行需缩进到该宽度
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	if m := bulletPattern.FindStringSubmatch(line); m != nil && !hrPattern.MatchString(line) {
+		return listMarker{char: m[2], width: listWidth(m)}, true
+	}
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return listMarker{ordered: true, char: m[3], start: strings.TrimLeft(m[2], "0"), width: listWidth(m)}, true
+	}
+	return listMarker{}, false
+}
+
+// 标记后超过4个空格时视为1个空格，其余属于内容的缩进
+func listWidth(m []string) int {
+	spaces := len(m[len(m)-1])
+	if spaces == 0 || spaces > 4 {
+		spaces = 1
+	}
+	return len(m[0]) - len(m[len(m)-1]) + spaces
+}
+
+// 判断一行是否开始新的块，用于结束段落
+func startsBlock(line string) bool {
+	if headingPattern.MatchString(line) || hrPattern.MatchString(line) ||
+		fencePattern.MatchString(line) || quotePattern.MatchString(line) {
+		return true
+	}
+	if m, ok := parseListMarker(line); ok {
+		// 空列表项和非1开头的有序列表不打断段落
+		rest := strings.TrimSpace(line[min(m.width, len(line)):])
+		return rest != "" && (!m.ordered || m.start == "1")
+	}
+	return false
+}
+
+// 渲染块级内容，tight为true时(紧凑列表项内)段落不包<p>
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+
+		case fencePattern.MatchString(line):
+			i = renderFence(b, lines, i)
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := string('0' + rune(len(m[1])))
+			b.WriteString("<h" + level + ">" + renderInline(strings.TrimSpace(m[2])) + "</h" + level + ">\n")
+			i++
+
+		case hrPattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quotePattern.MatchString(line):
+			var inner []string
+			for i < len(lines) && !isBlank(lines[i]) {
+				if loc := quotePattern.FindStringIndex(lines[i]); loc != nil {
+					inner = append(inner, lines[i][loc[1]:])
+				} else if startsBlock(lines[i]) {
+					break
+				} else {
+					// 引用中段落的延续行
+					inner = append(inner, lines[i])
+				}
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, inner, false)
+			b.WriteString("</blockquote>\n")
+
+		default:
+			if marker, ok := parseListMarker(line); ok {
+				i = renderList(b, lines, i, marker)
+				continue
+			}
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func renderFence(b *strings.Builder, lines []string, i int) int {
+	m := fencePattern.FindStringSubmatch(lines[i])
+	indent, fence, lang := len(m[1]), m[2], m[3]
+	i++
+
+	var code []string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" ") == "" {
+			i++
+			break
+		}
+		// 去掉与开始标记相同的缩进
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var para []string
+	for i < len(lines) && !isBlank(lines[i]) {
+		if len(para) > 0 && startsBlock(lines[i]) {
+			break
+		}
+		para = append(para, lines[i])
+		i++
+	}
+
+	var text strings.Builder
+	for n, line := range para {
+		line = strings.TrimLeft(line, " ")
+		if n == len(para)-1 {
+			text.WriteString(renderInline(strings.TrimRight(line, " ")))
+			break
+		}
+		// 行尾两个空格或反斜杠表示换行
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+		line = strings.TrimRight(line, " ")
+		if hardBreak {
+			text.WriteString(renderInline(strings.TrimSuffix(line, "\\")) + "<br>\n")
+		} else {
+			text.WriteString(renderInline(line) + "\n")
+		}
+	}
+
+	if tight {
+		b.WriteString(text.String() + "\n")
+	} else {
+		b.WriteString("<p>" + text.String() + "</p>\n")
+	}
+	return i
+}
+
+// 渲染连续的同类列表项，返回列表之后的行号
+func renderList(b *strings.Builder, lines []string, i int, marker listMarker) int {
+	var items [][]string
+	loose := false
+
+	for i < len(lines) {
+		m, ok := parseListMarker(lines[i])
+		if !ok || m.ordered != marker.ordered || m.char != marker.char {
+			break
+		}
+
+		item := []string{strings.TrimLeft(lines[i][min(m.width, len(lines[i])):], " ")}
+		if m.width > len(lines[i]) {
+			item[0] = ""
+		}
+		i++
+
+		// 收集缩进的续行和延续的段落行，空行后只有缩进的行仍属于该项
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				next := i + 1
+				for next < len(lines) && isBlank(lines[next]) {
+					next++
+				}
+				if next < len(lines) && indentOf(lines[next]) >= m.width {
+					for ; i < next; i++ {
+						item = append(item, "")
+					}
+					continue
+				}
+				break
+			}
+			if indentOf(line) >= m.width {
+				item = append(item, line[m.width:])
+			} else if _, isItem := parseListMarker(line); isItem {
+				break
+			} else if !isBlank(item[len(item)-1]) && !startsBlock(line) {
+				item = append(item, line)
+			} else {
+				break
+			}
+			i++
+		}
+		items = append(items, item)
+
+		// 项之间有空行时为松散列表，每项的段落包<p>
+		if i < len(lines) && isBlank(lines[i]) {
+			next := i
+			for next < len(lines) && isBlank(lines[next]) {
+				next++
+			}
+			if next < len(lines) {
+				if m2, ok := parseListMarker(lines[next]); ok && m2.ordered == marker.ordered && m2.char == marker.char {
+					loose = true
+					i = next
+					continue
+				}
+			}
+			break
+		}
+	}
+	for _, item := range items {
+		if hasInnerBlank(item) {
+			loose = true
+		}
+	}
+
+	tag := "ul"
+	if marker.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if marker.ordered && marker.start != "" && marker.start != "1" {
+		b.WriteString(` start="` + marker.start + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		var inner strings.Builder
+		renderBlocks(&inner, item, !loose)
+		b.WriteString("<li>" + strings.TrimSuffix(inner.String(), "\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// 列表项内部(不含末尾)是否有空行分隔的多个块
+func hasInnerBlank(item []string) bool {
+	end := len(item)
+	for end > 0 && isBlank(item[end-1]) {
+		end--
+	}
+	for _, line := range item[:end] {
+		if isBlank(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
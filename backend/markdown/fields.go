@@ -0,0 +1,34 @@
+package markdown
+
+import "backend/models"
+
+// 以下函数渲染内容模型中的Markdown字段，须在翻译和简历版本覆盖之后调用
+
+// RenderProfile 渲染个人简介
+func RenderProfile(profile *models.Profile) {
+	profile.IntroductionHTML = HTML(profile.Introduction)
+}
+
+// RenderSkill 渲染技能描述
+func RenderSkill(skill *models.Skill) {
+	skill.DescriptionHTML = HTML(skill.Description)
+}
+
+// RenderExperience 逐项渲染工作职责和主要成就
+func RenderExperience(exp *models.Experience) {
+	exp.ResponsibilitiesHTML = renderItems(exp.Responsibilities)
+	exp.AchievementsHTML = renderItems(exp.Achievements)
+}
+
+// RenderProject 渲染项目描述
+func RenderProject(p *models.Project) {
+	p.DescriptionHTML = HTML(p.Description)
+}
+
+func renderItems(items []string) []string {
+	rendered := make([]string, len(items))
+	for i, item := range items {
+		rendered[i] = Item(item)
+	}
+	return rendered
+}
@@ -0,0 +1,357 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 可以用反斜杠转义的字符
+const escapable = "\\`*_{}[]()#+-.!~<>|\"'"
+
+// 渲染行内语法，其余文本全部转义
+func renderInline(s string) string {
+	var b strings.Builder
+	var text strings.Builder // 待转义输出的普通文本
+	flush := func() {
+		b.WriteString(html.EscapeString(text.String()))
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(escapable, s[i+1]) >= 0:
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if code, n, ok := parseCodeSpan(s[i:]); ok {
+				flush()
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += n
+				continue
+			}
+
+		case c == '!' && strings.HasPrefix(s[i:], "!["):
+			if label, dest, title, n, ok := parseLink(s[i+1:]); ok {
+				flush()
+				b.WriteString(renderImage(Text(label), dest, title))
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if label, dest, title, n, ok := parseLink(s[i:]); ok {
+				flush()
+				b.WriteString(renderLink(renderInline(label), dest, title))
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				if url := s[i+1 : i+end]; isAutolink(url) {
+					flush()
+					dest := url
+					if strings.Contains(url, "@") && !strings.Contains(url, ":") {
+						dest = "mailto:" + url
+					}
+					b.WriteString(renderLink(html.EscapeString(url), dest, ""))
+					i += end + 1
+					continue
+				}
+			}
+
+		case c == 'h' && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")) && wordBoundary(s, i):
+			n := bareURLLength(s[i:])
+			flush()
+			b.WriteString(renderLink(html.EscapeString(s[i:i+n]), s[i:i+n], ""))
+			i += n
+			continue
+
+		case c == '*' || c == '_' || c == '~':
+			if tag, inner, n, ok := parseEmphasis(s, i); ok {
+				flush()
+				b.WriteString("<" + tag + ">" + renderInline(inner) + "</" + tag + ">")
+				i += n
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		text.WriteString(s[i : i+size])
+		i += size
+	}
+	flush()
+	return b.String()
+}
+
+// 解析行内代码，返回代码内容和消耗的长度
+func parseCodeSpan(s string) (string, int, bool) {
+	ticks := len(s) - len(strings.TrimLeft(s, "`"))
+	fence := s[:ticks]
+	for pos := ticks; pos < len(s); {
+		end := strings.Index(s[pos:], fence)
+		if end < 0 {
+			return "", 0, false
+		}
+		end += pos
+		after := end + ticks
+		if after < len(s) && s[after] == '`' {
+			// 反引号数量不同，继续查找
+			pos = after + len(s[after:]) - len(strings.TrimLeft(s[after:], "`"))
+			continue
+		}
+		code := s[ticks:end]
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+		return code, after, true
+	}
+	return "", 0, false
+}
+
+// 解析 [文本](地址 "标题")，s以[开头
+func parseLink(s string) (label, dest, title string, n int, ok bool) {
+	depth := 0
+	closeBracket := -1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			if _, skip, found := parseCodeSpan(s[i:]); found {
+				i += skip - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeBracket = i
+			}
+		}
+		if closeBracket >= 0 {
+			break
+		}
+	}
+	if closeBracket < 0 || closeBracket+1 >= len(s) || s[closeBracket+1] != '(' {
+		return "", "", "", 0, false
+	}
+
+	rest := s[closeBracket+2:]
+	depth = 1
+	end := -1
+	for i := 0; i < len(rest) && end < 0; i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return "", "", "", 0, false
+	}
+
+	inner := strings.TrimSpace(rest[:end])
+	if strings.HasPrefix(inner, "<") {
+		if gt := strings.IndexByte(inner, '>'); gt > 0 {
+			dest, inner = inner[1:gt], strings.TrimSpace(inner[gt+1:])
+		}
+	} else if sp := strings.IndexAny(inner, " \n"); sp >= 0 {
+		dest, inner = inner[:sp], strings.TrimSpace(inner[sp+1:])
+	} else {
+		dest, inner = inner, ""
+	}
+	if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+		title = inner[1 : len(inner)-1]
+	} else if inner != "" {
+		return "", "", "", 0, false
+	}
+
+	return s[1:closeBracket], unescape(dest), unescape(title), closeBracket + 2 + end + 1, true
+}
+
+// 解析强调：**粗体** __粗体__ *斜体* _斜体_ ~~删除线~~
+func parseEmphasis(s string, i int) (tag, inner string, n int, ok bool) {
+	c := s[i]
+	run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+	var delim string
+	switch {
+	case c == '~' && run == 2:
+		delim, tag = "~~", "del"
+	case c != '~' && run >= 2:
+		delim, tag = string([]byte{c, c}), "strong"
+	case c != '~' && run == 1:
+		delim, tag = string(c), "em"
+	default:
+		return "", "", 0, false
+	}
+
+	start := i + len(delim)
+	if start >= len(s) || isSpaceAt(s, start) {
+		return "", "", 0, false
+	}
+	// 下划线在单词内部不算强调，避免snake_case被误解析
+	if c == '_' && !wordBoundary(s, i) {
+		return "", "", 0, false
+	}
+
+	for pos := start + 1; pos <= len(s)-len(delim); pos++ {
+		if s[pos] == '\\' {
+			pos++
+			continue
+		}
+		if s[pos] == '`' {
+			if _, skip, found := parseCodeSpan(s[pos:]); found {
+				pos += skip - 1
+				continue
+			}
+		}
+		if s[pos] != c {
+			continue
+		}
+		// 按整段连续的标记判断：长度相同才能结束，*斜体**粗体*** 这样的三个标记
+		// 用最后几个结束本层，前面的留给内层
+		closeRun := len(s[pos:]) - len(strings.TrimLeft(s[pos:], string(c)))
+		if isSpaceBefore(s, pos) || (closeRun != len(delim) && closeRun != 3) {
+			pos += closeRun - 1
+			continue
+		}
+		end := pos + closeRun - len(delim)
+		after := pos + closeRun
+		if c == '_' && after < len(s) && isWordByte(s, after) {
+			pos += closeRun - 1
+			continue
+		}
+		return tag, s[start:end], after - i, true
+	}
+	return "", "", 0, false
+}
+
+func isAutolink(s string) bool {
+	if s == "" || strings.ContainsAny(s, " <>\n") {
+		return false
+	}
+	if strings.Contains(s, "@") && !strings.Contains(s, ":") {
+		return strings.Index(s, "@") > 0 && strings.Contains(s[strings.Index(s, "@"):], ".")
+	}
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "mailto:")
+}
+
+// 裸链接的长度，末尾的标点不计入
+func bareURLLength(s string) int {
+	n := strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '<' || r == '>' || r == '"' || r > unicode.MaxASCII
+	})
+	if n < 0 {
+		n = len(s)
+	}
+	for n > 0 && strings.IndexByte(".,:;!?'*_~", s[n-1]) >= 0 {
+		n--
+	}
+	// 不成对的右括号不属于链接
+	if n > 0 && s[n-1] == ')' && strings.Count(s[:n], "(") < strings.Count(s[:n], ")") {
+		n--
+	}
+	return n
+}
+
+func renderLink(content, dest, title string) string {
+	href, ok := SafeURL(dest)
+	if !ok {
+		return content
+	}
+	out := `<a href="` + html.EscapeString(href) + `"`
+	if title != "" {
+		out += ` title="` + html.EscapeString(title) + `"`
+	}
+	if isExternal(href) {
+		out += ` rel="nofollow noopener noreferrer" target="_blank"`
+	}
+	return out + ">" + content + "</a>"
+}
+
+func renderImage(alt, src, title string) string {
+	url, ok := SafeURL(src)
+	if !ok || strings.HasPrefix(strings.ToLower(url), "mailto:") || strings.HasPrefix(strings.ToLower(url), "tel:") {
+		return html.EscapeString(alt)
+	}
+	out := `<img src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(alt) + `"`
+	if title != "" {
+		out += ` title="` + html.EscapeString(title) + `"`
+	}
+	return out + ` loading="lazy">`
+}
+
+// SafeURL 检查链接地址，只允许http、https、mailto、tel协议和相对地址
+func SafeURL(raw string) (string, bool) {
+	url := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, raw)
+	if url == "" {
+		return "", false
+	}
+	if colon := strings.IndexByte(url, ':'); colon >= 0 {
+		if sep := strings.IndexAny(url, "/?#"); sep < 0 || colon < sep {
+			switch strings.ToLower(url[:colon]) {
+			case "http", "https", "mailto", "tel":
+			default:
+				return "", false
+			}
+		}
+	}
+	return url, true
+}
+
+func isExternal(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "//")
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(escapable, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isSpaceAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsSpace(r)
+}
+
+func isSpaceBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return unicode.IsSpace(r)
+}
+
+func isWordByte(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// i之前是否为单词边界(开头、空白或标点)
+func wordBoundary(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !(unicode.IsLetter(r) || unicode.IsDigit(r)) || r > unicode.MaxASCII
+}
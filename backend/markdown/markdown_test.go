@@ -0,0 +1,141 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		raw, want string
+		ok        bool
+	}{
+		{"https://example.com/a?b=c", "https://example.com/a?b=c", true},
+		{"HTTP://example.com", "HTTP://example.com", true},
+		{"mailto:me@example.com", "mailto:me@example.com", true},
+		{"tel:+86-10-12345678", "tel:+86-10-12345678", true},
+		{"/projects/1", "/projects/1", true},
+		{"#contact", "#contact", true},
+		{"//cdn.example.com/a.png", "//cdn.example.com/a.png", true},
+		// 冒号出现在路径、查询或片段中时仍是相对地址
+		{"/path:with:colon", "/path:with:colon", true},
+		{"?q=a:b", "?q=a:b", true},
+		// 实体和百分号编码不会被解码，浏览器也只会把它们当作相对地址
+		{"&#106;avascript:alert(1)", "&#106;avascript:alert(1)", true},
+		{"javascript&colon;alert(1)", "javascript&colon;alert(1)", true},
+		{"javascript%3Aalert(1)", "javascript%3Aalert(1)", true},
+
+		{"javascript:alert(1)", "", false},
+		{"JaVaScRiPt:alert(1)", "", false},
+		{"  javascript:alert(1)  ", "", false},
+		{"java\tscript:alert(1)", "", false},
+		{"java\nscript:alert(1)", "", false},
+		{"\x01javascript:alert(1)", "", false},
+		{"java　script:alert(1)", "", false},
+		{"vbscript:msgbox(1)", "", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", "", false},
+		{"DATA:image/svg+xml,<svg onload=alert(1)>", "", false},
+		{"file:///etc/passwd", "", false},
+		{"", "", false},
+		{" \t", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SafeURL(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("SafeURL(%q) = %q, %v; 期望 %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// 外部链接统一添加的属性
+const external = ` rel="nofollow noopener noreferrer" target="_blank"`
+
+func TestHTMLSanitizes(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		// 不安全的链接只保留文字
+		{"javascript链接", "[x](javascript:alert(1))", "<p>x</p>"},
+		{"大小写混合", "[x](JaVaScRiPt:alert(1))", "<p>x</p>"},
+		{"首尾空白", "[x]( javascript:alert(1) )", "<p>x</p>"},
+		{"尖括号中夹空白", "[x](<java script:alert(1)>)", "<p>x</p>"},
+		{"data链接", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>"},
+		{"data图片", "![x](DATA:image/svg+xml,<svg>)", "<p>x</p>"},
+		{"mailto图片", "![x](mailto:a@example.com)", "<p>x</p>"},
+		{"javascript自动链接", "<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>"},
+		// 实体编码的协议不会被解码，&再次转义后浏览器得到的是相对地址
+		{"实体编码", "[x](&#106;avascript:alert(1))", `<p><a href="&amp;#106;avascript:alert(1)">x</a></p>`},
+		{"实体编码的冒号", "[x](javascript&colon;alert(1))", `<p><a href="javascript&amp;colon;alert(1)">x</a></p>`},
+		// 制表符展开为空格后不再是合法的链接，按原文输出
+		{"制表符", "[x](java\tscript:alert(1))", "<p>[x](java    script:alert(1))</p>"},
+
+		// 原文中的HTML一律转义
+		{"script标签", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"事件属性", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>"},
+		{"链接文字中的标签", "[<b>x</b>](/a)", `<p><a href="/a">&lt;b&gt;x&lt;/b&gt;</a></p>`},
+		{"行内代码", "`<script>`", "<p><code>&lt;script&gt;</code></p>"},
+		{"代码块", "```\n<script>\n```", "<pre><code>&lt;script&gt;\n</code></pre>"},
+		{"引用", "> <svg onload=alert(1)>", "<blockquote>\n<p>&lt;svg onload=alert(1)&gt;</p>\n</blockquote>"},
+		{"标题", "# <iframe>", "<h1>&lt;iframe&gt;</h1>"},
+		{"列表", "- <script>", "<ul>\n<li>&lt;script&gt;</li>\n</ul>"},
+
+		// 属性值中的引号被转义，不能闭合属性
+		{"标题中的双引号", `[x](https://e.com "a\" onmouseover=\"alert(1)")`,
+			`<p><a href="https://e.com" title="a&#34; onmouseover=&#34;alert(1)"` + external + `>x</a></p>`},
+		{"单引号标题中的双引号", `[x](https://e.com 'a" onmouseover="alert(1)')`,
+			`<p><a href="https://e.com" title="a&#34; onmouseover=&#34;alert(1)"` + external + `>x</a></p>`},
+		{"标题中的实体", `[x](https://e.com "a&quot; onmouseover=&quot;alert(1)")`,
+			`<p><a href="https://e.com" title="a&amp;quot; onmouseover=&amp;quot;alert(1)"` + external + `>x</a></p>`},
+		{"图片替代文字", `![a" onerror="alert(1)](/p.png)`,
+			`<p><img src="/p.png" alt="a&#34; onerror=&#34;alert(1)" loading="lazy"></p>`},
+		{"图片标题", `![a](/p.png "t\" onerror=\"x")`,
+			`<p><img src="/p.png" alt="a" title="t&#34; onerror=&#34;x" loading="lazy"></p>`},
+		{"地址中的双引号", `[x](/a"onclick="x)`, `<p><a href="/a&#34;onclick=&#34;x">x</a></p>`},
+		{"裸链接后的双引号", `https://e.com/a"onmouseover="x`,
+			`<p><a href="https://e.com/a"` + external + `>https://e.com/a</a>&#34;onmouseover=&#34;x</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.src); got != tt.want {
+				t.Errorf("HTML(%q)\n得到 %s\n期望 %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLEmphasis(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"**粗体** 和 *斜体*", "<p><strong>粗体</strong> 和 <em>斜体</em></p>"},
+		{"__粗体__ 和 _斜体_", "<p><strong>粗体</strong> 和 <em>斜体</em></p>"},
+		{"***粗斜体***", "<p><strong><em>粗斜体</em></strong></p>"},
+		{"**粗体*斜体*粗体**", "<p><strong>粗体<em>斜体</em>粗体</strong></p>"},
+		{"*斜体**粗体**斜体*", "<p><em>斜体<strong>粗体</strong>斜体</em></p>"},
+		{"~~删除**粗体**~~", "<p><del>删除<strong>粗体</strong></del></p>"},
+		{"**[链接](https://e.com)**", `<p><strong><a href="https://e.com"` + external + `>链接</a></strong></p>`},
+		{"**<em>**", "<p><strong>&lt;em&gt;</strong></p>"},
+		{"snake_case_name", "<p>snake_case_name</p>"},
+		{"2 * 3 * 4", "<p>2 * 3 * 4</p>"},
+		{"**未闭合", "<p>**未闭合</p>"},
+		{`\*不是强调\*`, "<p>*不是强调*</p>"},
+		{"`**代码**`", "<p><code>**代码**</code></p>"},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.src); got != tt.want {
+			t.Errorf("HTML(%q)\n得到 %s\n期望 %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestItem(t *testing.T) {
+	// 列表项文本不包<p>，子列表照常渲染
+	got := Item("负责**容器平台**建设\n- <b>子项</b>")
+	want := "负责<strong>容器平台</strong>建设\n<ul>\n<li>&lt;b&gt;子项&lt;/b&gt;</li>\n</ul>"
+	if got != want {
+		t.Errorf("Item()\n得到 %s\n期望 %s", got, want)
+	}
+	if strings.TrimSpace(Item("   ")) != "" {
+		t.Error("空白内容应渲染为空")
+	}
+}
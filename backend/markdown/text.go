@@ -0,0 +1,109 @@
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Text 将Markdown转为纯文本，用于搜索索引、纯文本导出等不能显示HTML的场合。
+// 列表项以"- "或序号开头，链接保留文字，块之间以空行分隔
+func Text(src string) string {
+	rendered := HTML(src)
+	var b strings.Builder
+	var counters []int // 每层列表的序号，无序列表为-1
+	newline := func() {
+		if out := b.String(); out != "" && !strings.HasSuffix(out, "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	for i := 0; i < len(rendered); {
+		if rendered[i] != '<' {
+			end := strings.IndexByte(rendered[i:], '<')
+			if end < 0 {
+				end = len(rendered) - i
+			}
+			// 标签之间的换行只是HTML的排版
+			if segment := rendered[i : i+end]; strings.Trim(segment, "\n") != "" {
+				b.WriteString(segment)
+			}
+			i += end
+			continue
+		}
+
+		end := strings.IndexByte(rendered[i:], '>')
+		tag := rendered[i+1 : i+end]
+		i += end + 1
+		name := strings.TrimPrefix(strings.Fields(tag)[0], "/")
+		closing := strings.HasPrefix(tag, "/")
+		switch name {
+		case "p", "h1", "h2", "h3", "h4", "h5", "h6", "pre", "blockquote", "hr":
+			if closing || name == "hr" {
+				b.WriteString("\n\n")
+			}
+		case "ul", "ol":
+			if closing {
+				counters = counters[:len(counters)-1]
+				if len(counters) == 0 {
+					b.WriteString("\n\n")
+				}
+			} else if name == "ul" {
+				counters = append(counters, -1)
+			} else {
+				start, err := strconv.Atoi(attr(tag, "start"))
+				if err != nil {
+					start = 1
+				}
+				counters = append(counters, start-1)
+			}
+		case "li":
+			if closing || len(counters) == 0 {
+				break
+			}
+			newline()
+			b.WriteString(strings.Repeat("  ", len(counters)-1))
+			if n := &counters[len(counters)-1]; *n >= 0 {
+				*n++
+				b.WriteString(strconv.Itoa(*n) + ". ")
+			} else {
+				b.WriteString("- ")
+			}
+		case "img":
+			b.WriteString(attr(tag, "alt"))
+		}
+	}
+
+	// 合并多余的空行
+	lines := strings.Split(html.UnescapeString(b.String()), "\n")
+	var out []string
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if line == "" {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// 读取标签属性，仅用于本包生成的HTML
+func attr(tag, name string) string {
+	key := name + `="`
+	start := strings.Index(tag, key)
+	if start < 0 {
+		return ""
+	}
+	start += len(key)
+	end := strings.IndexByte(tag[start:], '"')
+	if end < 0 {
+		return ""
+	}
+	return tag[start : start+end]
+}
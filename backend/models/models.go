@@ -4,20 +4,21 @@ import "time"
 
 // Profile 个人信息模型
 type Profile struct {
-	ID            int       `json:"id"`
-	Name          string    `json:"name"`
-	Title         string    `json:"title"`
-	Avatar        string    `json:"avatar"`
-	Email         string    `json:"email"`
-	Phone         string    `json:"phone"`
-	Location      string    `json:"location"`
-	Introduction  string    `json:"introduction"`
-	YearsOfExp    int       `json:"years_of_exp"`
-	Education     string    `json:"education"`
-	JobStatus     string    `json:"job_status"`
-	Philosophy    string    `json:"philosophy"`
-	LastUpdated   time.Time `json:"last_updated"`
-	ResumeFileURL string    `json:"resume_file_url"`
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	Title            string    `json:"title"`
+	Avatar           string    `json:"avatar"`
+	Email            string    `json:"email"`
+	Phone            string    `json:"phone"`
+	Location         string    `json:"location"`
+	Introduction     string    `json:"introduction"`      // Markdown
+	IntroductionHTML string    `json:"introduction_html"` // 由introduction渲染的HTML，只读
+	YearsOfExp       int       `json:"years_of_exp"`
+	Education        string    `json:"education"`
+	JobStatus        string    `json:"job_status"`
+	Philosophy       string    `json:"philosophy"`
+	LastUpdated      time.Time `json:"last_updated"`
+	ResumeFileURL    string    `json:"resume_file_url"`
 }
 
 // SkillCategory 技能分类模型
@@ -31,12 +32,14 @@ type SkillCategory struct {
 
 // Skill 技能模型
 type Skill struct {
-	ID          int      `json:"id"`
-	CategoryID  int      `json:"category_id"`
-	Name        string   `json:"name"`
-	Level       int      `json:"level"`
-	Description string   `json:"description"`
-	Tags        []string `json:"tags"`
+	ID              int            `json:"id"`
+	CategoryID      int            `json:"category_id"`
+	Name            string         `json:"name"`
+	Level           int            `json:"level"`
+	Description     string         `json:"description"`      // Markdown
+	DescriptionHTML string         `json:"description_html"` // 由description渲染的HTML，只读
+	Tags            []string       `json:"tags"`
+	Evidence        *SkillEvidence `json:"evidence,omitempty"` // 由工作经历和项目推算，只读
 }
 
 // Experience 工作经历模型
@@ -48,10 +51,14 @@ type Experience struct {
 	Location         string   `json:"location"`
 	Color            string   `json:"color"`
 	Icon             string   `json:"icon"`
-	Responsibilities []string `json:"responsibilities"`
-	Achievements     []string `json:"achievements"`
+	Responsibilities []string `json:"responsibilities"` // 每项为Markdown
+	Achievements     []string `json:"achievements"`     // 每项为Markdown
 	Technologies     []string `json:"technologies"`
 	SortOrder        int      `json:"sort_order"`
+
+	// 由工作职责和主要成就逐项渲染的HTML，只读
+	ResponsibilitiesHTML []string `json:"responsibilities_html"`
+	AchievementsHTML     []string `json:"achievements_html"`
 }
 
 // Project 项目经验模型
@@ -59,7 +66,8 @@ type Project struct {
 	ID               int      `json:"id"`
 	Title            string   `json:"title"`
 	Category         string   `json:"category"`
-	Description      string   `json:"description"`
+	Description      string   `json:"description"`      // Markdown
+	DescriptionHTML  string   `json:"description_html"` // 由description渲染的HTML，只读
 	Image            string   `json:"image"`
 	DemoLink         string   `json:"demo_link"`
 	RepoLink         string   `json:"repo_link"`
@@ -85,6 +93,7 @@ type Certificate struct {
 	Description  string `json:"description"`
 	Icon         string `json:"icon"`
 	Link         string `json:"link"`
+	ExpiryDate   string `json:"expiry_date"` // 过期日期(YYYY-MM-DD)，长期有效则留空
 	SortOrder    int    `json:"sort_order"`
 }
 
@@ -116,7 +125,7 @@ type APIResponse struct {
 
 // VerificationRequest 访客验证请求
 type VerificationRequest struct {
-	VerificationType string `json:"verification_type"` // "name", "email", "phone", "password"
+	VerificationType string `json:"verification_type"` // "name", "email", "phone", "password", "link"
 	Value            string `json:"value"`
 }
 
@@ -125,3 +134,399 @@ type VerificationResponse struct {
 	Success bool   `json:"success"`
 	Token   string `json:"token,omitempty"`
 }
+
+// TestimonialInvite 推荐信邀请链接(一次性)
+type TestimonialInvite struct {
+	ID           int        `json:"id"`
+	Token        string     `json:"token"`
+	Recipient    string     `json:"recipient"`
+	Note         string     `json:"note"`
+	ExperienceID *int       `json:"experience_id"`
+	ProjectID    *int       `json:"project_id"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Testimonial 推荐信模型
+type Testimonial struct {
+	ID           int        `json:"id"`
+	InviteID     *int       `json:"invite_id,omitempty"`
+	AuthorName   string     `json:"author_name"`
+	AuthorRole   string     `json:"author_role"`
+	Relationship string     `json:"relationship"`
+	Content      string     `json:"content"`
+	Status       string     `json:"status"` // "pending", "approved", "rejected"
+	ExperienceID *int       `json:"experience_id"`
+	ProjectID    *int       `json:"project_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// TestimonialSubmission 推荐人提交的推荐信内容
+type TestimonialSubmission struct {
+	AuthorName   string `json:"author_name" binding:"required"`
+	AuthorRole   string `json:"author_role"`
+	Relationship string `json:"relationship" binding:"required"`
+	Content      string `json:"content" binding:"required"`
+}
+
+// ContactRequest 联系表单提交请求
+type ContactRequest struct {
+	Name    string `json:"name" binding:"required,max=100"`
+	Email   string `json:"email" binding:"required,email,max=200"`
+	Subject string `json:"subject" binding:"required,max=200"`
+	Body    string `json:"body" binding:"required,max=5000"`
+	Website string `json:"website"` // 蜜罐字段，正常用户不会填写
+}
+
+// Message 联系留言模型
+type Message struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	IsRead    bool      `json:"is_read"`
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MailQueueItem 邮件发送队列记录
+type MailQueueItem struct {
+	ID            int        `json:"id"`
+	Template      string     `json:"template"`
+	ToAddr        string     `json:"to"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"` // "pending", "sent", "failed"
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// VisitorKeyStats 按访客密码统计的访问数据
+type VisitorKeyStats struct {
+	AccessKey string    `json:"access_key"`
+	Password  string    `json:"password,omitempty"`
+	Views     int       `json:"views"`
+	Sessions  int       `json:"sessions"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ProjectViewStats 按项目统计的浏览次数
+type ProjectViewStats struct {
+	ProjectID int    `json:"project_id"`
+	Title     string `json:"title"`
+	Views     int    `json:"views"`
+	Sessions  int    `json:"sessions"`
+}
+
+// DailyVisitStats 按天统计的访问数据
+type DailyVisitStats struct {
+	Day      string `json:"day"`
+	Views    int    `json:"views"`
+	Sessions int    `json:"sessions"`
+}
+
+// VisitSession 单次访客会话(一次验证对应一个会话)
+type VisitSession struct {
+	SessionID     string    `json:"session_id"`
+	AccessKey     string    `json:"access_key"`
+	VerifiedAt    time.Time `json:"verified_at"`
+	LastActivity  time.Time `json:"last_activity"`
+	ActiveSeconds int64     `json:"active_seconds"` // 从验证到最后一次活动的时间
+	Views         int       `json:"views"`
+	UserAgent     string    `json:"user_agent"`
+	IP            string    `json:"ip"`
+}
+
+// VisitAnalytics 访客统计汇总
+type VisitAnalytics struct {
+	TotalViews     int                `json:"total_views"`
+	UniqueSessions int                `json:"unique_sessions"`
+	ByAccessKey    []VisitorKeyStats  `json:"by_access_key"`
+	ByProject      []ProjectViewStats `json:"by_project"`
+	ByDay          []DailyVisitStats  `json:"by_day"`
+}
+
+// Webhook 外部回调地址
+type Webhook struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url" binding:"required,url"`
+	Secret      string    `json:"secret"`
+	Events      []string  `json:"events" binding:"required,min=1"` // 订阅的事件，如 project.created、project.*、*
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookDelivery 回调投递记录
+type WebhookDelivery struct {
+	ID             int        `json:"id"`
+	WebhookID      int        `json:"webhook_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"` // "pending", "success", "failed"
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// SearchHit 一条全文搜索命中结果，Title和Snippet为带<mark>高亮的HTML
+type SearchHit struct {
+	Type    string  `json:"type"`
+	ID      int     `json:"id"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+// SearchResults 全文搜索结果，按实体类型分组，组内按相关度排序
+type SearchResults struct {
+	Query        string      `json:"query"`
+	Total        int         `json:"total"`
+	Projects     []SearchHit `json:"projects"`
+	Experiences  []SearchHit `json:"experiences"`
+	Skills       []SearchHit `json:"skills"`
+	Certificates []SearchHit `json:"certificates"`
+}
+
+// Technology 规范化的技术/标签
+type Technology struct {
+	ID          int      `json:"id"`
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name" binding:"required"`
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Aliases     []string `json:"aliases"`
+	UsageCount  int      `json:"usage_count"`
+}
+
+// TechnologySkillRef 使用某项技术的技能
+type TechnologySkillRef struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+// TechnologyExperienceRef 使用某项技术的工作经历
+type TechnologyExperienceRef struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Company string `json:"company"`
+	Period  string `json:"period"`
+}
+
+// TechnologyProjectRef 使用某项技术的项目
+type TechnologyProjectRef struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+}
+
+// TechnologyUsage 某项技术在各处的使用情况
+type TechnologyUsage struct {
+	Technology
+	Skills      []TechnologySkillRef      `json:"skills"`
+	Experiences []TechnologyExperienceRef `json:"experiences"`
+	Projects    []TechnologyProjectRef    `json:"projects"`
+}
+
+// SkillEvidence 根据工作经历和项目推算的技能依据
+type SkillEvidence struct {
+	Technologies   []string                  `json:"technologies"`   // 用于匹配的技术
+	Experiences    []TechnologyExperienceRef `json:"experiences"`    // 使用过该技能的工作经历
+	Projects       []TechnologyProjectRef    `json:"projects"`       // 使用过该技能的项目
+	YearsOfUse     float64                   `json:"years_of_use"`   // 根据工作经历时间段合并计算的使用年限
+	LastUsed       string                    `json:"last_used"`      // 最近使用的年月(YYYY-MM)，无法推算时为空
+	CurrentlyUsed  bool                      `json:"currently_used"` // 当前工作中仍在使用
+	SuggestedLevel *int                      `json:"suggested_level,omitempty"`
+}
+
+// JobMatchRequest 职位描述匹配请求
+type JobMatchRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// JobKeyword 职位描述中识别出的关键词
+type JobKeyword struct {
+	Keyword     string   `json:"keyword"`
+	Technology  string   `json:"technology,omitempty"` // 对应的规范技术名称，未登记的关键词为空
+	Occurrences int      `json:"occurrences"`
+	Sources     []string `json:"sources"` // 在作品集中出现的位置：skill、experience、project、text
+}
+
+// JobMatchSuggestion 建议重点展示的项目或工作经历
+type JobMatchSuggestion struct {
+	ID       int      `json:"id"`
+	Title    string   `json:"title"`
+	Score    float64  `json:"score"`
+	Keywords []string `json:"keywords"`
+}
+
+// JobMatchResult 职位描述与作品集的匹配结果
+type JobMatchResult struct {
+	Score       int                  `json:"score"` // 覆盖率(0-100)，按关键词出现次数加权
+	Matched     []JobKeyword         `json:"matched"`
+	Missing     []JobKeyword         `json:"missing"`
+	Skills      []TechnologySkillRef `json:"skills"`
+	Projects    []JobMatchSuggestion `json:"projects"`
+	Experiences []JobMatchSuggestion `json:"experiences"`
+}
+
+// Variant 简历版本：选取并排序部分项目、工作经历、技能和证书，并可覆盖个人信息中的职位和简介。
+// 某类内容的ID列表为空时展示该类的全部内容
+type Variant struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name" binding:"required"`
+	Description    string    `json:"description"`
+	Title          string    `json:"title"`        // 覆盖个人信息中的职位，为空时不覆盖
+	Introduction   string    `json:"introduction"` // 覆盖个人信息中的简介，为空时不覆盖
+	ProjectIDs     []int     `json:"project_ids"`
+	ExperienceIDs  []int     `json:"experience_ids"`
+	SkillIDs       []int     `json:"skill_ids"`
+	CertificateIDs []int     `json:"certificate_ids"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ApplicationContact 求职申请的联系人
+type ApplicationContact struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"` // 如招聘专员、面试官
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// ApplicationStage 求职申请的一次阶段变更
+type ApplicationStage struct {
+	ID        int       `json:"id"`
+	FromStage string    `json:"from_stage"`
+	Stage     string    `json:"stage"`
+	Note      string    `json:"note"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Application 求职申请记录，可关联为该公司发放的访客密码或分享链接
+type Application struct {
+	ID              int                  `json:"id"`
+	Company         string               `json:"company" binding:"required"`
+	Role            string               `json:"role" binding:"required"`
+	Stage           string               `json:"stage"` // wishlist/applied/screening/interview/offer/accepted/rejected/withdrawn
+	JobURL          string               `json:"job_url"`
+	AppliedAt       string               `json:"applied_at"` // YYYY-MM-DD
+	Contacts        []ApplicationContact `json:"contacts"`
+	Notes           string               `json:"notes"`
+	VisitorAccessID *int                 `json:"visitor_access_id"`
+	AccessKey       string               `json:"access_key"`  // 关联访客的标识，只读
+	AccessType      string               `json:"access_type"` // password或link，只读
+	Views           int                  `json:"views"`       // 关联访客的累计浏览次数，只读
+	LastViewedAt    *time.Time           `json:"last_viewed_at"`
+	Timeline        []ApplicationStage   `json:"timeline,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// ApplicationAnalytics 求职申请关联访客的访问统计
+type ApplicationAnalytics struct {
+	ApplicationID int            `json:"application_id"`
+	Company       string         `json:"company"`
+	AccessKey     string         `json:"access_key"`
+	Summary       VisitAnalytics `json:"summary"`
+	Sessions      []VisitSession `json:"sessions"`
+}
+
+// LocaleSettings 多语言配置
+type LocaleSettings struct {
+	Base      string   `json:"base"`     // 原文的语言
+	Fallback  string   `json:"fallback"` // 缺少翻译时使用的语言
+	Supported []string `json:"supported"`
+}
+
+// TranslationField 单个字段的原文和翻译，列表字段的值为字符串数组
+type TranslationField struct {
+	Field  string      `json:"field"`
+	List   bool        `json:"list"`
+	Source interface{} `json:"source"`
+	Value  interface{} `json:"value"` // 未翻译时为null
+}
+
+// EntityTranslations 单项内容在某种语言下的翻译
+type EntityTranslations struct {
+	EntityType string             `json:"entity_type"`
+	EntityID   int                `json:"entity_id"`
+	Locale     string             `json:"locale"`
+	Fields     []TranslationField `json:"fields"`
+	UpdatedAt  *time.Time         `json:"updated_at"`
+}
+
+// MissingTranslation 缺少翻译的内容及字段
+type MissingTranslation struct {
+	EntityType string   `json:"entity_type"`
+	EntityID   int      `json:"entity_id"`
+	Label      string   `json:"label"`
+	Fields     []string `json:"fields"`
+}
+
+// TranslationReport 某种语言的翻译完成情况
+type TranslationReport struct {
+	Locale     string               `json:"locale"`
+	Total      int                  `json:"total"`      // 原文不为空的字段数
+	Translated int                  `json:"translated"` // 已翻译的字段数
+	Missing    []MissingTranslation `json:"missing"`
+}
+
+// Tenant 多租户部署中的一个作品集，使用独立的数据目录和后端进程
+type Tenant struct {
+	ID          int        `json:"id"`
+	Slug        string     `json:"slug" binding:"required"` // 用于路径前缀 /t/<slug>/ 和子域名
+	Name        string     `json:"name"`
+	Hosts       []string   `json:"hosts"`  // 绑定的域名
+	Status      string     `json:"status"` // active/suspended
+	Port        int        `json:"port"`
+	Running     bool       `json:"running"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+}
+
+// TenantCredentials 创建租户时生成的初始密码，只返回一次
+type TenantCredentials struct {
+	Tenant          Tenant `json:"tenant"`
+	AdminUsername   string `json:"admin_username"`
+	AdminPassword   string `json:"admin_password"`
+	VisitorPassword string `json:"visitor_password"`
+}
+
+// Portfolio 作品集全部公开内容的快照，供静态站点等导出使用
+type Portfolio struct {
+	Locale          string          `json:"locale"`
+	VariantID       int             `json:"variant_id,omitempty"`
+	Profile         Profile         `json:"profile"`
+	SkillCategories []SkillCategory `json:"skill_categories"`
+	Experiences     []Experience    `json:"experiences"`
+	Projects        []Project       `json:"projects"`
+	Certificates    []Certificate   `json:"certificates"`
+}
+
+// StaticExport 一次静态站点导出的结果
+type StaticExport struct {
+	Output      string    `json:"output"`
+	Format      string    `json:"format"` // dir/zip
+	ContentHash string    `json:"content_hash"`
+	Files       int       `json:"files"`
+	Written     []string  `json:"written"` // 本次新写入或更新的文件
+	Removed     []string  `json:"removed"` // 内容已不存在而删除的文件
+	Unchanged   int       `json:"unchanged"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Error       string    `json:"error,omitempty"` // 自动生成失败时的错误
+}
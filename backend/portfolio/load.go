@@ -0,0 +1,349 @@
+package portfolio
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"backend/database"
+	"backend/i18n"
+	"backend/markdown"
+	"backend/models"
+)
+
+// Options 读取作品集快照的选项
+type Options struct {
+	Locale         string // 内容语言，为空时使用原文语言
+	VariantID      int    // 大于0时只包含该简历版本选中的内容，并按版本顺序排列
+	IncludeContact bool   // 是否包含邮箱和电话
+}
+
+// Load 读取作品集快照，内容经过翻译、简历版本筛选和Markdown渲染，与访客看到的一致
+func Load(opts Options) (models.Portfolio, error) {
+	locale := opts.Locale
+	if locale == "" || !i18n.Supported(locale) {
+		locale = i18n.Settings().Base
+	}
+	result := models.Portfolio{
+		Locale:          locale,
+		SkillCategories: []models.SkillCategory{},
+		Experiences:     []models.Experience{},
+		Projects:        []models.Project{},
+		Certificates:    []models.Certificate{},
+	}
+
+	var variant *Variant
+	if opts.VariantID > 0 {
+		v, err := LoadVariant(opts.VariantID)
+		if err != nil {
+			return result, err
+		}
+		variant = v
+		result.VariantID = v.ID
+	}
+
+	var err error
+	if result.Profile, err = loadProfile(locale, variant); err != nil {
+		return result, err
+	}
+	if !opts.IncludeContact {
+		result.Profile.Email = ""
+		result.Profile.Phone = ""
+	}
+	if result.SkillCategories, err = loadSkills(locale, variant); err != nil {
+		return result, err
+	}
+	if result.Experiences, err = loadExperiences(locale, variant); err != nil {
+		return result, err
+	}
+	if result.Projects, err = loadProjects(locale, variant); err != nil {
+		return result, err
+	}
+	if result.Certificates, err = loadCertificates(locale, variant); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// LoadProfile 只读取个人信息，选项与Load相同
+func LoadProfile(opts Options) (models.Profile, error) {
+	locale := opts.Locale
+	if locale == "" || !i18n.Supported(locale) {
+		locale = i18n.Settings().Base
+	}
+	var variant *Variant
+	if opts.VariantID > 0 {
+		v, err := LoadVariant(opts.VariantID)
+		if err != nil {
+			return models.Profile{}, err
+		}
+		variant = v
+	}
+
+	profile, err := loadProfile(locale, variant)
+	if err == nil && !opts.IncludeContact {
+		profile.Email = ""
+		profile.Phone = ""
+	}
+	return profile, err
+}
+
+func loadProfile(locale string, variant *Variant) (models.Profile, error) {
+	var profile models.Profile
+	var avatar, email, phone, location, introduction, education, jobStatus, philosophy, resumeFileURL sql.NullString
+	var yearsOfExp sql.NullInt64
+	var lastUpdated sql.NullTime
+	err := database.DB.QueryRow(`
+		SELECT id, name, title, avatar, email, phone, location, introduction,
+		years_of_exp, education, job_status, philosophy, last_updated, resume_file_url
+		FROM profile LIMIT 1`).Scan(
+		&profile.ID, &profile.Name, &profile.Title, &avatar, &email, &phone, &location, &introduction,
+		&yearsOfExp, &education, &jobStatus, &philosophy, &lastUpdated, &resumeFileURL)
+	if err == sql.ErrNoRows {
+		return profile, nil
+	}
+	if err != nil {
+		return profile, err
+	}
+	profile.Avatar, profile.Email, profile.Phone = avatar.String, email.String, phone.String
+	profile.Location, profile.Introduction = location.String, introduction.String
+	profile.YearsOfExp = int(yearsOfExp.Int64)
+	profile.Education, profile.JobStatus, profile.Philosophy = education.String, jobStatus.String, philosophy.String
+	profile.LastUpdated = lastUpdated.Time
+	profile.ResumeFileURL = resumeFileURL.String
+
+	translations, err := translationsFor(i18n.Profile, locale)
+	if err != nil {
+		return profile, err
+	}
+	i18n.TranslateProfile(&profile, translations[profile.ID])
+
+	// 简历版本覆盖职位和简介
+	if variant != nil {
+		title, intro := variant.Title, variant.Introduction
+		values, err := translationsFor(i18n.Variant, locale)
+		if err != nil {
+			return profile, err
+		}
+		i18n.TranslateString(&title, values[variant.ID], "title")
+		i18n.TranslateString(&intro, values[variant.ID], "introduction")
+		if title != "" {
+			profile.Title = title
+		}
+		if intro != "" {
+			profile.Introduction = intro
+		}
+	}
+	markdown.RenderProfile(&profile)
+	return profile, nil
+}
+
+func loadSkills(locale string, variant *Variant) ([]models.SkillCategory, error) {
+	categoryTranslations, err := translationsFor(i18n.SkillCategory, locale)
+	if err != nil {
+		return nil, err
+	}
+	skillTranslations, err := translationsFor(i18n.Skill, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query("SELECT id, name, COALESCE(description, ''), COALESCE(icon, '') FROM skill_categories ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	var categories []models.SkillCategory
+	for rows.Next() {
+		var category models.SkillCategory
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.Icon); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i18n.TranslateSkillCategory(&category, categoryTranslations[category.ID])
+		categories = append(categories, category)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := []models.SkillCategory{}
+	for _, category := range categories {
+		rows, err := database.DB.Query(`
+			SELECT id, category_id, name, COALESCE(level, 0), COALESCE(description, ''), COALESCE(tags, '[]')
+			FROM skills WHERE category_id = ? ORDER BY id`, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		category.Skills = []models.Skill{}
+		for rows.Next() {
+			var skill models.Skill
+			var tagsJSON string
+			if err := rows.Scan(&skill.ID, &skill.CategoryID, &skill.Name, &skill.Level, &skill.Description, &tagsJSON); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if !variant.Allows(ItemSkill, skill.ID) {
+				continue
+			}
+			skill.Tags = decodeList(tagsJSON)
+			i18n.TranslateSkill(&skill, skillTranslations[skill.ID])
+			markdown.RenderSkill(&skill)
+			category.Skills = append(category.Skills, skill)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		// 简历版本选择了技能时不输出空分类
+		if variant.Selects(ItemSkill) && len(category.Skills) == 0 {
+			continue
+		}
+		sort.SliceStable(category.Skills, func(i, j int) bool {
+			return variant.Less(ItemSkill, category.Skills[i].ID, category.Skills[j].ID)
+		})
+		result = append(result, category)
+	}
+	return result, nil
+}
+
+func loadExperiences(locale string, variant *Variant) ([]models.Experience, error) {
+	translations, err := translationsFor(i18n.Experience, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, period, title, company, COALESCE(location, ''), COALESCE(color, ''), COALESCE(icon, ''),
+		COALESCE(responsibilities, '[]'), COALESCE(achievements, '[]'), COALESCE(technologies, '[]'), COALESCE(sort_order, 0)
+		FROM experiences ORDER BY sort_order, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Experience{}
+	for rows.Next() {
+		var exp models.Experience
+		var responsibilities, achievements, technologies string
+		if err := rows.Scan(&exp.ID, &exp.Period, &exp.Title, &exp.Company, &exp.Location, &exp.Color, &exp.Icon,
+			&responsibilities, &achievements, &technologies, &exp.SortOrder); err != nil {
+			return nil, err
+		}
+		if !variant.Allows(ItemExperience, exp.ID) {
+			continue
+		}
+		exp.Responsibilities = decodeList(responsibilities)
+		exp.Achievements = decodeList(achievements)
+		exp.Technologies = decodeList(technologies)
+		i18n.TranslateExperience(&exp, translations[exp.ID])
+		markdown.RenderExperience(&exp)
+		result = append(result, exp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return variant.Less(ItemExperience, result[i].ID, result[j].ID)
+	})
+	return result, nil
+}
+
+func loadProjects(locale string, variant *Variant) ([]models.Project, error) {
+	translations, err := translationsFor(i18n.Project, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, title, COALESCE(category, ''), COALESCE(description, ''), COALESCE(image, ''),
+		COALESCE(demo_link, ''), COALESCE(repo_link, ''), COALESCE(show_architecture, 0),
+		COALESCE(metrics, '[]'), COALESCE(key_points, '[]'), COALESCE(tech_stack, '[]'), COALESCE(sort_order, 0)
+		FROM projects ORDER BY sort_order, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		var metrics, keyPoints, techStack string
+		if err := rows.Scan(&p.ID, &p.Title, &p.Category, &p.Description, &p.Image, &p.DemoLink, &p.RepoLink,
+			&p.ShowArchitecture, &metrics, &keyPoints, &techStack, &p.SortOrder); err != nil {
+			return nil, err
+		}
+		if !variant.Allows(ItemProject, p.ID) {
+			continue
+		}
+		p.Metrics = []models.Metric{}
+		json.Unmarshal([]byte(metrics), &p.Metrics)
+		p.KeyPoints = decodeList(keyPoints)
+		p.TechStack = decodeList(techStack)
+		i18n.TranslateProject(&p, translations[p.ID])
+		markdown.RenderProject(&p)
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return variant.Less(ItemProject, result[i].ID, result[j].ID)
+	})
+	return result, nil
+}
+
+func loadCertificates(locale string, variant *Variant) ([]models.Certificate, error) {
+	translations, err := translationsFor(i18n.Certificate, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, name, COALESCE(organization, ''), COALESCE(date, ''), COALESCE(description, ''),
+		COALESCE(icon, ''), COALESCE(link, ''), COALESCE(expiry_date, ''), COALESCE(sort_order, 0)
+		FROM certificates ORDER BY sort_order, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Certificate{}
+	for rows.Next() {
+		var cert models.Certificate
+		if err := rows.Scan(&cert.ID, &cert.Name, &cert.Organization, &cert.Date, &cert.Description,
+			&cert.Icon, &cert.Link, &cert.ExpiryDate, &cert.SortOrder); err != nil {
+			return nil, err
+		}
+		if !variant.Allows(ItemCertificate, cert.ID) {
+			continue
+		}
+		i18n.TranslateCertificate(&cert, translations[cert.ID])
+		result = append(result, cert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return variant.Less(ItemCertificate, result[i].ID, result[j].ID)
+	})
+	return result, nil
+}
+
+// 原文语言不需要读取翻译
+func translationsFor(entityType, locale string) (map[int]map[string]string, error) {
+	if locale == i18n.Settings().Base {
+		return nil, nil
+	}
+	return i18n.Translations(entityType, locale)
+}
+
+func decodeList(raw string) []string {
+	list := []string{}
+	json.Unmarshal([]byte(raw), &list)
+	return list
+}
@@ -0,0 +1,77 @@
+package portfolio
+
+import (
+	"database/sql"
+
+	"backend/database"
+)
+
+// 简历版本中的内容类型
+const (
+	ItemProject     = "project"
+	ItemExperience  = "experience"
+	ItemSkill       = "skill"
+	ItemCertificate = "certificate"
+)
+
+// Variant 简历版本的展示范围，记录每类内容选中的ID及展示顺序。
+// 方法可在nil上调用，nil表示不限制范围
+type Variant struct {
+	ID           int
+	Title        string
+	Introduction string
+	positions    map[string]map[int]int
+}
+
+// LoadVariant 从数据库读取简历版本，sql.ErrNoRows表示版本不存在
+func LoadVariant(id int) (*Variant, error) {
+	v := &Variant{ID: id, positions: map[string]map[int]int{}}
+	var title, introduction sql.NullString
+	err := database.DB.QueryRow("SELECT title, introduction FROM variants WHERE id = ?", id).Scan(&title, &introduction)
+	if err != nil {
+		return nil, err
+	}
+	v.Title = title.String
+	v.Introduction = introduction.String
+
+	rows, err := database.DB.Query("SELECT item_type, item_id, position FROM variant_items WHERE variant_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemType string
+		var itemID, position int
+		if err := rows.Scan(&itemType, &itemID, &position); err != nil {
+			return nil, err
+		}
+		if v.positions[itemType] == nil {
+			v.positions[itemType] = map[int]int{}
+		}
+		v.positions[itemType][itemID] = position
+	}
+	return v, rows.Err()
+}
+
+// Selects 判断版本是否选择了该类内容
+func (v *Variant) Selects(itemType string) bool {
+	return v != nil && len(v.positions[itemType]) > 0
+}
+
+// Allows 判断内容是否在版本中，版本未选择该类内容时全部可见
+func (v *Variant) Allows(itemType string, id int) bool {
+	if !v.Selects(itemType) {
+		return true
+	}
+	_, ok := v.positions[itemType][id]
+	return ok
+}
+
+// Less 按版本中的顺序比较两项内容，未选择该类内容时保持原顺序
+func (v *Variant) Less(itemType string, a, b int) bool {
+	if v == nil {
+		return false
+	}
+	return v.positions[itemType][a] < v.positions[itemType][b]
+}
@@ -0,0 +1,171 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// ISO/IEC 18004 附录中M级纠错的格式信息(已与0x5412异或)，依次为掩码0-7
+var formatTable = []int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+
+func TestFormatBits(t *testing.T) {
+	for mask, want := range formatTable {
+		if got := formatBits(mask); got != want {
+			t.Errorf("formatBits(%d) = %#x, 期望 %#x", mask, got, want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	for version, want := range map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3, 21: 0x15683, 40: 0x28C69} {
+		if got := versionBits(version); got != want {
+			t.Errorf("versionBits(%d) = %#x, 期望 %#x", version, got, want)
+		}
+	}
+}
+
+func TestRSRemainder(t *testing.T) {
+	tests := []struct {
+		name, data, ecc string
+	}{
+		// ISO/IEC 18004 附录I中 "01234567" 的1-M符号
+		{"01234567", "10200c566180ec11ec11ec11ec11ec11", "a524d4c1ed36c7872c55"},
+		// "HELLO WORLD" 的1-M符号
+		{"HELLO WORLD", "205b0b78d172dc4d4340ec11ec11ec11", "c4232777ebd7e7e25d17"},
+	}
+	for _, tt := range tests {
+		data, err := hex.DecodeString(tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(rsRemainder(data, rsDivisor(10))); got != tt.ecc {
+			t.Errorf("%s 的纠错码 = %s, 期望 %s", tt.name, got, tt.ecc)
+		}
+	}
+}
+
+func TestEncodeVersionBoundaries(t *testing.T) {
+	// M级字节模式的容量：版本1为14字节，6为106，9为180(版本10起字符计数占16位)，40为2331
+	tests := []struct {
+		length, size int
+	}{
+		{14, 21}, {15, 25},
+		{106, 41}, {107, 45},
+		{180, 53}, {181, 57},
+		{2331, 177},
+	}
+	for _, tt := range tests {
+		code, err := Encode(bytes.Repeat([]byte("a"), tt.length))
+		if err != nil {
+			t.Errorf("编码%d字节失败: %v", tt.length, err)
+			continue
+		}
+		if code.Size != tt.size {
+			t.Errorf("%d字节的符号边长 = %d, 期望 %d", tt.length, code.Size, tt.size)
+		}
+	}
+	if _, err := Encode(bytes.Repeat([]byte("a"), 2332)); err != ErrTooLong {
+		t.Errorf("编码2332字节应返回ErrTooLong, 实际: %v", err)
+	}
+}
+
+// 从符号中读回两份格式信息和版本信息，检查与计算值一致
+func TestEncodeFunctionPatterns(t *testing.T) {
+	for _, data := range []string{"https://example.com/", strings.Repeat("x", 120)} {
+		code, err := Encode([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := code.Size
+
+		read := func(coords [][2]int) int {
+			v := 0
+			for i, xy := range coords {
+				if code.Black(xy[0], xy[1]) {
+					v |= 1 << i
+				}
+			}
+			return v
+		}
+		var first, second [][2]int
+		for i := 0; i <= 5; i++ {
+			first = append(first, [2]int{8, i})
+		}
+		first = append(first, [2]int{8, 7}, [2]int{8, 8}, [2]int{7, 8})
+		for i := 9; i < 15; i++ {
+			first = append(first, [2]int{14 - i, 8})
+		}
+		for i := 0; i < 8; i++ {
+			second = append(second, [2]int{n - 1 - i, 8})
+		}
+		for i := 8; i < 15; i++ {
+			second = append(second, [2]int{8, n - 15 + i})
+		}
+		format := read(first)
+		if read(second) != format {
+			t.Errorf("边长%d: 两份格式信息不一致: %#x %#x", n, format, read(second))
+		}
+		valid := false
+		for _, f := range formatTable {
+			valid = valid || f == format
+		}
+		if !valid {
+			t.Errorf("边长%d: 格式信息 %#x 不是M级的有效值", n, format)
+		}
+		if !code.Black(8, n-8) {
+			t.Errorf("边长%d: 缺少固定的深色模块", n)
+		}
+
+		// 定位图案：外圈深色、内圈浅色、中心3x3深色
+		for _, corner := range [][2]int{{0, 0}, {n - 7, 0}, {0, n - 7}} {
+			for dy := 0; dy < 7; dy++ {
+				for dx := 0; dx < 7; dx++ {
+					ring := max(abs(dx-3), abs(dy-3))
+					if want := ring != 2; code.Black(corner[0]+dx, corner[1]+dy) != want {
+						t.Fatalf("边长%d: 定位图案(%d,%d)处模块不正确", n, corner[0]+dx, corner[1]+dy)
+					}
+				}
+			}
+		}
+
+		version := (n - 17) / 4
+		if version >= 7 {
+			var upper, lower [][2]int
+			for i := 0; i < 18; i++ {
+				upper = append(upper, [2]int{n - 11 + i%3, i / 3})
+				lower = append(lower, [2]int{i / 3, n - 11 + i%3})
+			}
+			if got := read(upper); got != versionBits(version) || read(lower) != got {
+				t.Errorf("版本%d的版本信息 = %#x/%#x, 期望 %#x", version, got, read(lower), versionBits(version))
+			}
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	code, err := Encode([]byte("https://example.com/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := code.PNG(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 四周各4个模块的空白
+	if want := (code.Size + 8) * 3; img.Bounds().Dx() != want || img.Bounds().Dy() != want {
+		t.Errorf("图片尺寸 = %v, 期望 %dx%d", img.Bounds(), want, want)
+	}
+	if r, _, _, _ := img.At(4*3, 4*3).RGBA(); r != 0 {
+		t.Error("左上角定位图案应为深色")
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("空白区应为浅色")
+	}
+}
@@ -0,0 +1,22 @@
+// Package retry 计算后台队列(邮件、Webhook)失败后重试的等待时间
+package retry
+
+import "time"
+
+// Backoff 指数退避策略：第一次失败后等待Base，之后每次翻倍，不超过Max
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay 返回第attempts次失败后的等待时间
+func (b Backoff) Delay(attempts int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= b.Max {
+			return b.Max
+		}
+	}
+	return d
+}
@@ -0,0 +1,335 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"backend/database"
+	"backend/events"
+	"backend/markdown"
+)
+
+// 可搜索的实体类型
+const (
+	TypeProject     = "project"
+	TypeExperience  = "experience"
+	TypeSkill       = "skill"
+	TypeCertificate = "certificate"
+)
+
+// 高亮标记在SQL中使用的占位字符，转义后再替换为<mark>标签
+const (
+	markStart = "\x01"
+	markEnd   = "\x02"
+)
+
+// 当前是否使用FTS5(需要以sqlite_fts5构建标签编译)，否则退回FTS4
+var useFTS5 bool
+
+// 索引结构版本，修改索引字段或分词方式时递增，启动时只在版本或FTS模块变化后重建索引
+const indexVersion = 1
+
+// Setup 创建全文索引表，索引结构变化时重建索引
+func Setup() error {
+	useFTS5 = fts5Available()
+	module := "fts4"
+	if useFTS5 {
+		module = "fts5"
+	} else {
+		log.Printf("当前SQLite未启用FTS5，全文搜索退回使用FTS4，使用 -tags sqlite_fts5 构建可获得更好的排序效果")
+	}
+
+	// 索引随内容变更事件增量更新，结构未变时沿用已有索引
+	migrationName := fmt.Sprintf("search_index_v%d_%s", indexVersion, module)
+	var existing string
+	err := database.DB.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'search_index'").Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	applied, err := database.MigrationApplied(migrationName)
+	if err != nil {
+		return err
+	}
+	if applied && strings.Contains(strings.ToLower(existing), "using "+module) {
+		return nil
+	}
+
+	if _, err := database.DB.Exec("DROP TABLE IF EXISTS search_index"); err != nil {
+		return err
+	}
+	if useFTS5 {
+		_, err = database.DB.Exec(`
+		CREATE VIRTUAL TABLE search_index USING fts5(
+			entity_type UNINDEXED,
+			entity_id UNINDEXED,
+			title,
+			body,
+			tags
+		)`)
+	} else {
+		_, err = database.DB.Exec(`
+		CREATE VIRTUAL TABLE search_index USING fts4(
+			entity_type,
+			entity_id,
+			title,
+			body,
+			tags,
+			notindexed=entity_type,
+			notindexed=entity_id
+		)`)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := Rebuild(); err != nil {
+		return err
+	}
+	return database.MarkMigrationApplied(migrationName)
+}
+
+// 用临时表探测当前SQLite是否支持FTS5，临时表只对所在连接可见，建表和删除需使用同一连接
+func fts5Available() bool {
+	ctx := context.Background()
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "CREATE VIRTUAL TABLE temp.fts5_probe USING fts5(x)"); err != nil {
+		return false
+	}
+	conn.ExecContext(ctx, "DROP TABLE temp.fts5_probe")
+	return true
+}
+
+// Start 订阅内容变更事件，保持索引与数据表同步
+func Start() {
+	events.Watch(64, func(e events.Event) bool {
+		return entityTypeOf(e.Type) != ""
+	}, handleEvent)
+}
+
+// 根据事件类型(如project.updated)得到实体类型
+func entityTypeOf(eventType string) string {
+	prefix := strings.SplitN(eventType, ".", 2)[0]
+	switch prefix {
+	case TypeProject, TypeExperience, TypeSkill, TypeCertificate:
+		return prefix
+	}
+	return ""
+}
+
+// 处理单个内容变更事件
+func handleEvent(e events.Event) {
+	entityType := entityTypeOf(e.Type)
+
+	id := events.EntityID(e)
+	if id == 0 {
+		return
+	}
+
+	var err error
+	if strings.HasSuffix(e.Type, ".deleted") {
+		err = Remove(entityType, id)
+	} else {
+		err = Index(entityType, id)
+	}
+	if err != nil {
+		log.Printf("更新搜索索引失败(%s %d): %v", entityType, id, err)
+	}
+}
+
+// 一条待索引的文档
+type document struct {
+	entityType string
+	entityID   int
+	title      string
+	body       []string
+	tags       []string
+}
+
+// Rebuild 清空并重建全部索引
+func Rebuild() error {
+	if _, err := database.DB.Exec("DELETE FROM search_index"); err != nil {
+		return err
+	}
+
+	count := 0
+	for _, entityType := range []string{TypeProject, TypeExperience, TypeSkill, TypeCertificate} {
+		docs, err := loadDocuments(entityType, 0)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if err := insertDocument(doc); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	log.Printf("搜索索引重建完成，共 %d 条记录", count)
+	return nil
+}
+
+// Index 重新索引单个实体
+func Index(entityType string, id int) error {
+	if err := Remove(entityType, id); err != nil {
+		return err
+	}
+	docs, err := loadDocuments(entityType, id)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := insertDocument(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove 从索引中删除单个实体
+func Remove(entityType string, id int) error {
+	_, err := database.DB.Exec("DELETE FROM search_index WHERE entity_type = ? AND entity_id = ?", entityType, id)
+	return err
+}
+
+// 写入一条文档，文本先做中文分字处理
+func insertDocument(doc document) error {
+	_, err := database.DB.Exec(
+		"INSERT INTO search_index (entity_type, entity_id, title, body, tags) VALUES (?, ?, ?, ?, ?)",
+		doc.entityType, doc.entityID, segment(doc.title),
+		segment(strings.Join(doc.body, "\n")), segment(strings.Join(doc.tags, " ")))
+	return err
+}
+
+// 从数据表读取需要索引的文档，id为0时读取该类型的全部记录
+func loadDocuments(entityType string, id int) ([]document, error) {
+	var query string
+	switch entityType {
+	case TypeProject:
+		query = `SELECT id, title, COALESCE(category, ''), COALESCE(description, ''),
+			COALESCE(key_points, ''), COALESCE(metrics, ''), COALESCE(tech_stack, '') FROM projects`
+	case TypeExperience:
+		query = `SELECT id, title, company, COALESCE(location, '') || ' ' || period,
+			COALESCE(responsibilities, ''), COALESCE(achievements, ''), COALESCE(technologies, '') FROM experiences`
+	case TypeSkill:
+		query = `SELECT s.id, s.name, COALESCE(c.name, ''), COALESCE(s.description, ''), '', '', COALESCE(s.tags, '')
+			FROM skills s LEFT JOIN skill_categories c ON c.id = s.category_id`
+	case TypeCertificate:
+		query = `SELECT id, name, COALESCE(organization, ''), COALESCE(description, ''), '', '', '' FROM certificates`
+	default:
+		return nil, nil
+	}
+
+	var args []interface{}
+	if id > 0 {
+		if entityType == TypeSkill {
+			query += " WHERE s.id = ?"
+		} else {
+			query += " WHERE id = ?"
+		}
+		args = append(args, id)
+	}
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []document
+	for rows.Next() {
+		var doc document
+		var f1, f2, f3, f4, tagsJSON sql.NullString
+		if err := rows.Scan(&doc.entityID, &doc.title, &f1, &f2, &f3, &f4, &tagsJSON); err != nil {
+			return nil, err
+		}
+		doc.entityType = entityType
+
+		switch entityType {
+		case TypeProject:
+			// f2为Markdown格式的描述，f3为关键点JSON数组，f4为成果指标JSON数组
+			doc.body = append([]string{f1.String, markdown.Text(f2.String)}, decodeStrings(f3.String)...)
+			var metrics []struct {
+				Value string `json:"value"`
+				Label string `json:"label"`
+			}
+			json.Unmarshal([]byte(f4.String), &metrics)
+			for _, m := range metrics {
+				doc.body = append(doc.body, m.Value+" "+m.Label)
+			}
+		case TypeExperience:
+			// 职位和公司都作为标题的一部分
+			doc.title += " " + f1.String
+			// 工作职责和主要成就为Markdown，只索引文字
+			doc.body = []string{f2.String}
+			for _, item := range append(decodeStrings(f3.String), decodeStrings(f4.String)...) {
+				doc.body = append(doc.body, markdown.Text(item))
+			}
+		case TypeSkill:
+			doc.body = []string{f1.String, markdown.Text(f2.String)}
+		default:
+			doc.body = []string{f1.String, f2.String}
+		}
+		doc.tags = decodeStrings(tagsJSON.String)
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// 技术别名也加入索引，使搜索 "K8s" 能找到标注为 Kubernetes 的内容
+	if entityType != TypeCertificate {
+		for i := range docs {
+			aliases, err := technologyAliases(entityType, docs[i].entityID)
+			if err != nil {
+				return nil, err
+			}
+			docs[i].tags = append(docs[i].tags, aliases...)
+		}
+	}
+	return docs, nil
+}
+
+// 查询实体关联技术的全部别名
+func technologyAliases(entityType string, id int) ([]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT a.name FROM `+entityType+`_technologies et
+		JOIN technology_aliases a ON a.technology_id = et.technology_id
+		JOIN technologies t ON t.id = et.technology_id
+		WHERE et.`+entityType+`_id = ? AND a.name <> t.name`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// 解析JSON字符串数组，格式不正确时返回空
+func decodeStrings(raw string) []string {
+	var values []string
+	if raw == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil
+	}
+	return values
+}
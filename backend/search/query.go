@@ -0,0 +1,169 @@
+package search
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 各列在排序中的权重：entity_type, entity_id, title, body, tags
+var columnWeights = []float64{0, 0, 10, 1, 5}
+
+// Search 执行全文搜索，limit为最多返回的命中数量。
+// allow不为nil时只返回它允许的内容(如访客简历版本内的内容)，过滤后仍尽量返回limit条
+func Search(q string, limit int, allow func(entityType string, id int) bool) (models.SearchResults, error) {
+	results := models.SearchResults{
+		Query:        normalizeQuery(q),
+		Projects:     []models.SearchHit{},
+		Experiences:  []models.SearchHit{},
+		Skills:       []models.SearchHit{},
+		Certificates: []models.SearchHit{},
+	}
+
+	match := buildMatchQuery(q)
+	if match == "" {
+		return results, nil
+	}
+
+	var hits []models.SearchHit
+	var err error
+	if useFTS5 {
+		hits, err = searchFTS5(match, limit, allow)
+	} else {
+		hits, err = searchFTS4(match, limit, allow)
+	}
+	if err != nil {
+		return results, err
+	}
+
+	for _, hit := range hits {
+		switch hit.Type {
+		case TypeProject:
+			results.Projects = append(results.Projects, hit)
+		case TypeExperience:
+			results.Experiences = append(results.Experiences, hit)
+		case TypeSkill:
+			results.Skills = append(results.Skills, hit)
+		case TypeCertificate:
+			results.Certificates = append(results.Certificates, hit)
+		}
+	}
+	results.Total = len(hits)
+	return results, nil
+}
+
+// 使用FTS5内置的bm25排序和highlight/snippet函数。
+// 需要过滤时不在SQL中限制数量，按相关度依次读取，凑够limit条后停止
+func searchFTS5(match string, limit int, allow func(string, int) bool) ([]models.SearchHit, error) {
+	query := `
+		SELECT entity_type, entity_id,
+			highlight(search_index, 2, ?, ?),
+			snippet(search_index, 3, ?, ?, '…', 24),
+			bm25(search_index, 0, 0, 10, 1, 5) AS rank
+		FROM search_index WHERE search_index MATCH ?
+		ORDER BY rank`
+	args := []interface{}{markStart, markEnd, markStart, markEnd, match}
+	if allow == nil {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []models.SearchHit
+	for len(hits) < limit && rows.Next() {
+		var hit models.SearchHit
+		if err := rows.Scan(&hit.Type, &hit.ID, &hit.Title, &hit.Snippet, &hit.Score); err != nil {
+			return nil, err
+		}
+		if allow != nil && !allow(hit.Type, hit.ID) {
+			continue
+		}
+		// bm25越小越相关，取反后分数越大越相关
+		hit.Score = -hit.Score
+		hit.Title = desegment(hit.Title)
+		hit.Snippet = desegment(hit.Snippet)
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// FTS4没有内置排序函数，根据matchinfo在Go中计算加权的TF-IDF分数
+func searchFTS4(match string, limit int, allow func(string, int) bool) ([]models.SearchHit, error) {
+	rows, err := database.DB.Query(`
+		SELECT entity_type, entity_id,
+			snippet(search_index, ?, ?, '', 2, 64),
+			snippet(search_index, ?, ?, '…', 3, 24),
+			matchinfo(search_index, 'pcx')
+		FROM search_index WHERE search_index MATCH ?`,
+		markStart, markEnd, markStart, markEnd, match)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []models.SearchHit
+	for rows.Next() {
+		var hit models.SearchHit
+		var info []byte
+		if err := rows.Scan(&hit.Type, &hit.ID, &hit.Title, &hit.Snippet, &info); err != nil {
+			return nil, err
+		}
+		if allow != nil && !allow(hit.Type, hit.ID) {
+			continue
+		}
+		hit.Score = scoreMatchInfo(info)
+		hit.Title = desegment(hit.Title)
+		hit.Snippet = desegment(hit.Snippet)
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 按分数从高到低排序
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// 解析matchinfo('pcx')：短语数、列数，以及每个短语在每列的(本行命中, 全部命中, 命中行数)
+func scoreMatchInfo(info []byte) float64 {
+	if len(info) < 8 {
+		return 0
+	}
+	value := func(i int) float64 {
+		return float64(binary.LittleEndian.Uint32(info[i*4:]))
+	}
+	phrases, cols := int(value(0)), int(value(1))
+	if len(info) < (2+phrases*cols*3)*4 {
+		return 0
+	}
+
+	var score float64
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < cols && c < len(columnWeights); c++ {
+			base := 2 + (p*cols+c)*3
+			hitsThisRow, hitsAllRows := value(base), value(base+1)
+			if hitsThisRow > 0 && hitsAllRows > 0 {
+				score += columnWeights[c] * hitsThisRow / hitsAllRows
+			}
+		}
+	}
+	return score
+}
+
+// 清理查询中多余的空白
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(q), " ")
+}
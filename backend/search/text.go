@@ -0,0 +1,131 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// 是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// segment 在每个中日韩字符两侧插入空格，使默认分词器按单字建立索引，从而支持中文子串搜索。
+// 原文中的空白统一替换为两个空格，以便desegment区分插入的空格和原有的空格
+func segment(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		var b strings.Builder
+		b.Grow(len(word) * 2)
+		for _, r := range word {
+			if isCJK(r) {
+				b.WriteRune(' ')
+				b.WriteRune(r)
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteRune(r)
+		}
+		words[i] = strings.Join(strings.Fields(b.String()), " ")
+	}
+	return strings.Join(words, "  ")
+}
+
+// desegment 去掉segment插入的空格，合并相邻的高亮标记，并转义为可安全展示的HTML
+// 传入文本中的高亮位置使用占位字符markStart/markEnd表示
+func desegment(s string) string {
+	runes := []rune(s)
+
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r != ' ' {
+			b.WriteRune(r)
+			continue
+		}
+		// 连续多个空格是原文中的空白，保留一个
+		if i+1 < len(runes) && runes[i+1] == ' ' {
+			for i+1 < len(runes) && runes[i+1] == ' ' {
+				i++
+			}
+			b.WriteRune(' ')
+			continue
+		}
+		// 单个空格紧挨中文字符时是segment插入的，去掉
+		if isCJK(neighbor(runes, i, -1)) || isCJK(neighbor(runes, i, 1)) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	s = strings.ReplaceAll(b.String(), markEnd+markStart, "")
+	s = html.EscapeString(s)
+	return strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>").Replace(s)
+}
+
+// 跳过高亮占位字符，找到左侧或右侧最近的文字字符
+func neighbor(runes []rune, i, dir int) rune {
+	for j := i + dir; j >= 0 && j < len(runes); j += dir {
+		if r := string(runes[j]); r != markStart && r != markEnd {
+			return runes[j]
+		}
+	}
+	return 0
+}
+
+// buildMatchQuery 将用户输入转换为FTS的MATCH表达式：
+// 中文词转换为逐字短语，英文词转为小写后使用前缀匹配(避免被识别为AND/OR等运算符)，多个词之间为AND关系
+func buildMatchQuery(q string) string {
+	var terms []string
+	for _, word := range strings.Fields(q) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || isCJK(r) {
+				return r
+			}
+			return ' '
+		}, word)
+
+		terms = append(terms, segmentMixed(word)...)
+	}
+	return strings.Join(terms, " ")
+}
+
+// 将一个词拆分为可用于MATCH的短语：连续的中文字符组成一个短语，其他部分作为前缀词
+func segmentMixed(word string) []string {
+	var out []string
+	var cjk, latin []rune
+	flush := func() {
+		if len(cjk) > 0 {
+			chars := make([]string, len(cjk))
+			for i, r := range cjk {
+				chars[i] = string(r)
+			}
+			out = append(out, `"`+strings.Join(chars, " ")+`"`)
+			cjk = nil
+		}
+		if len(latin) > 0 {
+			out = append(out, strings.ToLower(string(latin))+"*")
+			latin = nil
+		}
+	}
+	for _, r := range word {
+		switch {
+		case r == ' ':
+			flush()
+		case isCJK(r):
+			if len(latin) > 0 {
+				flush()
+			}
+			cjk = append(cjk, r)
+		default:
+			if len(cjk) > 0 {
+				flush()
+			}
+			latin = append(latin, r)
+		}
+	}
+	flush()
+	return out
+}
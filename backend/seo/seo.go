@@ -0,0 +1,350 @@
+package seo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/markdown"
+	"backend/models"
+)
+
+// Page 一个前端路由页面的元数据
+type Page struct {
+	Path        string // 相对于站点根地址的路径，如 "/"
+	Title       string
+	Description string
+	NoIndex     bool // 登录、验证等页面不需要被收录
+}
+
+// Site 生成元数据所需的站点信息
+type Site struct {
+	BaseURL   string   // 站点根地址，不以/结尾
+	Locale    string   // 当前页面语言
+	Locales   []string // 所有支持的语言，用于hreflang
+	Base      string   // 原文语言，对应不带?lang=的地址
+	Portfolio models.Portfolio
+
+	OmitProjects bool // JSON-LD中不列出项目
+}
+
+// Config 从环境变量读取的搜索引擎相关配置
+type Config struct {
+	SiteURL    string // 站点的公开地址，为空时根据请求推断
+	NoIndex    bool   // 禁止搜索引擎收录整个站点
+	RobotsFile string // 自定义robots.txt文件
+	Projects   bool   // 在JSON-LD中列出公开的项目
+}
+
+// LoadConfig 读取SITE_URL、SEO_NOINDEX、ROBOTS_FILE和SEO_JSONLD_PROJECTS
+func LoadConfig() Config {
+	return Config{
+		SiteURL:    strings.TrimSuffix(os.Getenv("SITE_URL"), "/"),
+		NoIndex:    os.Getenv("SEO_NOINDEX") == "true",
+		RobotsFile: os.Getenv("ROBOTS_FILE"),
+		Projects:   os.Getenv("SEO_JSONLD_PROJECTS") != "false",
+	}
+}
+
+// 描述的最大长度，搜索结果和链接预览一般只显示这么多
+const descriptionLimit = 160
+
+// HomePage 首页的标题和描述
+func HomePage(p models.Portfolio) Page {
+	title := p.Profile.Name
+	if p.Profile.Title != "" {
+		if title != "" {
+			title += " - "
+		}
+		title += p.Profile.Title
+	}
+	return Page{
+		Path:        "/",
+		Title:       title,
+		Description: Summary(markdown.Text(p.Profile.Introduction), descriptionLimit),
+	}
+}
+
+// Summary 将文本压缩为一行并按字符截断
+func Summary(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// URL 页面在指定语言下的完整地址，原文语言不带?lang=
+func (s Site) URL(path, locale string) string {
+	u := s.BaseURL + path
+	if locale != "" && locale != s.Base {
+		u += "?lang=" + locale
+	}
+	return u
+}
+
+// Head 生成插入<head>的标签：标题、描述、规范地址、多语言地址、Open Graph、Twitter卡片和JSON-LD
+func Head(site Site, page Page, noIndex bool) string {
+	var b strings.Builder
+	meta := func(attr, key, value string) {
+		if value == "" {
+			return
+		}
+		b.WriteString(`<meta ` + attr + `="` + html.EscapeString(key) + `" content="` + html.EscapeString(value) + "\">\n")
+	}
+	link := func(rel, href, hreflang string) {
+		b.WriteString(`<link rel="` + rel + `" href="` + html.EscapeString(href) + `"`)
+		if hreflang != "" {
+			b.WriteString(` hreflang="` + html.EscapeString(hreflang) + `"`)
+		}
+		b.WriteString(">\n")
+	}
+
+	profile := site.Portfolio.Profile
+	pageURL := site.URL(page.Path, site.Locale)
+
+	b.WriteString("<title>" + html.EscapeString(page.Title) + "</title>\n")
+	meta("name", "description", page.Description)
+	if noIndex || page.NoIndex {
+		meta("name", "robots", "noindex, nofollow")
+	} else {
+		link("canonical", pageURL, "")
+		for _, locale := range site.Locales {
+			link("alternate", site.URL(page.Path, locale), locale)
+		}
+		if len(site.Locales) > 1 {
+			link("alternate", site.URL(page.Path, site.Base), "x-default")
+		}
+	}
+
+	// 订阅源自动发现
+	for _, feed := range [][2]string{
+		{"application/atom+xml", "/feed.atom"},
+		{"application/rss+xml", "/feed.rss"},
+		{"application/feed+json", "/feed.json"},
+	} {
+		b.WriteString(`<link rel="alternate" type="` + feed[0] + `" title="` + html.EscapeString(profile.Name) +
+			`" href="` + html.EscapeString(site.URL(feed[1], site.Locale)) + "\">\n")
+	}
+
+	meta("property", "og:type", "profile")
+	meta("property", "og:title", page.Title)
+	meta("property", "og:description", page.Description)
+	meta("property", "og:url", pageURL)
+	meta("property", "og:site_name", profile.Name)
+	meta("property", "og:locale", strings.ReplaceAll(site.Locale, "-", "_"))
+	if image := AbsoluteURL(site.BaseURL, profile.Avatar); image != "" {
+		meta("property", "og:image", image)
+		meta("property", "og:image:alt", profile.Name)
+		meta("name", "twitter:image", image)
+	}
+	meta("name", "twitter:card", "summary")
+	meta("name", "twitter:title", page.Title)
+	meta("name", "twitter:description", page.Description)
+
+	if page.Path == "/" && !page.NoIndex {
+		if ld, err := JSONLD(site); err == nil {
+			b.WriteString(`<script type="application/ld+json">` + ld + "</script>\n")
+		}
+	}
+	return b.String()
+}
+
+// JSONLD 生成schema.org结构化数据：个人信息为Person，项目为CreativeWork
+func JSONLD(site Site) (string, error) {
+	p := site.Portfolio
+	personID := site.BaseURL + "/#person"
+
+	person := map[string]interface{}{
+		"@type":       "Person",
+		"@id":         personID,
+		"name":        p.Profile.Name,
+		"url":         site.URL("/", site.Locale),
+		"jobTitle":    p.Profile.Title,
+		"description": Summary(markdown.Text(p.Profile.Introduction), 500),
+	}
+	if image := AbsoluteURL(site.BaseURL, p.Profile.Avatar); image != "" {
+		person["image"] = image
+	}
+	if p.Profile.Location != "" {
+		person["address"] = map[string]string{"@type": "PostalAddress", "addressLocality": p.Profile.Location}
+	}
+	var skills []string
+	for _, category := range p.SkillCategories {
+		for _, skill := range category.Skills {
+			skills = append(skills, skill.Name)
+		}
+	}
+	if len(skills) > 0 {
+		person["knowsAbout"] = skills
+	}
+	var credentials []map[string]interface{}
+	for _, cert := range p.Certificates {
+		credential := map[string]interface{}{
+			"@type": "EducationalOccupationalCredential",
+			"name":  cert.Name,
+		}
+		if cert.Organization != "" {
+			credential["recognizedBy"] = map[string]string{"@type": "Organization", "name": cert.Organization}
+		}
+		if u := AbsoluteURL(site.BaseURL, cert.Link); u != "" {
+			credential["url"] = u
+		}
+		credentials = append(credentials, credential)
+	}
+	if len(credentials) > 0 {
+		person["hasCredential"] = credentials
+	}
+
+	graph := []interface{}{person}
+	projects := p.Projects
+	if site.OmitProjects {
+		projects = nil
+	}
+	for _, project := range projects {
+		work := map[string]interface{}{
+			"@type":       "CreativeWork",
+			"@id":         site.BaseURL + "/#project-" + strconv.Itoa(project.ID),
+			"name":        project.Title,
+			"description": Summary(markdown.Text(project.Description), 500),
+			"creator":     map[string]string{"@id": personID},
+			"inLanguage":  site.Locale,
+		}
+		if project.Category != "" {
+			work["genre"] = project.Category
+		}
+		if len(project.TechStack) > 0 {
+			work["keywords"] = strings.Join(project.TechStack, ", ")
+		}
+		if u := AbsoluteURL(site.BaseURL, project.DemoLink); u != "" {
+			work["url"] = u
+		}
+		if u := AbsoluteURL(site.BaseURL, project.RepoLink); u != "" {
+			work["sameAs"] = u
+		}
+		if u := AbsoluteURL(site.BaseURL, project.Image); u != "" {
+			work["image"] = u
+		}
+		graph = append(graph, work)
+	}
+
+	// json.Marshal会转义<、>和&，内容中的</script>不会提前结束脚本块
+	encoded, err := json.Marshal(map[string]interface{}{
+		"@context": "https://schema.org",
+		"@graph":   graph,
+	})
+	return string(encoded), err
+}
+
+var (
+	titlePattern       = regexp.MustCompile(`(?is)<title>.*?</title>\s*`)
+	descriptionPattern = regexp.MustCompile(`(?is)<meta\s+name="description"[^>]*>\s*`)
+	langPattern        = regexp.MustCompile(`(?i)<html([^>]*)\slang="[^"]*"`)
+)
+
+// Inject 将元数据标签插入页面的</head>之前，替换原有的<title>和描述，并设置<html lang>
+func Inject(page, head, locale string) string {
+	page = titlePattern.ReplaceAllString(page, "")
+	page = descriptionPattern.ReplaceAllString(page, "")
+	if locale != "" {
+		page = langPattern.ReplaceAllString(page, `<html$1 lang="`+html.EscapeString(locale)+`"`)
+	}
+	if i := strings.Index(strings.ToLower(page), "</head>"); i >= 0 {
+		return page[:i] + head + page[i:]
+	}
+	return head + page
+}
+
+type sitemapURL struct {
+	Loc        string        `xml:"loc"`
+	LastMod    string        `xml:"lastmod,omitempty"`
+	Alternates []sitemapLink `xml:"xhtml:link"`
+}
+
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Sitemap 生成sitemap.xml，每个公开页面的每种语言各一条，并互相标注hreflang
+func Sitemap(site Site, pages []Page, lastMod time.Time) ([]byte, error) {
+	var urls []sitemapURL
+	for _, page := range pages {
+		if page.NoIndex {
+			continue
+		}
+		var alternates []sitemapLink
+		if len(site.Locales) > 1 {
+			for _, locale := range site.Locales {
+				alternates = append(alternates, sitemapLink{Rel: "alternate", Hreflang: locale, Href: site.URL(page.Path, locale)})
+			}
+		}
+		for _, locale := range site.Locales {
+			u := sitemapURL{Loc: site.URL(page.Path, locale), Alternates: alternates}
+			if !lastMod.IsZero() {
+				u.LastMod = lastMod.UTC().Format("2006-01-02")
+			}
+			urls = append(urls, u)
+		}
+	}
+
+	encoded, err := xml.MarshalIndent(struct {
+		XMLName xml.Name     `xml:"urlset"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		XHTML   string       `xml:"xmlns:xhtml,attr"`
+		URLs    []sitemapURL `xml:"url"`
+	}{
+		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTML: "http://www.w3.org/1999/xhtml",
+		URLs:  urls,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(encoded, '\n')...), nil
+}
+
+// Robots 生成robots.txt：有自定义文件时使用文件内容，否则允许收录首页、禁止后台和接口。
+// 内容中没有Sitemap时自动补上
+func Robots(cfg Config, baseURL string) (string, error) {
+	var content string
+	switch {
+	case cfg.RobotsFile != "":
+		raw, err := os.ReadFile(cfg.RobotsFile)
+		if err != nil {
+			return "", err
+		}
+		content = strings.TrimRight(string(raw), "\n") + "\n"
+	case cfg.NoIndex:
+		return "User-agent: *\nDisallow: /\n", nil
+	default:
+		content = "User-agent: *\nAllow: /\nDisallow: /api/\nDisallow: /admin\nDisallow: /login\nDisallow: /verify\n"
+	}
+	if !strings.Contains(strings.ToLower(content), "sitemap:") {
+		content += "\nSitemap: " + baseURL + "/sitemap.xml\n"
+	}
+	return content, nil
+}
+
+// AbsoluteURL 相对地址补全为完整地址，只接受http和https，其他地址返回空字符串
+func AbsoluteURL(baseURL, u string) string {
+	u = strings.TrimSpace(u)
+	switch {
+	case u == "":
+		return ""
+	case strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "http://"):
+		return u
+	case strings.HasPrefix(u, "//"):
+		return "https:" + u
+	case strings.HasPrefix(u, "/"):
+		return baseURL + u
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package taxonomy
+
+// 内置的常见技术目录，首次迁移时写入，之后由管理员在后台维护。
+// 别名会在经历描述和导入的文本中按整词匹配，不收录JS、TS、PG这类同时是普通缩写的简写
+var catalog = []struct {
+	name     string
+	category string
+	aliases  []string
+}{
+	{"Kubernetes", "容器与编排", []string{"K8s"}},
+	{"Docker", "容器与编排", []string{"Docker Engine"}},
+	{"Helm", "容器与编排", []string{"Helm Chart", "Helm Charts"}},
+	{"Istio", "服务网格", nil},
+	{"Jenkins", "CI/CD", nil},
+	{"GitLab CI", "CI/CD", []string{"GitLab CI/CD"}},
+	{"GitHub Actions", "CI/CD", nil},
+	{"Argo CD", "CI/CD", nil},
+	{"Prometheus", "监控与可观测性", nil},
+	{"Grafana", "监控与可观测性", nil},
+	{"ELK", "监控与可观测性", []string{"Elastic Stack"}},
+	{"Elasticsearch", "数据库", nil},
+	{"Terraform", "基础设施即代码", nil},
+	{"Ansible", "配置管理", nil},
+	{"AWS", "云平台", []string{"Amazon Web Services"}},
+	{"阿里云", "云平台", []string{"Aliyun", "Alibaba Cloud"}},
+	{"Azure", "云平台", []string{"Microsoft Azure"}},
+	{"GCP", "云平台", []string{"Google Cloud", "Google Cloud Platform"}},
+	{"Linux", "操作系统", nil},
+	{"Shell", "编程语言", []string{"Bash", "Shell Script", "Shell脚本"}},
+	{"Python", "编程语言", []string{"Python3"}},
+	{"Go", "编程语言", []string{"Golang"}},
+	{"Java", "编程语言", nil},
+	{"JavaScript", "编程语言", nil},
+	{"TypeScript", "编程语言", nil},
+	{"MySQL", "数据库", nil},
+	{"PostgreSQL", "数据库", []string{"Postgres"}},
+	{"Redis", "数据库", nil},
+	{"MongoDB", "数据库", []string{"Mongo"}},
+	{"Kafka", "消息队列", []string{"Apache Kafka"}},
+	{"RabbitMQ", "消息队列", nil},
+	{"Nginx", "网络", nil},
+	{"TCP/IP", "网络", nil},
+	{"Git", "版本控制", nil},
+	{"Vue.js", "前端", []string{"Vue", "Vue3"}},
+	{"React", "前端", []string{"React.js"}},
+	{"Node.js", "后端", []string{"Node"}},
+}
+
+// 早期版本目录中的简写别名，在普通文字中容易误匹配(如"PG admin"、"TS experience")，
+// 已有数据库中仍属于对应技术的这些别名在迁移时删除
+var droppedAliases = map[string]string{
+	"kube": "Kubernetes",
+	"ES":   "Elasticsearch",
+	"TF":   "Terraform",
+	"JS":   "JavaScript",
+	"TS":   "TypeScript",
+	"PG":   "PostgreSQL",
+}
@@ -0,0 +1,229 @@
+package taxonomy
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/database"
+	"backend/models"
+)
+
+// SkillEvidence 推算技能的使用依据：先根据技能名称匹配技术(如 "Shell脚本" 匹配 Shell、
+// "Prometheus & Grafana" 匹配两者)，名称无法匹配时使用技能标签关联的技术；
+// 再找出使用这些技术的工作经历和项目，并根据工作经历的时间段计算使用年限
+func SkillEvidence(skillID int, name string) (models.SkillEvidence, error) {
+	evidence := models.SkillEvidence{
+		Technologies: []string{},
+		Experiences:  []models.TechnologyExperienceRef{},
+		Projects:     []models.TechnologyProjectRef{},
+	}
+
+	techIDs, err := skillTechnologies(skillID, name)
+	if err != nil || len(techIDs) == 0 {
+		return evidence, err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(techIDs)), ",")
+	args := make([]interface{}, len(techIDs))
+	for i, id := range techIDs {
+		args[i] = id
+	}
+
+	rows, err := database.DB.Query("SELECT name FROM technologies WHERE id IN ("+placeholders+") ORDER BY name", args...)
+	if err != nil {
+		return evidence, err
+	}
+	for rows.Next() {
+		var techName string
+		if err := rows.Scan(&techName); err != nil {
+			rows.Close()
+			return evidence, err
+		}
+		evidence.Technologies = append(evidence.Technologies, techName)
+	}
+	rows.Close()
+
+	rows, err = database.DB.Query(`
+		SELECT DISTINCT e.id, e.title, e.company, e.period, e.sort_order FROM experience_technologies et
+		JOIN experiences e ON e.id = et.experience_id
+		WHERE et.technology_id IN (`+placeholders+`)
+		ORDER BY e.sort_order, e.id`, args...)
+	if err != nil {
+		return evidence, err
+	}
+	for rows.Next() {
+		var ref models.TechnologyExperienceRef
+		var sortOrder sql.NullInt64
+		if err := rows.Scan(&ref.ID, &ref.Title, &ref.Company, &ref.Period, &sortOrder); err != nil {
+			rows.Close()
+			return evidence, err
+		}
+		evidence.Experiences = append(evidence.Experiences, ref)
+	}
+	rows.Close()
+
+	rows, err = database.DB.Query(`
+		SELECT DISTINCT p.id, p.title, COALESCE(p.category, ''), p.sort_order FROM project_technologies pt
+		JOIN projects p ON p.id = pt.project_id
+		WHERE pt.technology_id IN (`+placeholders+`)
+		ORDER BY p.sort_order, p.id`, args...)
+	if err != nil {
+		return evidence, err
+	}
+	for rows.Next() {
+		var ref models.TechnologyProjectRef
+		var sortOrder sql.NullInt64
+		if err := rows.Scan(&ref.ID, &ref.Title, &ref.Category, &sortOrder); err != nil {
+			rows.Close()
+			return evidence, err
+		}
+		evidence.Projects = append(evidence.Projects, ref)
+	}
+	rows.Close()
+
+	Summarize(&evidence, time.Now())
+	return evidence, nil
+}
+
+// Summarize 根据依据中的工作经历和项目重新计算使用年限、最近使用时间和建议熟练度，
+// 依据被筛选(如只保留简历版本中的内容)后调用。工作经历的时间段须为原文，不能是翻译后的文本
+func Summarize(e *models.SkillEvidence, now time.Time) {
+	e.YearsOfUse, e.LastUsed, e.CurrentlyUsed, e.SuggestedLevel = 0, "", false, nil
+
+	var periods []Period
+	for _, ref := range e.Experiences {
+		if p, ok := ParsePeriod(ref.Period, now); ok {
+			periods = append(periods, p)
+		}
+	}
+	if len(periods) > 0 {
+		e.YearsOfUse = math.Round(float64(totalMonths(periods))/12*10) / 10
+
+		last := periods[0]
+		for _, p := range periods[1:] {
+			if p.Current || (!last.Current && p.End.After(last.End)) {
+				last = p
+			}
+		}
+		e.LastUsed = last.End.Format("2006-01")
+		e.CurrentlyUsed = last.Current
+	}
+
+	if len(e.Experiences) > 0 || len(e.Projects) > 0 {
+		level := suggestLevel(*e, now)
+		e.SuggestedLevel = &level
+	}
+}
+
+// suggestLevel 根据依据给出建议的熟练度(0-100)：
+// 使用年限最多60分(8年封顶)，经历和项目数量每项5分最多25分，
+// 仍在使用加15分，停用后每年减3分
+func suggestLevel(e models.SkillEvidence, now time.Time) int {
+	score := math.Min(e.YearsOfUse, 8) / 8 * 60
+	score += math.Min(float64(len(e.Experiences)+len(e.Projects))*5, 25)
+
+	switch {
+	case e.CurrentlyUsed:
+		score += 15
+	case e.LastUsed != "":
+		if last, err := time.Parse("2006-01", e.LastUsed); err == nil {
+			idle := float64(monthIndex(now)-monthIndex(last)) / 12
+			score += math.Max(15-3*idle, 0)
+		}
+	}
+
+	return int(math.Round(math.Max(0, math.Min(score, 100))))
+}
+
+// 确定技能对应的技术ID
+func skillTechnologies(skillID int, name string) ([]int, error) {
+	var ids []int
+
+	// 技能名称本身就是技术名称或别名
+	if id, _, err := lookup(name); err == nil {
+		return []int{id}, nil
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// 技能名称中包含技术名称或别名
+	rows, err := database.DB.Query("SELECT technology_id, name FROM technology_aliases")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var alias string
+		if err := rows.Scan(&id, &alias); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !seen[id] && containsWord(name, alias) {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if len(ids) > 0 {
+		return ids, nil
+	}
+
+	// 名称无法匹配时使用技能标签关联的技术
+	rows, err = database.DB.Query("SELECT technology_id FROM skill_technologies WHERE skill_id = ? ORDER BY position", skillID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// containsWord 判断text中是否包含完整的word(忽略大小写)，
+// word两侧不能紧挨英文字母或数字，避免 "Go" 匹配到 "Google"
+func containsWord(text, word string) bool {
+	if len([]rune(word)) < 2 {
+		return false
+	}
+	text, word = strings.ToLower(text), strings.ToLower(word)
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if !isWordRune(lastRune(text[:start])) && !isWordRune(firstRune(text[end:])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// 英文字母和数字，中文等其他字符视为词的边界
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0
+	}
+	return runes[len(runes)-1]
+}
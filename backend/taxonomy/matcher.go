@@ -0,0 +1,494 @@
+package taxonomy
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"backend/database"
+	"backend/models"
+)
+
+// 职位描述中可能是技术名称的英文词，如 Kafka、CI/CD、C++、Node.js
+var jobTokenPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+#]*(?:[./-][A-Za-z0-9+#]+)*`)
+
+// 职位描述中常见的首字母大写但不是技术名称的词
+var jobStopwords = toSet(strings.Fields(`
+	a an the and or but if of to in on at by for with from into over via per as is are be been being
+	we you they it its our your their us this that these those will would must should can could may
+	have has had do does not no yes also well all any each one two more most than such other etc
+	about what who how why when where which while within across including include includes e.g i.e
+	experience experienced years year strong solid deep proven good great excellent nice plus bonus
+	knowledge skill skills ability able familiar familiarity understanding proficiency proficient expert
+	expertise hands-on track record working work team teams job role position description company
+	requirements required requirement responsibilities responsibility qualifications qualification
+	preferred minimum least degree bachelor bachelors master masters phd computer science related field
+	engineer engineers engineering senior junior lead staff principal developer developers manager
+	design build develop maintain support ensure help join looking seeking candidate candidates
+	communication english chinese mandarin equal opportunity employer apply please benefits salary
+	location remote hybrid onsite full part time day days week weeks month months hiring process
+	interview ideal key main primary new based using use up out high large scale production
+	system systems service services infrastructure platform platforms tool tools tooling
+	environment environments operations operational software application applications development
+	best practice practices culture mission passion passionate collaborate collaboration drive own
+	ownership deliver delivery improve improving implement implementing manage managing management
+	responsible programming scripting pipeline pipelines
+	you'll we're you're what's i ii iii iv v
+`))
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// 只转换ASCII字母的小写，保证字节位置不变
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s)
+}
+
+// 匹配到的文本区间，用于避免同一处文字被重复计算
+type textSpan struct{ start, end int }
+
+type spanSet []textSpan
+
+func (s spanSet) overlaps(start, end int) bool {
+	for _, sp := range s {
+		if start < sp.end && sp.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// 查找word在text中所有完整出现的位置；三个字符以内的纯英文别名(如 Go、AWS)区分大小写，
+// 避免把英文单词 "go" 误认为 Go 语言
+func findWord(text, lowered, word string) []textSpan {
+	if len([]rune(word)) < 2 {
+		return nil
+	}
+	haystack, needle := lowered, asciiLower(word)
+	if len(word) <= 3 && isASCII(word) {
+		haystack, needle = text, word
+	}
+
+	var spans []textSpan
+	for offset := 0; offset < len(haystack); {
+		i := strings.Index(haystack[offset:], needle)
+		if i < 0 {
+			break
+		}
+		start, end := offset+i, offset+i+len(needle)
+		if !isWordRune(lastRune(haystack[:start])) && !isWordRune(firstRune(haystack[end:])) {
+			spans = append(spans, textSpan{start, end})
+		}
+		offset = start + 1
+	}
+	return spans
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// 关键词的权重：多次出现的关键词更重要，最多计为2倍
+func keywordWeight(occurrences int) float64 {
+	return 1 + 0.5*math.Min(float64(occurrences-1), 2)
+}
+
+// 作品集中某个项目或工作经历的文本及关联技术
+type portfolioItem struct {
+	id    int
+	title string
+	text  string
+	techs map[int]bool
+}
+
+// MatchJobDescription 在本地分析职位描述：识别其中的技术、技能和疑似技术名称的关键词，
+// 与技能、标签、工作经历技术和项目技术栈(含别名)比对，给出覆盖率和建议重点展示的内容
+func MatchJobDescription(text string) (models.JobMatchResult, error) {
+	result := models.JobMatchResult{
+		Matched:     []models.JobKeyword{},
+		Missing:     []models.JobKeyword{},
+		Skills:      []models.TechnologySkillRef{},
+		Projects:    []models.JobMatchSuggestion{},
+		Experiences: []models.JobMatchSuggestion{},
+	}
+	lowered := asciiLower(text)
+	var matchedSpans spanSet
+
+	type keyword struct {
+		models.JobKeyword
+		techID  int
+		skillID int
+	}
+	var keywords []*keyword
+
+	// 1. 已登记的技术及其别名，按长度从长到短匹配，避免 "GitLab CI" 再被 "GitLab" 匹配一次
+	rows, err := database.DB.Query(`
+		SELECT a.name, t.id, t.name FROM technology_aliases a
+		JOIN technologies t ON t.id = a.technology_id`)
+	if err != nil {
+		return result, err
+	}
+	type aliasRow struct {
+		alias, techName string
+		techID          int
+	}
+	var aliases []aliasRow
+	for rows.Next() {
+		var a aliasRow
+		if err := rows.Scan(&a.alias, &a.techID, &a.techName); err != nil {
+			rows.Close()
+			return result, err
+		}
+		aliases = append(aliases, a)
+	}
+	rows.Close()
+	sort.SliceStable(aliases, func(i, j int) bool {
+		return len(aliases[i].alias) > len(aliases[j].alias)
+	})
+
+	byTech := make(map[int]*keyword)
+	techByKey := make(map[string]int)
+	techNames := make(map[int]string)
+	for _, a := range aliases {
+		techByKey[aliasKey(a.alias)] = a.techID
+		techNames[a.techID] = a.techName
+	}
+	techKeyword := func(techID int) *keyword {
+		kw := byTech[techID]
+		if kw == nil {
+			kw = &keyword{techID: techID}
+			kw.Keyword = techNames[techID]
+			kw.Technology = techNames[techID]
+			byTech[techID] = kw
+			keywords = append(keywords, kw)
+		}
+		return kw
+	}
+	for _, a := range aliases {
+		for _, sp := range findWord(text, lowered, a.alias) {
+			if matchedSpans.overlaps(sp.start, sp.end) {
+				continue
+			}
+			matchedSpans = append(matchedSpans, sp)
+			techKeyword(a.techID).Occurrences++
+		}
+	}
+
+	// 2. 技能名称(如 "Service Mesh"、"数据库运维")
+	skillRows, err := database.DB.Query("SELECT id, name, COALESCE(level, 0) FROM skills ORDER BY id")
+	if err != nil {
+		return result, err
+	}
+	var skills []models.TechnologySkillRef
+	for skillRows.Next() {
+		var s models.TechnologySkillRef
+		if err := skillRows.Scan(&s.ID, &s.Name, &s.Level); err != nil {
+			skillRows.Close()
+			return result, err
+		}
+		skills = append(skills, s)
+	}
+	skillRows.Close()
+
+	for _, s := range skills {
+		var kw *keyword
+		for _, sp := range findWord(text, lowered, s.Name) {
+			if matchedSpans.overlaps(sp.start, sp.end) {
+				continue
+			}
+			matchedSpans = append(matchedSpans, sp)
+			if kw == nil {
+				kw = &keyword{skillID: s.ID}
+				kw.Keyword = s.Name
+				kw.Sources = []string{"skill"}
+				keywords = append(keywords, kw)
+			}
+			kw.Occurrences++
+		}
+	}
+
+	// 3. 其余疑似技术名称的英文词(含大写字母且不是常见词)
+	byToken := make(map[string]*keyword)
+	for _, loc := range jobTokenPattern.FindAllStringIndex(text, -1) {
+		token := strings.TrimRight(text[loc[0]:loc[1]], ".")
+		if len(token) < 2 || token == strings.ToLower(token) || jobStopwords[asciiLower(token)] {
+			continue
+		}
+		if matchedSpans.overlaps(loc[0], loc[0]+len(token)) {
+			continue
+		}
+		// 写法不同但匹配键相同的技术，如 "ArgoCD" 对应 "Argo CD"
+		if techID, ok := techByKey[aliasKey(token)]; ok {
+			matchedSpans = append(matchedSpans, textSpan{loc[0], loc[0] + len(token)})
+			techKeyword(techID).Occurrences++
+			continue
+		}
+
+		key := asciiLower(token)
+		kw := byToken[key]
+		if kw == nil {
+			kw = &keyword{}
+			kw.Keyword = token
+			byToken[key] = kw
+			keywords = append(keywords, kw)
+		}
+		kw.Occurrences++
+	}
+
+	// 作品集数据
+	projects, err := loadPortfolioItems(EntityProject)
+	if err != nil {
+		return result, err
+	}
+	experiences, err := loadPortfolioItems(EntityExperience)
+	if err != nil {
+		return result, err
+	}
+	skillTechs := make(map[int][]int)
+	for _, s := range skills {
+		ids, err := skillTechnologies(s.ID, s.Name)
+		if err != nil {
+			return result, err
+		}
+		skillTechs[s.ID] = ids
+	}
+	portfolioText, err := loadPortfolioText(projects, experiences)
+	if err != nil {
+		return result, err
+	}
+	portfolioLowered := asciiLower(portfolioText)
+
+	// 判断每个关键词在作品集中出现的位置
+	for _, kw := range keywords {
+		if kw.skillID != 0 {
+			continue
+		}
+		sources := make(map[string]bool)
+		if kw.techID != 0 {
+			for _, ids := range skillTechs {
+				for _, id := range ids {
+					if id == kw.techID {
+						sources["skill"] = true
+					}
+				}
+			}
+			for _, p := range projects {
+				if p.techs[kw.techID] {
+					sources["project"] = true
+				}
+			}
+			for _, e := range experiences {
+				if e.techs[kw.techID] {
+					sources["experience"] = true
+				}
+			}
+		}
+		if len(sources) == 0 {
+			// 未关联的技术或未登记的关键词，检查是否出现在作品集的文字中
+			candidates := []string{kw.Keyword}
+			for _, a := range aliases {
+				if a.techID == kw.techID && kw.techID != 0 {
+					candidates = append(candidates, a.alias)
+				}
+			}
+			for _, candidate := range candidates {
+				if len(findWord(portfolioText, portfolioLowered, candidate)) > 0 {
+					sources["text"] = true
+					break
+				}
+			}
+		}
+		kw.Sources = []string{}
+		for _, source := range []string{"skill", "experience", "project", "text"} {
+			if sources[source] {
+				kw.Sources = append(kw.Sources, source)
+			}
+		}
+	}
+
+	// 覆盖率和匹配/缺失列表
+	var matchedWeight, totalWeight float64
+	matchedTechs := make(map[int]float64)
+	for _, kw := range keywords {
+		weight := keywordWeight(kw.Occurrences)
+		totalWeight += weight
+		if len(kw.Sources) > 0 {
+			matchedWeight += weight
+			result.Matched = append(result.Matched, kw.JobKeyword)
+			if kw.techID != 0 {
+				matchedTechs[kw.techID] = weight
+			}
+		} else {
+			result.Missing = append(result.Missing, kw.JobKeyword)
+		}
+	}
+	if totalWeight > 0 {
+		result.Score = int(math.Round(matchedWeight / totalWeight * 100))
+	}
+	sortKeywords(result.Matched)
+	sortKeywords(result.Missing)
+
+	// 相关技能
+	for _, s := range skills {
+		relevant := false
+		for _, kw := range keywords {
+			if kw.skillID == s.ID {
+				relevant = true
+			}
+		}
+		for _, id := range skillTechs[s.ID] {
+			if _, ok := matchedTechs[id]; ok {
+				relevant = true
+			}
+		}
+		if relevant {
+			result.Skills = append(result.Skills, s)
+		}
+	}
+
+	// 建议重点展示的项目和工作经历
+	var matchedKeywords []*keyword
+	for _, kw := range keywords {
+		if len(kw.Sources) > 0 {
+			matchedKeywords = append(matchedKeywords, kw)
+		}
+	}
+	suggest := func(items []portfolioItem) []models.JobMatchSuggestion {
+		suggestions := []models.JobMatchSuggestion{}
+		for _, item := range items {
+			s := models.JobMatchSuggestion{ID: item.id, Title: item.title, Keywords: []string{}}
+			itemLowered := asciiLower(item.text)
+			for _, kw := range matchedKeywords {
+				if (kw.techID != 0 && item.techs[kw.techID]) || len(findWord(item.text, itemLowered, kw.Keyword)) > 0 {
+					s.Score += keywordWeight(kw.Occurrences)
+					s.Keywords = append(s.Keywords, kw.Keyword)
+				}
+			}
+			if s.Score > 0 {
+				s.Score = math.Round(s.Score*10) / 10
+				suggestions = append(suggestions, s)
+			}
+		}
+		sort.SliceStable(suggestions, func(i, j int) bool {
+			return suggestions[i].Score > suggestions[j].Score
+		})
+		if len(suggestions) > 5 {
+			suggestions = suggestions[:5]
+		}
+		return suggestions
+	}
+	result.Projects = suggest(projects)
+	result.Experiences = suggest(experiences)
+
+	return result, nil
+}
+
+// 按出现次数从多到少排序
+func sortKeywords(keywords []models.JobKeyword) {
+	sort.SliceStable(keywords, func(i, j int) bool {
+		return keywords[i].Occurrences > keywords[j].Occurrences
+	})
+}
+
+// 读取项目或工作经历的文本和关联技术
+func loadPortfolioItems(entity string) ([]portfolioItem, error) {
+	var query string
+	if entity == EntityProject {
+		query = `SELECT id, title, COALESCE(category, '') || ' ' || COALESCE(description, ''),
+			COALESCE(key_points, ''), '' FROM projects ORDER BY sort_order, id`
+	} else {
+		query = `SELECT id, title || ' ' || company, COALESCE(location, ''),
+			COALESCE(responsibilities, ''), COALESCE(achievements, '') FROM experiences ORDER BY sort_order, id`
+	}
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []portfolioItem
+	index := make(map[int]int)
+	for rows.Next() {
+		var item portfolioItem
+		var text, listA, listB string
+		if err := rows.Scan(&item.id, &item.title, &text, &listA, &listB); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		parts := []string{item.title, text}
+		for _, list := range []string{listA, listB} {
+			var values []string
+			json.Unmarshal([]byte(list), &values)
+			parts = append(parts, values...)
+		}
+		item.text = strings.Join(parts, "\n")
+		item.techs = make(map[int]bool)
+		index[item.id] = len(items)
+		items = append(items, item)
+	}
+	rows.Close()
+
+	rows, err = database.DB.Query("SELECT " + entity + "_id, technology_id FROM " + entity + "_technologies")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, techID int
+		if err := rows.Scan(&id, &techID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			items[i].techs[techID] = true
+		}
+	}
+	return items, rows.Err()
+}
+
+// 汇总作品集中的全部文字，用于判断未关联技术的关键词是否被提及
+func loadPortfolioText(projects, experiences []portfolioItem) (string, error) {
+	var parts []string
+	for _, items := range [][]portfolioItem{projects, experiences} {
+		for _, item := range items {
+			parts = append(parts, item.text)
+		}
+	}
+
+	queries := []string{
+		"SELECT COALESCE(title, '') || '\n' || COALESCE(introduction, '') FROM profile",
+		"SELECT name || '\n' || COALESCE(description, '') FROM skills",
+		"SELECT name || '\n' || COALESCE(organization, '') || '\n' || COALESCE(description, '') FROM certificates",
+	}
+	for _, query := range queries {
+		rows, err := database.DB.Query(query)
+		if err != nil {
+			return "", err
+		}
+		for rows.Next() {
+			var text string
+			if err := rows.Scan(&text); err != nil {
+				rows.Close()
+				return "", err
+			}
+			parts = append(parts, text)
+		}
+		rows.Close()
+	}
+	return strings.Join(parts, "\n"), nil
+}
@@ -0,0 +1,115 @@
+package taxonomy
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 匹配时间段中的年月，如 2019、2019.03、2019-3、2019/03、2019年3月
+var periodDatePattern = regexp.MustCompile(`(\d{4})(?:[年./-](\d{1,2})(?:月|\b))?`)
+
+// 表示至今的写法
+var presentWords = []string{"至今", "现在", "目前", "今", "present", "now", "current"}
+
+// Period 解析后的时间段，以月为单位，End为最后一个月(包含)
+type Period struct {
+	Start   time.Time
+	End     time.Time
+	Current bool
+}
+
+// ParsePeriod 解析工作经历的时间段文本，如 "2019 - 2021"、"2021.03 - 至今"、"2019年3月~2020年6月"。
+// 开始时间缺少月份时按1月计算，结束时间缺少月份时按12月计算
+func ParsePeriod(period string, now time.Time) (Period, bool) {
+	matches := periodDatePattern.FindAllStringSubmatch(period, 2)
+	if len(matches) == 0 {
+		return Period{}, false
+	}
+
+	start, ok := periodMonth(matches[0], 1)
+	if !ok {
+		return Period{}, false
+	}
+
+	current := false
+	lower := strings.ToLower(period)
+	for _, word := range presentWords {
+		if strings.Contains(lower, word) {
+			current = true
+			break
+		}
+	}
+
+	var end time.Time
+	switch {
+	case len(matches) > 1:
+		if end, ok = periodMonth(matches[1], 12); !ok {
+			return Period{}, false
+		}
+	case current:
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		// 只有一个年份时视为当年全年
+		if end, ok = periodMonth(matches[0], 12); !ok {
+			return Period{}, false
+		}
+	}
+
+	if len(matches) > 1 {
+		current = false
+	}
+	if end.Before(start) {
+		start, end = end, start
+	}
+	return Period{Start: start, End: end, Current: current}, true
+}
+
+// 将匹配到的年月转换为当月第一天，缺少月份时使用defaultMonth
+func periodMonth(match []string, defaultMonth int) (time.Time, bool) {
+	year, err := strconv.Atoi(match[1])
+	if err != nil || year < 1950 || year > 2100 {
+		return time.Time{}, false
+	}
+	month := defaultMonth
+	if match[2] != "" {
+		month, err = strconv.Atoi(match[2])
+		if err != nil || month < 1 || month > 12 {
+			return time.Time{}, false
+		}
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), true
+}
+
+// 月份序号，便于计算月数
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// totalMonths 合并重叠的时间段后计算总月数
+func totalMonths(periods []Period) int {
+	type span struct{ start, end int }
+	spans := make([]span, 0, len(periods))
+	for _, p := range periods {
+		spans = append(spans, span{monthIndex(p.Start), monthIndex(p.End) + 1})
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	total := 0
+	for i := 0; i < len(spans); {
+		start, end := spans[i].start, spans[i].end
+		i++
+		for i < len(spans) && spans[i].start <= end {
+			if spans[i].end > end {
+				end = spans[i].end
+			}
+			i++
+		}
+		total += end - start
+	}
+	return total
+}
@@ -0,0 +1,682 @@
+package taxonomy
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"backend/database"
+	"backend/events"
+	"backend/models"
+)
+
+// 关联技术的实体类型
+const (
+	EntitySkill      = "skill"
+	EntityExperience = "experience"
+	EntityProject    = "project"
+)
+
+// 实体对应的数据表和保存技术名称JSON数组的字段
+var entities = map[string]struct {
+	table  string
+	column string
+}{
+	EntitySkill:      {"skills", "tags"},
+	EntityExperience: {"experiences", "technologies"},
+	EntityProject:    {"projects", "tech_stack"},
+}
+
+// ErrAliasConflict 别名已属于另一项技术
+var ErrAliasConflict = errors.New("别名已被其他技术使用")
+
+// 一次性迁移的名称
+const migrationName = "technology_taxonomy"
+
+// 删除目录中已移除的简写别名的迁移名称
+const droppedAliasesMigration = "technology_dropped_aliases"
+
+// Setup 首次运行时写入内置技术目录，并把已有的JSON数组规范化后建立关联
+func Setup() error {
+	applied, err := database.MigrationApplied(migrationName)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		for _, t := range catalog {
+			if _, err := Create(t.name, t.category, "", t.aliases); err != nil && err != ErrAliasConflict {
+				return err
+			}
+		}
+		if err := NormalizeAll(); err != nil {
+			return err
+		}
+
+		log.Printf("技术标签迁移完成，已规范化技能、工作经历和项目中的技术名称")
+		if err := database.MarkMigrationApplied(migrationName); err != nil {
+			return err
+		}
+	}
+
+	return dropAliases()
+}
+
+// 删除早期目录写入的简写别名，管理员把这些别名改给了其他技术时保留
+func dropAliases() error {
+	applied, err := database.MigrationApplied(droppedAliasesMigration)
+	if err != nil || applied {
+		return err
+	}
+	for alias, name := range droppedAliases {
+		if _, err := database.DB.Exec(`
+			DELETE FROM technology_aliases
+			WHERE alias = ? AND technology_id IN (SELECT id FROM technologies WHERE name = ?)`,
+			aliasKey(alias), name); err != nil {
+			return err
+		}
+	}
+	return database.MarkMigrationApplied(droppedAliasesMigration)
+}
+
+// aliasKey 归一化名称用于匹配：忽略大小写、空白以及 - _ . 等分隔符，
+// 使 "GitLab CI"、"gitlab-ci" 和 "Node.js"、"nodejs" 都能对应到同一项技术
+func aliasKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '_' || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// slugify 生成URL中使用的标识，如 "Vue.js" -> "vue-js"、"C#" -> "csharp"
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			dash = false
+		case r == '+':
+			b.WriteString("plus")
+			dash = false
+		case r == '#':
+			b.WriteString("sharp")
+			dash = false
+		default:
+			if !dash && b.Len() > 0 {
+				b.WriteRune('-')
+				dash = true
+			}
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		slug = "tech"
+	}
+	return slug
+}
+
+// 生成未被占用的slug，冲突时追加数字后缀
+func uniqueSlug(name string) (string, error) {
+	base := slugify(name)
+	slug := base
+	for i := 2; ; i++ {
+		var count int
+		if err := database.DB.QueryRow("SELECT COUNT(*) FROM technologies WHERE slug = ?", slug).Scan(&count); err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// 根据名称或别名查找技术
+func lookup(name string) (int, string, error) {
+	var id int
+	var canonical string
+	err := database.DB.QueryRow(`
+		SELECT t.id, t.name FROM technology_aliases a
+		JOIN technologies t ON t.id = a.technology_id
+		WHERE a.alias = ?`, aliasKey(name)).Scan(&id, &canonical)
+	return id, canonical, err
+}
+
+// Create 新建技术，规范名称和别名都会登记为匹配键
+func Create(name, category, description string, aliases []string) (int, error) {
+	name = strings.TrimSpace(name)
+	names := append([]string{name}, aliases...)
+	for _, alias := range names {
+		owner, err := aliasOwner(alias)
+		if err != nil {
+			return 0, err
+		}
+		if owner != 0 {
+			return 0, ErrAliasConflict
+		}
+	}
+
+	slug, err := uniqueSlug(name)
+	if err != nil {
+		return 0, err
+	}
+	result, err := database.DB.Exec(
+		"INSERT INTO technologies (slug, name, category, description) VALUES (?, ?, ?, ?)",
+		slug, name, category, description)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := result.LastInsertId()
+
+	if err := addAliases(int(id), names); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// 查询别名当前属于哪项技术，未登记时返回0
+func aliasOwner(alias string) (int, error) {
+	var owner int
+	err := database.DB.QueryRow("SELECT technology_id FROM technology_aliases WHERE alias = ?", aliasKey(alias)).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return owner, err
+}
+
+// 为技术登记别名，空白别名和重复的匹配键会被忽略
+func addAliases(id int, aliases []string) error {
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if aliasKey(alias) == "" {
+			continue
+		}
+		if _, err := database.DB.Exec(
+			"INSERT OR IGNORE INTO technology_aliases (alias, name, technology_id) VALUES (?, ?, ?)",
+			aliasKey(alias), alias, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将名称解析为技术，未登记的名称自动创建为未分类的新技术
+func resolve(name string) (int, string, error) {
+	id, canonical, err := lookup(name)
+	if err != sql.ErrNoRows {
+		return id, canonical, err
+	}
+
+	name = strings.TrimSpace(name)
+	id, err = Create(name, "", "", nil)
+	if err == ErrAliasConflict {
+		// 并发请求已创建了同名技术
+		return lookup(name)
+	}
+	return id, name, err
+}
+
+// Normalize 将技术名称替换为规范名称并去重，保持原有顺序
+func Normalize(names []string) ([]string, error) {
+	if names == nil {
+		return nil, nil
+	}
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if aliasKey(name) == "" {
+			continue
+		}
+		_, canonical, err := resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			normalized = append(normalized, canonical)
+		}
+	}
+	return normalized, nil
+}
+
+// Link 按技术名称重建实体与技术的关联
+func Link(entity string, id int, names []string) error {
+	if err := Unlink(entity, id); err != nil {
+		return err
+	}
+	for i, name := range names {
+		if aliasKey(name) == "" {
+			continue
+		}
+		techID, _, err := resolve(name)
+		if err != nil {
+			return err
+		}
+		_, err = database.DB.Exec(
+			"INSERT OR IGNORE INTO "+entity+"_technologies ("+entity+"_id, technology_id, position) VALUES (?, ?, ?)",
+			id, techID, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unlink 删除实体的全部技术关联
+func Unlink(entity string, id int) error {
+	_, err := database.DB.Exec("DELETE FROM "+entity+"_technologies WHERE "+entity+"_id = ?", id)
+	return err
+}
+
+// NormalizeAll 规范化所有技能、工作经历和项目中的技术名称并重建关联，
+// 在迁移以及技术改名、合并、修改别名后调用
+func NormalizeAll() error {
+	_, err := normalizeAll()
+	return err
+}
+
+// 规范化全部实体，返回技术名称数组被改写的实体ID
+func normalizeAll() (map[string][]int, error) {
+	changed := map[string][]int{}
+	for _, entity := range []string{EntitySkill, EntityExperience, EntityProject} {
+		ids, err := normalizeEntity(entity)
+		if err != nil {
+			return nil, err
+		}
+		changed[entity] = ids
+	}
+	return changed, nil
+}
+
+// 实体对应的更新事件
+var updatedEvents = map[string]string{
+	EntitySkill:      events.SkillUpdated,
+	EntityExperience: events.ExperienceUpdated,
+	EntityProject:    events.ProjectUpdated,
+}
+
+// 技术名称数组被直接改写的实体没有经过各自的更新接口，发布更新事件使搜索索引、订阅等保持同步
+func publishUpdated(changed map[string][]int) {
+	for entity, ids := range changed {
+		for _, id := range ids {
+			events.Publish(updatedEvents[entity], map[string]int{"id": id})
+		}
+	}
+}
+
+// 规范化某一类实体的技术名称数组，返回被改写的实体ID
+func normalizeEntity(entity string) ([]int, error) {
+	info := entities[entity]
+	rows, err := database.DB.Query("SELECT id, COALESCE(" + info.column + ", '') FROM " + info.table)
+	if err != nil {
+		return nil, err
+	}
+
+	type record struct {
+		id    int
+		names []string
+		raw   string
+	}
+	var records []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.raw); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if r.raw != "" {
+			if err := json.Unmarshal([]byte(r.raw), &r.names); err != nil {
+				log.Printf("解析%s %d的技术名称失败，跳过: %v", info.table, r.id, err)
+				continue
+			}
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var changed []int
+	for _, r := range records {
+		names, err := Normalize(r.names)
+		if err != nil {
+			return nil, err
+		}
+		if encoded, _ := json.Marshal(names); r.raw != "" && string(encoded) != r.raw {
+			if _, err := database.DB.Exec(
+				"UPDATE "+info.table+" SET "+info.column+" = ? WHERE id = ?", string(encoded), r.id); err != nil {
+				return nil, err
+			}
+			changed = append(changed, r.id)
+		}
+		if err := Link(entity, r.id, names); err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+// Update 修改技术的名称、分类、说明和别名；原名称保留为别名，避免旧数据无法匹配
+func Update(id int, name, category, description string, aliases []string) error {
+	var oldName string
+	if err := database.DB.QueryRow("SELECT name FROM technologies WHERE id = ?", id).Scan(&oldName); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	names := append([]string{name, oldName}, aliases...)
+	for _, alias := range names {
+		owner, err := aliasOwner(alias)
+		if err != nil {
+			return err
+		}
+		if owner != 0 && owner != id {
+			return ErrAliasConflict
+		}
+	}
+
+	if _, err := database.DB.Exec(
+		"UPDATE technologies SET name = ?, category = ?, description = ? WHERE id = ?",
+		name, category, description, id); err != nil {
+		return err
+	}
+	if _, err := database.DB.Exec("DELETE FROM technology_aliases WHERE technology_id = ?", id); err != nil {
+		return err
+	}
+	if err := addAliases(id, names); err != nil {
+		return err
+	}
+
+	changed, err := normalizeAll()
+	if err != nil {
+		return err
+	}
+	publishUpdated(changed)
+	return nil
+}
+
+// Merge 将source合并到target：source的名称和别名都成为target的别名，随后删除source
+func Merge(targetID, sourceID int) error {
+	if targetID == sourceID {
+		return errors.New("不能将技术合并到自身")
+	}
+	for _, id := range []int{targetID, sourceID} {
+		var exists int
+		if err := database.DB.QueryRow("SELECT 1 FROM technologies WHERE id = ?", id).Scan(&exists); err != nil {
+			return err
+		}
+	}
+
+	if _, err := database.DB.Exec(
+		"UPDATE technology_aliases SET technology_id = ? WHERE technology_id = ?", targetID, sourceID); err != nil {
+		return err
+	}
+	for entity := range entities {
+		if _, err := database.DB.Exec("DELETE FROM "+entity+"_technologies WHERE technology_id = ?", sourceID); err != nil {
+			return err
+		}
+	}
+	if _, err := database.DB.Exec("DELETE FROM technologies WHERE id = ?", sourceID); err != nil {
+		return err
+	}
+
+	changed, err := normalizeAll()
+	if err != nil {
+		return err
+	}
+	publishUpdated(changed)
+	return nil
+}
+
+// Delete 删除技术，并从所有技能、工作经历和项目的技术名称中移除
+func Delete(id int) error {
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM technologies WHERE id = ?", id).Scan(&exists); err != nil {
+		return err
+	}
+
+	changed := map[string][]int{}
+	for entity, info := range entities {
+		rows, err := database.DB.Query("SELECT "+entity+"_id FROM "+entity+"_technologies WHERE technology_id = ?", id)
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for rows.Next() {
+			var entityID int
+			if err := rows.Scan(&entityID); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, entityID)
+		}
+		rows.Close()
+
+		if _, err := database.DB.Exec("DELETE FROM "+entity+"_technologies WHERE technology_id = ?", id); err != nil {
+			return err
+		}
+
+		// 按剩余的关联重写JSON数组
+		for _, entityID := range ids {
+			names, err := linkedNames(entity, entityID)
+			if err != nil {
+				return err
+			}
+			encoded, _ := json.Marshal(names)
+			if _, err := database.DB.Exec(
+				"UPDATE "+info.table+" SET "+info.column+" = ? WHERE id = ?", string(encoded), entityID); err != nil {
+				return err
+			}
+		}
+		changed[entity] = ids
+	}
+
+	if _, err := database.DB.Exec("DELETE FROM technology_aliases WHERE technology_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := database.DB.Exec("DELETE FROM technologies WHERE id = ?", id); err != nil {
+		return err
+	}
+	publishUpdated(changed)
+	return nil
+}
+
+// 按关联表中的顺序返回实体的技术名称
+func linkedNames(entity string, id int) ([]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT t.name FROM `+entity+`_technologies et
+		JOIN technologies t ON t.id = et.technology_id
+		WHERE et.`+entity+`_id = ?
+		ORDER BY et.position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// List 返回全部技术及其别名和使用次数，category非空时只返回该分类
+func List(category string) ([]models.Technology, error) {
+	query := `
+		SELECT t.id, t.slug, t.name, COALESCE(t.category, ''), COALESCE(t.description, ''),
+		(SELECT COUNT(*) FROM skill_technologies WHERE technology_id = t.id) +
+		(SELECT COUNT(*) FROM experience_technologies WHERE technology_id = t.id) +
+		(SELECT COUNT(*) FROM project_technologies WHERE technology_id = t.id)
+		FROM technologies t`
+	var args []interface{}
+	if category != "" {
+		query += " WHERE t.category = ?"
+		args = append(args, category)
+	}
+	query += " ORDER BY t.category, t.name"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	technologies := []models.Technology{}
+	for rows.Next() {
+		var t models.Technology
+		if err := rows.Scan(&t.ID, &t.Slug, &t.Name, &t.Category, &t.Description, &t.UsageCount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		technologies = append(technologies, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	aliases, err := aliasesByTechnology()
+	if err != nil {
+		return nil, err
+	}
+	for i := range technologies {
+		technologies[i].Aliases = displayAliases(technologies[i].Name, aliases[technologies[i].ID])
+	}
+	return technologies, nil
+}
+
+// 读取所有别名，按技术ID分组
+func aliasesByTechnology() (map[int][]string, error) {
+	rows, err := database.DB.Query("SELECT technology_id, alias, name FROM technology_aliases ORDER BY alias")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var key, alias string
+		if err := rows.Scan(&id, &key, &alias); err != nil {
+			return nil, err
+		}
+		aliases[id] = append(aliases[id], alias)
+	}
+	return aliases, rows.Err()
+}
+
+// 别名列表中去掉与规范名称匹配键相同的项
+func displayAliases(name string, names []string) []string {
+	aliases := []string{}
+	for _, alias := range names {
+		if aliasKey(alias) != aliasKey(name) {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// Get 获取单项技术及其别名
+func Get(id int) (models.Technology, error) {
+	var t models.Technology
+	err := database.DB.QueryRow(`
+		SELECT id, slug, name, COALESCE(category, ''), COALESCE(description, '')
+		FROM technologies WHERE id = ?`, id).Scan(&t.ID, &t.Slug, &t.Name, &t.Category, &t.Description)
+	if err != nil {
+		return t, err
+	}
+
+	aliases, err := aliasesByTechnology()
+	if err != nil {
+		return t, err
+	}
+	t.Aliases = displayAliases(t.Name, aliases[t.ID])
+	return t, nil
+}
+
+// Usage 根据slug(或名称、别名)查询技术在技能、工作经历和项目中的使用情况
+func Usage(slug string) (models.TechnologyUsage, error) {
+	var usage models.TechnologyUsage
+
+	var id int
+	err := database.DB.QueryRow("SELECT id FROM technologies WHERE slug = ?", slug).Scan(&id)
+	if err == sql.ErrNoRows {
+		id, _, err = lookup(slug)
+	}
+	if err != nil {
+		return usage, err
+	}
+
+	if usage.Technology, err = Get(id); err != nil {
+		return usage, err
+	}
+	t := &usage.Technology
+
+	usage.Skills = []models.TechnologySkillRef{}
+	rows, err := database.DB.Query(`
+		SELECT s.id, s.name, COALESCE(s.level, 0) FROM skill_technologies st
+		JOIN skills s ON s.id = st.skill_id
+		WHERE st.technology_id = ? ORDER BY s.level DESC, s.id`, t.ID)
+	if err != nil {
+		return usage, err
+	}
+	for rows.Next() {
+		var ref models.TechnologySkillRef
+		if err := rows.Scan(&ref.ID, &ref.Name, &ref.Level); err != nil {
+			rows.Close()
+			return usage, err
+		}
+		usage.Skills = append(usage.Skills, ref)
+	}
+	rows.Close()
+
+	usage.Experiences = []models.TechnologyExperienceRef{}
+	rows, err = database.DB.Query(`
+		SELECT e.id, e.title, e.company, e.period FROM experience_technologies et
+		JOIN experiences e ON e.id = et.experience_id
+		WHERE et.technology_id = ? ORDER BY e.sort_order, e.id`, t.ID)
+	if err != nil {
+		return usage, err
+	}
+	for rows.Next() {
+		var ref models.TechnologyExperienceRef
+		if err := rows.Scan(&ref.ID, &ref.Title, &ref.Company, &ref.Period); err != nil {
+			rows.Close()
+			return usage, err
+		}
+		usage.Experiences = append(usage.Experiences, ref)
+	}
+	rows.Close()
+
+	usage.Projects = []models.TechnologyProjectRef{}
+	rows, err = database.DB.Query(`
+		SELECT p.id, p.title, COALESCE(p.category, '') FROM project_technologies pt
+		JOIN projects p ON p.id = pt.project_id
+		WHERE pt.technology_id = ? ORDER BY p.sort_order, p.id`, t.ID)
+	if err != nil {
+		return usage, err
+	}
+	for rows.Next() {
+		var ref models.TechnologyProjectRef
+		if err := rows.Scan(&ref.ID, &ref.Title, &ref.Category); err != nil {
+			rows.Close()
+			return usage, err
+		}
+		usage.Projects = append(usage.Projects, ref)
+	}
+	rows.Close()
+
+	t.UsageCount = len(usage.Skills) + len(usage.Experiences) + len(usage.Projects)
+	return usage, nil
+}
@@ -0,0 +1,368 @@
+package tenants
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/models"
+)
+
+// Run 以多租户网关模式运行：按Host或路径前缀 /t/<slug>/ 将请求转发到对应租户的后端进程，
+// 并在 /api/super 下提供超级管理员接口
+func Run(port string) error {
+	if err := openRegistry(config.DataDir); err != nil {
+		return err
+	}
+	if config.SuperToken == "" {
+		log.Printf("未设置SUPER_ADMIN_TOKEN，超级管理员接口不可用")
+	}
+
+	for _, t := range List() {
+		if t.Status != StatusActive {
+			continue
+		}
+		if err := start(t.Slug, t.Port, nil); err != nil {
+			log.Printf("启动租户%s失败: %v", t.Slug, err)
+		}
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.Default()
+
+	super := r.Group("/api/super")
+	super.Use(superAdminMiddleware())
+	{
+		super.GET("/tenants", getTenants)
+		super.POST("/tenants", createTenant)
+		super.PUT("/tenants/:slug", updateTenant)
+		super.POST("/tenants/:slug/suspend", suspendTenant)
+		super.POST("/tenants/:slug/resume", resumeTenant)
+	}
+
+	r.NoRoute(proxyTenant)
+
+	// 退出时停止所有租户进程
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Printf("正在停止所有租户")
+		StopAll()
+		os.Exit(0)
+	}()
+
+	log.Printf("多租户网关已启动，运行在 http://localhost:%s", port)
+	return r.Run(":" + port)
+}
+
+// 将请求转发到租户进程，路径前缀方式访问时去掉前缀
+func proxyTenant(c *gin.Context) {
+	path := c.Request.URL.Path
+	t, ok := byHost(c.Request.Host)
+	prefix := ""
+	if !ok && strings.HasPrefix(path, "/t/") {
+		slug := strings.SplitN(strings.TrimPrefix(path, "/t/"), "/", 2)[0]
+		if t, ok = Get(slug); ok {
+			prefix = "/t/" + slug
+			if path == prefix {
+				c.Redirect(http.StatusMovedPermanently, prefix+"/")
+				return
+			}
+		}
+	}
+
+	if !ok {
+		// 各租户共用同一份前端，路径前缀方式访问时静态资源直接由网关提供
+		if strings.HasPrefix(path, "/assets/") || path == "/favicon.ico" {
+			c.File("./public" + path)
+			return
+		}
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到对应的作品集",
+		})
+		return
+	}
+	if t.Status != StatusActive {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "该作品集已停用",
+		})
+		return
+	}
+	if !running(t.Slug) {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Message: "作品集服务暂不可用，请稍后再试",
+		})
+		return
+	}
+
+	target := &url.URL{Scheme: "http", Host: "127.0.0.1:" + strconv.Itoa(t.Port)}
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			if prefix != "" {
+				req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+				req.URL.RawPath = ""
+				if req.URL.Path == "" {
+					req.URL.Path = "/"
+				}
+				req.Header.Set("X-Forwarded-Prefix", prefix)
+			}
+			req.Header.Set("X-Forwarded-Host", req.Host)
+			req.Header.Set("X-Forwarded-Proto", forwardedProto(c))
+			req.Header.Set("X-Tenant", t.Slug)
+		},
+		// 立即刷新，保证SSE事件流实时送达
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			log.Printf("转发到租户%s失败: %v", t.Slug, err)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"success":false,"message":"作品集服务暂不可用，请稍后再试"}`))
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+// 超级管理员接口使用SUPER_ADMIN_TOKEN验证
+func superAdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.SuperToken == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.APIResponse{
+				Success: false,
+				Message: "未配置SUPER_ADMIN_TOKEN，超级管理员接口不可用",
+			})
+			return
+		}
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(config.SuperToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "需要超级管理员权限",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// 获取所有租户
+func getTenants(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "获取租户列表成功",
+		Data:    List(),
+	})
+}
+
+// 创建租户并启动其后端进程，返回只显示一次的初始管理员和访客密码
+func createTenant(c *gin.Context) {
+	var req models.Tenant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的租户数据: " + err.Error(),
+		})
+		return
+	}
+	if req.Name == "" {
+		req.Name = req.Slug
+	}
+
+	// 租户目录已存在说明有遗留数据，避免新租户继承其他人的内容
+	if _, err := os.Stat(tenantDir(req.Slug)); err == nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "租户数据目录已存在，请先清理 " + tenantDir(req.Slug),
+		})
+		return
+	}
+
+	t, err := create(req.Slug, req.Name, req.Hosts)
+	if err != nil {
+		respondTenantError(c, err, "创建租户失败")
+		return
+	}
+
+	adminPassword, err := randomPassword()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成初始密码失败",
+		})
+		return
+	}
+	visitorPassword, err := randomPassword()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "生成初始密码失败",
+		})
+		return
+	}
+	initialEnv := []string{
+		"INITIAL_ADMIN_PASSWORD=" + adminPassword,
+		"INITIAL_VISITOR_PASSWORD=" + visitorPassword,
+	}
+	if err := start(t.Slug, t.Port, initialEnv); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "启动租户失败: " + err.Error(),
+		})
+		return
+	}
+	t.Running = true
+
+	log.Printf("已创建租户%s", t.Slug)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "创建租户成功，请妥善保存初始密码",
+		Data: models.TenantCredentials{
+			Tenant:          t,
+			AdminUsername:   "admin",
+			AdminPassword:   adminPassword,
+			VisitorPassword: visitorPassword,
+		},
+	})
+}
+
+// 修改租户名称和绑定的域名
+func updateTenant(c *gin.Context) {
+	var req struct {
+		Name  string   `json:"name" binding:"required"`
+		Hosts []string `json:"hosts"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "无效的租户数据: " + err.Error(),
+		})
+		return
+	}
+
+	slug := c.Param("slug")
+	if err := update(slug, req.Name, req.Hosts); err != nil {
+		respondTenantError(c, err, "更新租户失败")
+		return
+	}
+
+	t, _ := Get(slug)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "更新租户成功",
+		Data:    t,
+	})
+}
+
+// 停用租户：停止其后端进程，数据保留，访问时返回403
+func suspendTenant(c *gin.Context) {
+	slug := c.Param("slug")
+	if err := setStatus(slug, StatusSuspended); err != nil {
+		respondTenantError(c, err, "停用租户失败")
+		return
+	}
+	stop(slug)
+
+	log.Printf("已停用租户%s", slug)
+	t, _ := Get(slug)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "停用租户成功",
+		Data:    t,
+	})
+}
+
+// 恢复已停用的租户
+func resumeTenant(c *gin.Context) {
+	slug := c.Param("slug")
+	if err := setStatus(slug, StatusActive); err != nil {
+		respondTenantError(c, err, "恢复租户失败")
+		return
+	}
+	t, _ := Get(slug)
+	if err := start(slug, t.Port, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "启动租户失败: " + err.Error(),
+		})
+		return
+	}
+
+	log.Printf("已恢复租户%s", slug)
+	t.Running = true
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "恢复租户成功",
+		Data:    t,
+	})
+}
+
+// 输出租户操作的错误
+func respondTenantError(c *gin.Context, err error, message string) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "未找到指定租户",
+		})
+	case ErrInvalidSlug:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	case ErrSlugTaken, ErrHostTaken:
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message + ": " + err.Error(),
+		})
+	}
+}
+
+// 生成随机初始密码
+func randomPassword() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// 判断来源地址是否为网关前面可信的反向代理，由主程序设置
+var trustedProxy = func(remoteIP string) bool { return false }
+
+// SetProxyTrust 设置判断可信反向代理的方法，只有可信代理传来的X-Forwarded-Proto会继续转发给租户
+func SetProxyTrust(fn func(remoteIP string) bool) {
+	trustedProxy = fn
+}
+
+// 转发给租户的协议，覆盖客户端自带的X-Forwarded-Proto
+func forwardedProto(c *gin.Context) string {
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); (proto == "http" || proto == "https") && trustedProxy(c.RemoteIP()) {
+		return proto
+	}
+	return "http"
+}
@@ -0,0 +1,256 @@
+package tenants
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/models"
+)
+
+// 租户状态
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+)
+
+var (
+	// ErrInvalidSlug 租户标识不合法
+	ErrInvalidSlug = errors.New("租户标识只能包含小写字母、数字和连字符，长度2-32且不能以连字符开头")
+	// ErrSlugTaken 租户标识已被使用
+	ErrSlugTaken = errors.New("租户标识已被使用")
+	// ErrHostTaken 域名已被其他租户使用
+	ErrHostTaken = errors.New("域名已被其他租户使用")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,31}$`)
+
+// 不能作为租户标识的保留字，避免与路径前缀和子域名冲突
+var reservedSlugs = map[string]bool{
+	"api": true, "admin": true, "assets": true, "t": true, "super": true, "www": true,
+}
+
+// 租户注册表保存在数据目录下独立的tenants.db中，各租户的内容库互不相干
+var (
+	registry *sql.DB
+	mu       sync.RWMutex
+	cache    = map[string]models.Tenant{} // 按标识缓存，变更时整体刷新
+)
+
+// 打开租户注册表
+func openRegistry(dataDir string) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(dataDir, "tenants.db"))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS tenants (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		slug TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		hosts TEXT NOT NULL DEFAULT '[]',
+		status TEXT NOT NULL DEFAULT 'active',
+		created_at TIMESTAMP NOT NULL,
+		suspended_at TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	registry = db
+	return reload()
+}
+
+// 重新加载租户缓存
+func reload() error {
+	rows, err := registry.Query("SELECT id, slug, name, hosts, status, created_at, suspended_at FROM tenants ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	loaded := map[string]models.Tenant{}
+	for rows.Next() {
+		var t models.Tenant
+		var hostsJSON string
+		var suspendedAt sql.NullTime
+		if err := rows.Scan(&t.ID, &t.Slug, &t.Name, &hostsJSON, &t.Status, &t.CreatedAt, &suspendedAt); err != nil {
+			return err
+		}
+		t.Hosts = []string{}
+		json.Unmarshal([]byte(hostsJSON), &t.Hosts)
+		if suspendedAt.Valid {
+			t.SuspendedAt = &suspendedAt.Time
+		}
+		t.Port = config.PortBase + t.ID
+		loaded[t.Slug] = t
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	cache = loaded
+	mu.Unlock()
+	return nil
+}
+
+// List 获取所有租户
+func List() []models.Tenant {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]models.Tenant, 0, len(cache))
+	for _, t := range cache {
+		t.Running = running(t.Slug)
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Get 根据标识获取租户
+func Get(slug string) (models.Tenant, bool) {
+	mu.RLock()
+	t, ok := cache[slug]
+	mu.RUnlock()
+	if ok {
+		t.Running = running(slug)
+	}
+	return t, ok
+}
+
+// 根据Host查找租户：先匹配租户绑定的域名，再匹配 <slug>.<TENANT_BASE_DOMAIN>
+func byHost(host string) (models.Tenant, bool) {
+	host = normalizeHost(host)
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, t := range cache {
+		for _, h := range t.Hosts {
+			if h == host {
+				return t, true
+			}
+		}
+	}
+	if config.BaseDomain != "" && strings.HasSuffix(host, "."+config.BaseDomain) {
+		t, ok := cache[strings.TrimSuffix(host, "."+config.BaseDomain)]
+		return t, ok
+	}
+	return models.Tenant{}, false
+}
+
+// 创建租户记录
+func create(slug, name string, hosts []string) (models.Tenant, error) {
+	if !slugPattern.MatchString(slug) || reservedSlugs[slug] {
+		return models.Tenant{}, ErrInvalidSlug
+	}
+	hosts = normalizeHosts(hosts)
+	if err := checkHosts(slug, hosts); err != nil {
+		return models.Tenant{}, err
+	}
+	if _, exists := Get(slug); exists {
+		return models.Tenant{}, ErrSlugTaken
+	}
+
+	hostsJSON, _ := json.Marshal(hosts)
+	_, err := registry.Exec(
+		"INSERT INTO tenants (slug, name, hosts, status, created_at) VALUES (?, ?, ?, ?, ?)",
+		slug, name, string(hostsJSON), StatusActive, time.Now(),
+	)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	if err := reload(); err != nil {
+		return models.Tenant{}, err
+	}
+	t, _ := Get(slug)
+	return t, nil
+}
+
+// 修改租户名称和绑定的域名
+func update(slug, name string, hosts []string) error {
+	hosts = normalizeHosts(hosts)
+	if err := checkHosts(slug, hosts); err != nil {
+		return err
+	}
+	hostsJSON, _ := json.Marshal(hosts)
+	result, err := registry.Exec("UPDATE tenants SET name = ?, hosts = ? WHERE slug = ?", name, string(hostsJSON), slug)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return reload()
+}
+
+// 修改租户状态
+func setStatus(slug, status string) error {
+	var suspendedAt interface{}
+	if status == StatusSuspended {
+		suspendedAt = time.Now()
+	}
+	result, err := registry.Exec("UPDATE tenants SET status = ?, suspended_at = ? WHERE slug = ?", status, suspendedAt, slug)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return reload()
+}
+
+// 检查域名是否已被其他租户使用
+func checkHosts(slug string, hosts []string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, t := range cache {
+		if t.Slug == slug {
+			continue
+		}
+		for _, h := range t.Hosts {
+			for _, host := range hosts {
+				if h == host {
+					return ErrHostTaken
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeHosts(hosts []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, h := range hosts {
+		h = normalizeHost(h)
+		if h != "" && !seen[h] {
+			seen[h] = true
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// 去掉端口并转为小写
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,274 @@
+package tenants
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 多租户网关配置，全部从环境变量读取
+type Config struct {
+	DataDir     string // 租户注册表和各租户数据目录的上级目录
+	SuperToken  string // 超级管理员接口的访问令牌，为空时接口不可用
+	BaseDomain  string // 设置后 <slug>.<BaseDomain> 自动解析到对应租户
+	PortBase    int    // 租户进程监听 127.0.0.1:PortBase+租户ID
+	Secret      string // 派生各租户令牌签名密钥的主密钥
+	StopTimeout time.Duration
+}
+
+var config = LoadConfig()
+
+// LoadConfig 从环境变量加载多租户配置
+func LoadConfig() Config {
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "./data"
+	}
+	portBase, err := strconv.Atoi(os.Getenv("TENANT_PORT_BASE"))
+	if err != nil || portBase <= 0 {
+		portBase = 19000
+	}
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your-secret-key"
+	}
+	return Config{
+		DataDir:     dataDir,
+		SuperToken:  os.Getenv("SUPER_ADMIN_TOKEN"),
+		BaseDomain:  normalizeHost(os.Getenv("TENANT_BASE_DOMAIN")),
+		PortBase:    portBase,
+		Secret:      secret,
+		StopTimeout: 5 * time.Second,
+	}
+}
+
+// Enabled 是否以多租户网关模式运行
+func Enabled() bool {
+	return os.Getenv("MULTI_TENANT") == "true"
+}
+
+// 每个租户是一个独立的后端进程，使用各自的数据目录和令牌密钥，
+// 因此管理员和访客令牌只在签发它的租户内有效
+type process struct {
+	cmd      *exec.Cmd
+	stopping bool
+	done     chan struct{}
+	// 仅在创建租户后的第一次启动时传入，用于初始化管理员和访客密码
+	initialEnv []string
+}
+
+var (
+	procMu    sync.Mutex
+	processes = map[string]*process{}
+)
+
+// 租户的数据目录
+func tenantDir(slug string) string {
+	return filepath.Join(config.DataDir, "tenants", slug)
+}
+
+// 由主密钥为租户派生独立的密钥
+func deriveSecret(slug, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(config.Secret))
+	mac.Write([]byte(purpose + ":" + slug))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 判断租户进程是否在运行
+func running(slug string) bool {
+	procMu.Lock()
+	defer procMu.Unlock()
+	p, ok := processes[slug]
+	return ok && !p.stopping
+}
+
+// 启动租户进程，已在运行时不做处理。initialEnv只用于首次初始化数据库
+func start(slug string, port int, initialEnv []string) error {
+	procMu.Lock()
+	defer procMu.Unlock()
+	if p, ok := processes[slug]; ok && !p.stopping {
+		return nil
+	}
+
+	if err := os.MkdirAll(tenantDir(slug), 0755); err != nil {
+		return err
+	}
+	p := &process{initialEnv: initialEnv}
+	if err := p.spawn(slug, port); err != nil {
+		return err
+	}
+	processes[slug] = p
+	go supervise(slug, port, p)
+	return nil
+}
+
+// 以当前可执行文件启动租户进程，工作目录与网关相同以共用前端静态文件
+func (p *process) spawn(slug string, port int) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable)
+	cmd.Env = append(childEnv(),
+		"PORT="+strconv.Itoa(port),
+		"HOST=127.0.0.1",
+		"DATA_DIR="+tenantDir(slug),
+		"JWT_SECRET="+deriveSecret(slug, "jwt"),
+		"VISITOR_SECRET="+deriveSecret(slug, "visitor"),
+		"TENANT_SLUG="+slug,
+		// 租户进程只通过网关访问，信任网关(以及网关信任的代理)转发的客户端IP
+		"TRUSTED_PROXIES="+strings.TrimSuffix("127.0.0.1,"+os.Getenv("TRUSTED_PROXIES"), ","),
+	)
+	cmd.Env = append(cmd.Env, p.initialEnv...)
+	cmd.Stdout = &prefixWriter{prefix: "[" + slug + "] ", out: os.Stdout}
+	cmd.Stderr = &prefixWriter{prefix: "[" + slug + "] ", out: os.Stderr}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	p.cmd = cmd
+	p.initialEnv = nil
+	p.done = make(chan struct{})
+	log.Printf("租户%s已启动，进程%d，端口%d", slug, cmd.Process.Pid, port)
+	return nil
+}
+
+// 网关自身的环境变量中去掉多租户和密钥相关的变量后传给租户进程
+func childEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		switch name {
+		case "MULTI_TENANT", "SUPER_ADMIN_TOKEN", "JWT_SECRET", "VISITOR_SECRET", "PORT", "HOST", "DATA_DIR", "TRUSTED_PROXIES",
+			"INITIAL_ADMIN_PASSWORD", "INITIAL_VISITOR_PASSWORD":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// 监控租户进程，异常退出时按指数退避重启，停用时不再重启
+func supervise(slug string, port int, p *process) {
+	backoff := time.Second
+	for {
+		startedAt := time.Now()
+		err := p.cmd.Wait()
+		close(p.done)
+
+		procMu.Lock()
+		if p.stopping {
+			if processes[slug] == p {
+				delete(processes, slug)
+			}
+			procMu.Unlock()
+			log.Printf("租户%s已停止", slug)
+			return
+		}
+		procMu.Unlock()
+
+		// 运行超过一分钟后再退出视为偶发故障，重置退避时间
+		if time.Since(startedAt) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("租户%s进程退出(%v)，%v后重启", slug, err, backoff)
+		time.Sleep(backoff)
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+
+		procMu.Lock()
+		if p.stopping {
+			delete(processes, slug)
+			procMu.Unlock()
+			return
+		}
+		err = p.spawn(slug, port)
+		procMu.Unlock()
+		if err != nil {
+			log.Printf("重启租户%s失败: %v", slug, err)
+			procMu.Lock()
+			delete(processes, slug)
+			procMu.Unlock()
+			return
+		}
+	}
+}
+
+// 停止租户进程：先发送中断信号，超时后强制结束
+func stop(slug string) {
+	procMu.Lock()
+	p, ok := processes[slug]
+	if !ok || p.stopping {
+		procMu.Unlock()
+		return
+	}
+	p.stopping = true
+	cmd, done := p.cmd, p.done
+	procMu.Unlock()
+
+	cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-done:
+	case <-time.After(config.StopTimeout):
+		cmd.Process.Kill()
+		<-done
+	}
+}
+
+// StopAll 停止所有租户进程，网关退出时调用
+func StopAll() {
+	procMu.Lock()
+	slugs := make([]string, 0, len(processes))
+	for slug := range processes {
+		slugs = append(slugs, slug)
+	}
+	procMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, slug := range slugs {
+		wg.Add(1)
+		go func(slug string) {
+			defer wg.Done()
+			stop(slug)
+		}(slug)
+	}
+	wg.Wait()
+}
+
+// 给租户进程的每行输出加上租户标识
+type prefixWriter struct {
+	prefix string
+	out    *os.File
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, data...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.out.Write(append([]byte(w.prefix), w.buf[:i+1]...))
+		w.buf = w.buf[i+1:]
+	}
+	return len(data), nil
+}
@@ -0,0 +1,150 @@
+// Package vcard 生成vCard 4.0(RFC 6350)电子名片
+package vcard
+
+import (
+	"encoding/base64"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"backend/markdown"
+	"backend/models"
+	"backend/seo"
+)
+
+// 前端静态文件目录，站内的头像从这里读取并嵌入名片
+const publicDir = "./public"
+
+// 嵌入名片的头像文件大小上限，过大的头像改为引用地址
+const maxEmbeddedPhoto = 256 << 10
+
+// Options 生成名片的选项
+type Options struct {
+	SiteURL    string // 站点地址，写入URL字段并用于补全头像地址
+	EmbedPhoto bool   // 站内头像是否以data URI嵌入，二维码中的名片不嵌入以控制长度
+}
+
+// Build 根据个人信息生成名片，邮箱和电话为空时不输出
+func Build(profile models.Profile, opts Options) string {
+	var b strings.Builder
+	line := func(name, value string) {
+		if value == "" {
+			return
+		}
+		writeFolded(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCARD")
+	line("VERSION", "4.0")
+	line("FN", escape(profile.Name))
+	// 无法可靠地拆分姓和名，整个姓名作为姓
+	line("N", escape(profile.Name)+";;;;")
+	line("TITLE", escape(profile.Title))
+	if profile.Email != "" {
+		line("EMAIL;TYPE=work", escape(profile.Email))
+	}
+	if profile.Phone != "" {
+		line("TEL;VALUE=uri;TYPE=cell", "tel:"+telURI(profile.Phone))
+	}
+	if profile.Location != "" {
+		line("ADR;TYPE=work;LABEL="+quote(profile.Location), ";;;"+escape(profile.Location)+";;;")
+	}
+	line("URL", opts.SiteURL)
+	line("PHOTO", photo(profile.Avatar, opts))
+	line("NOTE", escape(seo.Summary(markdown.Text(profile.Introduction), 500)))
+	if !profile.LastUpdated.IsZero() {
+		line("REV", profile.LastUpdated.UTC().Format("20060102T150405Z"))
+	}
+	line("PRODID", "-//cv-portfolio//vCard//ZH")
+	line("END", "VCARD")
+	return b.String()
+}
+
+// FileName 名片文件名，只保留姓名中可安全用于文件名的字符
+func FileName(profile models.Profile) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>|`, r) || r < 0x20 {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(profile.Name))
+	if name == "" {
+		name = "contact"
+	}
+	return name + ".vcf"
+}
+
+// 头像：站内文件嵌入为data URI，外部地址直接引用
+func photo(avatar string, opts Options) string {
+	avatar = strings.TrimSpace(avatar)
+	if opts.EmbedPhoto && strings.HasPrefix(avatar, "/") && !strings.HasPrefix(avatar, "//") {
+		if uri := dataURI(avatar); uri != "" {
+			return uri
+		}
+	}
+	return seo.AbsoluteURL(opts.SiteURL, avatar)
+}
+
+func dataURI(path string) string {
+	clean := filepath.Join(publicDir, filepath.FromSlash(filepath.Clean("/"+path)))
+	contentType := mime.TypeByExtension(filepath.Ext(clean))
+	if !strings.HasPrefix(contentType, "image/") {
+		return ""
+	}
+	info, err := os.Stat(clean)
+	if err != nil || info.IsDir() || info.Size() > maxEmbeddedPhoto {
+		return ""
+	}
+	raw, err := os.ReadFile(clean)
+	if err != nil {
+		return ""
+	}
+	contentType = strings.SplitN(contentType, ";", 2)[0]
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(raw)
+}
+
+// 电话号码只保留数字和开头的+，其余分隔符改为-
+func telURI(phone string) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+			if s := b.String(); s != "" && !strings.HasSuffix(s, "-") {
+				b.WriteByte('-')
+			}
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// 文本值中的反斜杠、逗号、分号和换行需要转义
+func escape(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`).Replace(value)
+}
+
+// 参数值加引号，去掉不能出现在引号内的字符
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(value) + `"`
+}
+
+// 每行不超过75字节，续行以空格开头，不拆开多字节字符
+func writeFolded(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
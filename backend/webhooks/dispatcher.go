@@ -0,0 +1,238 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/database"
+	"backend/events"
+	"backend/retry"
+)
+
+const (
+	maxAttempts     = 8                // 最多投递次数
+	pollInterval    = 15 * time.Second // 投递队列轮询间隔
+	batchSize       = 20               // 每轮最多投递的记录数
+	maxResponseBody = 1024             // 投递记录中保存的响应内容长度上限
+)
+
+// 首次重试等待30秒，之后每次翻倍，最长1小时
+var backoff = retry.Backoff{Base: 30 * time.Second, Max: time.Hour}
+
+var (
+	client = &http.Client{Timeout: 10 * time.Second}
+	wake   = make(chan struct{}, 1)
+)
+
+// Start 订阅事件中心并启动后台投递协程
+func Start() {
+	events.Watch(256, nil, enqueue)
+	go worker()
+}
+
+// Sign 计算签名：HMAC-SHA256(secret, timestamp + "." + body)，十六进制编码
+// 接收方应使用相同算法校验 X-Webhook-Signature 请求头，并拒绝时间戳过旧的请求以防重放
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Redeliver 以原有内容重新创建一条投递记录，返回新记录ID
+func Redeliver(deliveryID int) (int64, error) {
+	result, err := database.DB.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at, created_at)
+		SELECT webhook_id, event_id, event_type, payload, ?, ? FROM webhook_deliveries WHERE id = ?`,
+		time.Now(), time.Now(), deliveryID)
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return 0, sql.ErrNoRows
+	}
+	trigger()
+	return result.LastInsertId()
+}
+
+// 为匹配该事件的每个启用中的回调地址创建投递记录
+func enqueue(event events.Event) {
+	rows, err := database.DB.Query("SELECT id, events FROM webhooks WHERE active = 1")
+	if err != nil {
+		log.Printf("查询Webhook失败: %v", err)
+		return
+	}
+
+	var targets []int
+	for rows.Next() {
+		var id int
+		var eventsJSON string
+		if err := rows.Scan(&id, &eventsJSON); err != nil {
+			continue
+		}
+		var patterns []string
+		json.Unmarshal([]byte(eventsJSON), &patterns)
+		for _, p := range patterns {
+			if events.Match(p, event.Type) {
+				targets = append(targets, id)
+				break
+			}
+		}
+	}
+	rows.Close()
+
+	if len(targets) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("序列化Webhook事件失败: %v", err)
+		return
+	}
+
+	for _, id := range targets {
+		_, err := database.DB.Exec(`
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			id, event.ID, event.Type, string(payload), time.Now(), time.Now())
+		if err != nil {
+			log.Printf("创建Webhook投递记录失败: %v", err)
+		}
+	}
+	trigger()
+}
+
+// 唤醒投递协程
+func trigger() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// 后台投递协程
+func worker() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		processDeliveries()
+
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+type pendingDelivery struct {
+	id        int
+	webhookID int
+	eventType string
+	payload   string
+	attempts  int
+	url       string
+	secret    string
+}
+
+// 投递一批到期的记录
+func processDeliveries() {
+	rows, err := database.DB.Query(`
+		SELECT d.id, d.webhook_id, d.event_type, d.payload, d.attempts, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at LIMIT ?`, time.Now(), batchSize)
+	if err != nil {
+		log.Printf("查询Webhook投递队列失败: %v", err)
+		return
+	}
+
+	var batch []pendingDelivery
+	for rows.Next() {
+		var d pendingDelivery
+		if err := rows.Scan(&d.id, &d.webhookID, &d.eventType, &d.payload, &d.attempts, &d.url, &d.secret); err != nil {
+			log.Printf("读取Webhook投递记录失败: %v", err)
+			continue
+		}
+		batch = append(batch, d)
+	}
+	rows.Close()
+
+	for _, d := range batch {
+		deliver(d)
+	}
+}
+
+// 发送一次回调请求并记录结果
+func deliver(d pendingDelivery) {
+	attempts := d.attempts + 1
+	status, body, err := post(d)
+
+	if err == nil && status >= 200 && status < 300 {
+		database.DB.Exec(`
+			UPDATE webhook_deliveries SET status = 'success', attempts = ?, response_status = ?, response_body = ?,
+			last_error = NULL, delivered_at = ? WHERE id = ?`,
+			attempts, status, body, time.Now(), d.id)
+		log.Printf("Webhook投递成功，投递ID: %d，事件: %s", d.id, d.eventType)
+		return
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	} else {
+		errMsg = "HTTP " + strconv.Itoa(status)
+	}
+
+	nextStatus := "pending"
+	if attempts >= maxAttempts {
+		nextStatus = "failed"
+	}
+	database.DB.Exec(`
+		UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = ?, response_body = ?,
+		last_error = ?, next_attempt_at = ? WHERE id = ?`,
+		nextStatus, attempts, status, body, errMsg, time.Now().Add(backoff.Delay(attempts)), d.id)
+	log.Printf("Webhook投递失败，投递ID: %d，第%d次尝试: %s", d.id, attempts, errMsg)
+}
+
+// 发送带签名的POST请求，返回响应状态码和(截断的)响应内容
+func post(d pendingDelivery) (int, string, error) {
+	body := []byte(d.payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cv-portfolio-webhook/1.0")
+	req.Header.Set("X-Webhook-Event", d.eventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(d.id))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(d.secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return resp.StatusCode, "", fmt.Errorf("读取响应失败: %v", err)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/events"
+)
+
+// 记录收到的回调请求，按预设的状态码依次响应
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: string(body)})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, "status "+strconv.Itoa(status))
+}
+
+func setupDatabase(t *testing.T) {
+	t.Helper()
+	t.Setenv("DATA_DIR", t.TempDir())
+	if err := database.SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+}
+
+type deliveryRow struct {
+	status        string
+	attempts      int
+	responseCode  int
+	lastError     string
+	nextAttemptAt time.Time
+}
+
+func loadDelivery(t *testing.T, id int64) deliveryRow {
+	t.Helper()
+	var d deliveryRow
+	var code *int
+	var lastError *string
+	err := database.DB.QueryRow(`
+		SELECT status, attempts, response_status, last_error, next_attempt_at FROM webhook_deliveries WHERE id = ?`, id).
+		Scan(&d.status, &d.attempts, &code, &lastError, &d.nextAttemptAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != nil {
+		d.responseCode = *code
+	}
+	if lastError != nil {
+		d.lastError = *lastError
+	}
+	return d
+}
+
+// 让等待重试的投递立即到期
+func makeDue(t *testing.T) {
+	t.Helper()
+	if _, err := database.DB.Exec("UPDATE webhook_deliveries SET next_attempt_at = ?", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeliveryRetriesSignsAndRedelivers(t *testing.T) {
+	setupDatabase(t)
+	recv := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	const secret = "s3cret"
+	if _, err := database.DB.Exec(`INSERT INTO webhooks (url, secret, events, active) VALUES (?, ?, ?, 1)`,
+		server.URL, secret, `["project.*"]`); err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue(events.Event{ID: 41, Type: "message.received", Data: map[string]int{"id": 1}})
+	enqueue(events.Event{ID: 42, Type: events.ProjectCreated, Data: map[string]int{"id": 7}})
+	var count int
+	database.DB.QueryRow("SELECT COUNT(*) FROM webhook_deliveries").Scan(&count)
+	if count != 1 {
+		t.Fatalf("只有匹配订阅的事件应创建投递记录，实际%d条", count)
+	}
+	var id int64
+	database.DB.QueryRow("SELECT id FROM webhook_deliveries").Scan(&id)
+
+	// 第一次返回500：保持待投递，按退避时间安排下次重试
+	before := time.Now()
+	processDeliveries()
+	d := loadDelivery(t, id)
+	if d.status != "pending" || d.attempts != 1 || d.responseCode != 500 || d.lastError != "HTTP 500" {
+		t.Fatalf("第一次失败后的记录 = %+v", d)
+	}
+	if wait := d.nextAttemptAt.Sub(before); wait < backoff.Base || wait > backoff.Base+5*time.Second {
+		t.Errorf("第一次重试等待%v，期望约%v", wait, backoff.Base)
+	}
+
+	// 未到期时不会重试
+	processDeliveries()
+	if len(recv.requests) != 1 {
+		t.Fatalf("未到重试时间时不应投递，实际请求%d次", len(recv.requests))
+	}
+
+	// 第二次返回502：等待时间翻倍
+	makeDue(t)
+	before = time.Now()
+	processDeliveries()
+	d = loadDelivery(t, id)
+	if d.status != "pending" || d.attempts != 2 || d.responseCode != 502 {
+		t.Fatalf("第二次失败后的记录 = %+v", d)
+	}
+	if wait := d.nextAttemptAt.Sub(before); wait < 2*backoff.Base || wait > 2*backoff.Base+5*time.Second {
+		t.Errorf("第二次重试等待%v，期望约%v", wait, 2*backoff.Base)
+	}
+
+	// 第三次成功
+	makeDue(t)
+	processDeliveries()
+	d = loadDelivery(t, id)
+	if d.status != "success" || d.attempts != 3 || d.responseCode != 200 || d.lastError != "" {
+		t.Fatalf("成功后的记录 = %+v", d)
+	}
+
+	// 每次请求都带有可校验的签名，且内容相同
+	for i, req := range recv.requests {
+		timestamp := req.header.Get("X-Webhook-Timestamp")
+		want := "sha256=" + Sign(secret, timestamp, []byte(req.body))
+		if got := req.header.Get("X-Webhook-Signature"); got != want {
+			t.Errorf("第%d次请求签名 = %q, 期望 %q", i+1, got, want)
+		}
+		if req.header.Get("X-Webhook-Event") != events.ProjectCreated {
+			t.Errorf("第%d次请求事件类型 = %q", i+1, req.header.Get("X-Webhook-Event"))
+		}
+		if req.header.Get("X-Webhook-Delivery") != strconv.FormatInt(id, 10) {
+			t.Errorf("第%d次请求投递ID = %q", i+1, req.header.Get("X-Webhook-Delivery"))
+		}
+		if req.body != recv.requests[0].body || !strings.Contains(req.body, `"id":7`) {
+			t.Errorf("第%d次请求内容 = %s", i+1, req.body)
+		}
+	}
+
+	// 重新投递创建新的记录并以原内容发送
+	newID, err := Redeliver(int(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newID == id {
+		t.Fatal("重新投递应创建新的投递记录")
+	}
+	processDeliveries()
+	if d := loadDelivery(t, newID); d.status != "success" || d.attempts != 1 {
+		t.Fatalf("重新投递的记录 = %+v", d)
+	}
+	last := recv.requests[len(recv.requests)-1]
+	if last.body != recv.requests[0].body || last.header.Get("X-Webhook-Delivery") != strconv.FormatInt(newID, 10) {
+		t.Errorf("重新投递的请求 = %s %v", last.body, last.header)
+	}
+	if _, err := Redeliver(9999); err == nil {
+		t.Error("不存在的投递记录应返回错误")
+	}
+}
+
+func TestDeliveryGivesUpAfterMaxAttempts(t *testing.T) {
+	setupDatabase(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	database.DB.Exec(`INSERT INTO webhooks (url, secret, events, active) VALUES (?, 'x', '["*"]', 1)`, server.URL)
+	enqueue(events.Event{ID: 1, Type: events.ProjectUpdated})
+
+	for i := 0; i < maxAttempts; i++ {
+		makeDue(t)
+		processDeliveries()
+	}
+	var id int64
+	database.DB.QueryRow("SELECT id FROM webhook_deliveries").Scan(&id)
+	if d := loadDelivery(t, id); d.status != "failed" || d.attempts != maxAttempts {
+		t.Fatalf("达到最大次数后的记录 = %+v", d)
+	}
+}
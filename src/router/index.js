@@ -1,4 +1,5 @@
 import { createRouter, createWebHistory } from 'vue-router';
+import { tenantPrefix } from '../services/api';
 import App from '../App.vue';
 import Login from '../views/Login.vue';
 import VisitorVerification from '../components/VisitorVerification.vue';
@@ -29,7 +30,7 @@ const routes = [
 ];
 
 const router = createRouter({
-  history: createWebHistory(),
+  history: createWebHistory(tenantPrefix || undefined),
   routes,
   scrollBehavior(to, from, savedPosition) {
     if (to.hash) {
@@ -1,8 +1,11 @@
 import axios from 'axios';
 
+// 多租户部署时通过 /t/<租户标识>/ 访问，接口和页面路由都要带上该前缀
+export const tenantPrefix = (window.location.pathname.match(/^\/t\/[a-z0-9-]+/) || [''])[0];
+
 // 创建axios实例
 const api = axios.create({
-  baseURL: '/api',
+  baseURL: tenantPrefix + '/api',
   timeout: 10000,
   headers: {
     'Content-Type': 'application/json',
@@ -70,6 +73,66 @@ const apiService = {
   deleteVisitorAccess(id) {
     return api.delete(`/admin/visitor/access/${id}`);
   },
+  setVisitorAccessVariant(id, variantId) {
+    return api.put(`/admin/visitor/access/${id}/variant`, { variant_id: variantId });
+  },
+  createShareLink(data) {
+    return api.post('/admin/visitor/share-links', data);
+  },
+  
+  // 简历版本相关
+  getVariants() {
+    return api.get('/admin/variants');
+  },
+  getVariant(id) {
+    return api.get(`/admin/variants/${id}`);
+  },
+  createVariant(data) {
+    return api.post('/admin/variants', data);
+  },
+  updateVariant(id, data) {
+    return api.put(`/admin/variants/${id}`, data);
+  },
+  deleteVariant(id) {
+    return api.delete(`/admin/variants/${id}`);
+  },
+  
+  // 求职申请相关
+  getApplications(params) {
+    return api.get('/admin/applications', { params });
+  },
+  getApplication(id) {
+    return api.get(`/admin/applications/${id}`);
+  },
+  createApplication(data) {
+    return api.post('/admin/applications', data);
+  },
+  updateApplication(id, data) {
+    return api.put(`/admin/applications/${id}`, data);
+  },
+  deleteApplication(id) {
+    return api.delete(`/admin/applications/${id}`);
+  },
+  changeApplicationStage(id, stage, note) {
+    return api.post(`/admin/applications/${id}/stage`, { stage, note });
+  },
+  getApplicationAnalytics(id, params) {
+    return api.get(`/admin/applications/${id}/analytics`, { params });
+  },
+  
+  // 多语言相关
+  getLocales() {
+    return api.get('/locales');
+  },
+  getTranslationReport(locale) {
+    return api.get('/admin/translations/report', { params: { locale } });
+  },
+  getTranslations(type, id, locale) {
+    return api.get(`/admin/translations/${type}/${id}/${locale}`);
+  },
+  updateTranslations(type, id, locale, data) {
+    return api.put(`/admin/translations/${type}/${id}/${locale}`, data);
+  },
   
   // 个人信息相关
   getProfile() {
@@ -95,6 +158,9 @@ const apiService = {
   deleteSkill(id) {
     return api.delete(`/admin/skills/${id}`);
   },
+  acceptSuggestedSkillLevel(id) {
+    return api.post(`/admin/skills/${id}/accept-level`);
+  },
   
   // 工作经历相关
   getExperiences() {
@@ -147,6 +213,152 @@ const apiService = {
     return api.delete(`/admin/certificates/${id}`);
   },
   
+  // 全文搜索
+  search(q, params) {
+    return api.get('/search', { params: { ...params, q } });
+  },
+  rebuildSearchIndex() {
+    return api.post('/admin/search/reindex');
+  },
+  
+  // 技术标签相关
+  getTechnologies(params) {
+    return api.get('/technologies', { params });
+  },
+  getTechnology(slug) {
+    return api.get(`/technologies/${encodeURIComponent(slug)}`);
+  },
+  getAdminTechnologies(params) {
+    return api.get('/admin/technologies', { params });
+  },
+  createTechnology(data) {
+    return api.post('/admin/technologies', data);
+  },
+  updateTechnology(id, data) {
+    return api.put(`/admin/technologies/${id}`, data);
+  },
+  deleteTechnology(id) {
+    return api.delete(`/admin/technologies/${id}`);
+  },
+  mergeTechnology(id, sourceId) {
+    return api.post(`/admin/technologies/${id}/merge`, { source_id: sourceId });
+  },
+  
+  // 职位描述匹配
+  matchJobDescription(text) {
+    return api.post('/admin/job-match', { text });
+  },
+  
+  // 推荐信相关
+  getTestimonials(params) {
+    return api.get('/testimonials', { params });
+  },
+  getTestimonialInvite(token) {
+    return api.get(`/testimonials/invite/${token}`);
+  },
+  submitTestimonial(token, data) {
+    return api.post(`/testimonials/invite/${token}`, data);
+  },
+  getAdminTestimonials(params) {
+    return api.get('/admin/testimonials', { params });
+  },
+  updateTestimonial(id, data) {
+    return api.put(`/admin/testimonials/${id}`, data);
+  },
+  reviewTestimonial(id, data) {
+    return api.post(`/admin/testimonials/${id}/review`, data);
+  },
+  deleteTestimonial(id) {
+    return api.delete(`/admin/testimonials/${id}`);
+  },
+  getTestimonialInvites() {
+    return api.get('/admin/testimonials/invites');
+  },
+  createTestimonialInvite(data) {
+    return api.post('/admin/testimonials/invites', data);
+  },
+  deleteTestimonialInvite(id) {
+    return api.delete(`/admin/testimonials/invites/${id}`);
+  },
+  
+  // 联系留言相关
+  sendContactMessage(data) {
+    return api.post('/contact', data);
+  },
+  getMessages(params) {
+    return api.get('/admin/messages', { params });
+  },
+  getMessage(id) {
+    return api.get(`/admin/messages/${id}`);
+  },
+  markMessageRead(id, read) {
+    return api.put(`/admin/messages/${id}/read`, { read });
+  },
+  archiveMessage(id, archived) {
+    return api.put(`/admin/messages/${id}/archive`, { archived });
+  },
+  deleteMessage(id) {
+    return api.delete(`/admin/messages/${id}`);
+  },
+  
+  // 邮件队列
+  getMailQueue(params) {
+    return api.get('/admin/mail/queue', { params });
+  },
+  retryMail(id) {
+    return api.post(`/admin/mail/queue/${id}/retry`);
+  },
+  
+  // 访客统计
+  getVisitAnalytics(params) {
+    return api.get('/admin/analytics/visits', { params });
+  },
+  getVisitSessions(params) {
+    return api.get('/admin/analytics/sessions', { params });
+  },
+  
+  // 实时事件流：EventSource无法设置请求头，先换取一次性票据再通过参数传递。
+  // 票据只能使用一次，断线后需重新调用本方法订阅
+  async subscribeAdminEvents(types) {
+    const response = await api.post('/admin/events/ticket');
+    const params = new URLSearchParams({ ticket: response.data.ticket });
+    if (types) {
+      params.set('types', types);
+    }
+    return new EventSource(`${tenantPrefix}/api/admin/events?${params.toString()}`);
+  },
+  
+  // Webhook管理
+  getWebhooks() {
+    return api.get('/admin/webhooks');
+  },
+  createWebhook(data) {
+    return api.post('/admin/webhooks', data);
+  },
+  updateWebhook(id, data) {
+    return api.put(`/admin/webhooks/${id}`, data);
+  },
+  deleteWebhook(id) {
+    return api.delete(`/admin/webhooks/${id}`);
+  },
+  getWebhookDeliveries(id, params) {
+    return api.get(`/admin/webhooks/${id}/deliveries`, { params });
+  },
+  redeliverWebhook(deliveryId) {
+    return api.post(`/admin/webhooks/deliveries/${deliveryId}/redeliver`);
+  },
+  
+  // 静态站点导出
+  getStaticSiteStatus() {
+    return api.get('/admin/export/static');
+  },
+  regenerateStaticSite() {
+    return api.post('/admin/export/static');
+  },
+  downloadStaticSite(params) {
+    return api.get('/admin/export/static.zip', { params, responseType: 'blob', timeout: 60000 });
+  },
+  
   // 系统设置
   changePassword(data) {
     return api.put('/admin/settings/password', data);